@@ -34,6 +34,8 @@ type Atlas struct {
 	maps map[string]Map
 	// holds a reference to the cache backend
 	cacher cache.Interface
+	// composite map aliases
+	composites map[string]Composite
 }
 
 // AllMaps returns a slice of all maps contained in the Atlas so far.
@@ -55,6 +57,7 @@ func (a *Atlas) AllMaps() []Map {
 		layers := make([]Layer, len(m.Layers))
 		copy(layers, m.Layers)
 		m.Layers = layers
+		m.atlas = a
 
 		maps = append(maps, m)
 	}
@@ -141,6 +144,7 @@ func (a *Atlas) Map(mapName string) (Map, error) {
 	layers := make([]Layer, len(m.Layers))
 	copy(layers, m.Layers)
 	m.Layers = layers
+	m.atlas = a
 
 	return m, nil
 }
@@ -199,6 +203,11 @@ func AddMap(m Map) {
 	defaultAtlas.AddMap(m)
 }
 
+// AllComposites returns all the composite map aliases registered with defaultAtlas
+func AllComposites() []Composite {
+	return defaultAtlas.AllComposites()
+}
+
 // GetCache returns the registered cache for defaultAtlas, if one is registered, otherwise nil
 func GetCache() cache.Interface {
 	return defaultAtlas.GetCache()
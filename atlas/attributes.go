@@ -0,0 +1,253 @@
+package atlas
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	CastString = "string"
+	CastInt    = "int"
+	CastFloat  = "float"
+	CastBool   = "bool"
+)
+
+// Attributes is a pipeline of transformations applied to the tags of a layer's
+// features before they're encoded. The steps are applied in the following order:
+// renames, value mappings, casts, rounding and finally the key filters. All steps
+// after the renames use the renamed keys.
+type Attributes struct {
+	// Include is a list of keys to keep. All other keys are dropped. Empty keeps all keys.
+	Include []string
+	// Exclude is a list of keys to drop
+	Exclude []string
+	// Rename maps existing keys to new keys. The renames are applied at once to the
+	// original tags, so keys can be swapped. When several keys are renamed to the same
+	// key, the value of the last key in sorted order is kept.
+	Rename map[string]string
+	// Cast maps keys to the type (CastString, CastInt, CastFloat or CastBool) their
+	// values are converted to. Values which can't be converted are dropped.
+	Cast map[string]string
+	// Map maps keys to a table of replacement values. Values are looked up by their string form.
+	Map map[string]map[string]interface{}
+	// Round maps keys to the number of decimal places float values are rounded to
+	Round map[string]int
+	// Zooms are additional key filters for zoom ranges
+	Zooms []AttributeZoom
+}
+
+// AttributeZoom filters the keys of features in tiles between MinZoom and MaxZoom (inclusive)
+type AttributeZoom struct {
+	MinZoom uint
+	MaxZoom uint
+	Include []string
+	Exclude []string
+}
+
+// Validate checks the casts are supported and the zoom ranges are valid
+func (a *Attributes) Validate() error {
+	for k, typ := range a.Cast {
+		switch typ {
+		case CastString, CastInt, CastFloat, CastBool:
+		default:
+			return ErrInvalidAttributeCast{Key: k, Type: typ}
+		}
+	}
+
+	for _, z := range a.Zooms {
+		if z.MinZoom > z.MaxZoom {
+			return fmt.Errorf("atlas: attribute zoom range min (%v) is greater than max (%v)", z.MinZoom, z.MaxZoom)
+		}
+	}
+
+	return nil
+}
+
+// Apply transforms the tags of a feature in a tile at zoom in place
+func (a *Attributes) Apply(tags map[string]interface{}, zoom uint) {
+	a.rename(tags)
+
+	for k, table := range a.Map {
+		v, ok := tags[k]
+		if !ok {
+			continue
+		}
+		if mapped, ok := table[fmt.Sprint(v)]; ok {
+			tags[k] = mapped
+		}
+	}
+
+	for k, typ := range a.Cast {
+		v, ok := tags[k]
+		if !ok {
+			continue
+		}
+		if cv, ok := castValue(v, typ); ok {
+			tags[k] = cv
+		} else {
+			delete(tags, k)
+		}
+	}
+
+	for k, places := range a.Round {
+		switch v := tags[k].(type) {
+		case float64:
+			tags[k] = roundFloat(v, places)
+		case float32:
+			tags[k] = roundFloat(float64(v), places)
+		}
+	}
+
+	filterKeys(tags, a.Include, a.Exclude)
+
+	for _, z := range a.Zooms {
+		if zoom >= z.MinZoom && zoom <= z.MaxZoom {
+			filterKeys(tags, z.Include, z.Exclude)
+		}
+	}
+}
+
+// rename renames the keys of tags. The values are read from the tags before any key is
+// renamed, and set in the sorted order of their original keys so the result doesn't
+// depend on the order of the Rename map.
+func (a *Attributes) rename(tags map[string]interface{}) {
+	if len(a.Rename) == 0 {
+		return
+	}
+
+	from := make([]string, 0, len(a.Rename))
+	for k := range a.Rename {
+		if _, ok := tags[k]; ok {
+			from = append(from, k)
+		}
+	}
+	sort.Strings(from)
+
+	values := make([]interface{}, len(from))
+	for i, k := range from {
+		values[i] = tags[k]
+		delete(tags, k)
+	}
+	for i, k := range from {
+		tags[a.Rename[k]] = values[i]
+	}
+}
+
+// filterKeys drops the keys not in include (if include is not empty) and the keys in exclude
+func filterKeys(tags map[string]interface{}, include, exclude []string) {
+	if len(include) > 0 {
+		for k := range tags {
+			if !containsString(include, k) {
+				delete(tags, k)
+			}
+		}
+	}
+
+	for _, k := range exclude {
+		delete(tags, k)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
+
+func roundFloat(v float64, places int) float64 {
+	pow := math.Pow(10, float64(places))
+	return math.Round(v*pow) / pow
+}
+
+// castValue converts v to typ. false is returned if v can't be converted.
+func castValue(v interface{}, typ string) (interface{}, bool) {
+	switch typ {
+	case CastString:
+		switch val := v.(type) {
+		case string:
+			return val, true
+		case float64:
+			return strconv.FormatFloat(val, 'f', -1, 64), true
+		default:
+			return fmt.Sprint(val), true
+		}
+
+	case CastInt:
+		switch val := v.(type) {
+		case int64:
+			return val, true
+		case int:
+			return int64(val), true
+		case int32:
+			return int64(val), true
+		case uint64:
+			return val, true
+		case float64:
+			return int64(val), true
+		case float32:
+			return int64(val), true
+		case bool:
+			if val {
+				return int64(1), true
+			}
+			return int64(0), true
+		case string:
+			if i, err := strconv.ParseInt(val, 10, 64); err == nil {
+				return i, true
+			}
+			// allow decimal strings, truncating the fraction
+			if f, err := strconv.ParseFloat(val, 64); err == nil {
+				return int64(f), true
+			}
+		}
+
+	case CastFloat:
+		switch val := v.(type) {
+		case float64:
+			return val, true
+		case float32:
+			return float64(val), true
+		case int64:
+			return float64(val), true
+		case int:
+			return float64(val), true
+		case int32:
+			return float64(val), true
+		case uint64:
+			return float64(val), true
+		case string:
+			if f, err := strconv.ParseFloat(val, 64); err == nil {
+				return f, true
+			}
+		}
+
+	case CastBool:
+		switch val := v.(type) {
+		case bool:
+			return val, true
+		case int64:
+			return val != 0, true
+		case uint64:
+			return val != 0, true
+		case float64:
+			return val != 0, true
+		case string:
+			switch val {
+			case "yes", "y", "on":
+				return true, true
+			case "no", "n", "off":
+				return false, true
+			}
+			if b, err := strconv.ParseBool(val); err == nil {
+				return b, true
+			}
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,134 @@
+package atlas_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola/atlas"
+)
+
+func TestAttributesApply(t *testing.T) {
+	type tcase struct {
+		attrs    atlas.Attributes
+		zoom     uint
+		tags     map[string]interface{}
+		expected map[string]interface{}
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		tc.attrs.Apply(tc.tags, tc.zoom)
+
+		if !reflect.DeepEqual(tc.tags, tc.expected) {
+			t.Errorf("tags, expected %#v got %#v", tc.expected, tc.tags)
+		}
+	}
+
+	tests := map[string]tcase{
+		"include": {
+			attrs:    atlas.Attributes{Include: []string{"name"}},
+			tags:     map[string]interface{}{"name": "a", "class": "b"},
+			expected: map[string]interface{}{"name": "a"},
+		},
+		"exclude": {
+			attrs:    atlas.Attributes{Exclude: []string{"class"}},
+			tags:     map[string]interface{}{"name": "a", "class": "b"},
+			expected: map[string]interface{}{"name": "a"},
+		},
+		"rename then include": {
+			attrs: atlas.Attributes{
+				Rename:  map[string]string{"name_en": "name"},
+				Include: []string{"name"},
+			},
+			tags:     map[string]interface{}{"name_en": "a", "class": "b"},
+			expected: map[string]interface{}{"name": "a"},
+		},
+		"rename swap": {
+			attrs: atlas.Attributes{
+				Rename: map[string]string{"a": "b", "b": "a"},
+			},
+			tags:     map[string]interface{}{"a": 1, "b": 2},
+			expected: map[string]interface{}{"a": 2, "b": 1},
+		},
+		"rename chain": {
+			attrs: atlas.Attributes{
+				Rename: map[string]string{"a": "b", "b": "c"},
+			},
+			tags:     map[string]interface{}{"a": 1, "b": 2},
+			expected: map[string]interface{}{"b": 1, "c": 2},
+		},
+		"rename to the same key": {
+			attrs: atlas.Attributes{
+				Rename: map[string]string{"b": "name", "a": "name", "c": "name"},
+			},
+			tags:     map[string]interface{}{"a": 1, "b": 2},
+			expected: map[string]interface{}{"name": 2},
+		},
+		"cast": {
+			attrs: atlas.Attributes{
+				Cast: map[string]string{
+					"lanes":  atlas.CastInt,
+					"width":  atlas.CastFloat,
+					"oneway": atlas.CastBool,
+					"ref":    atlas.CastString,
+					"bad":    atlas.CastInt,
+				},
+			},
+			tags: map[string]interface{}{
+				"lanes":  "2",
+				"width":  int64(3),
+				"oneway": "yes",
+				"ref":    int64(12),
+				"bad":    "abc",
+			},
+			expected: map[string]interface{}{
+				"lanes":  int64(2),
+				"width":  3.0,
+				"oneway": true,
+				"ref":    "12",
+			},
+		},
+		"value map": {
+			attrs: atlas.Attributes{
+				Map: map[string]map[string]interface{}{
+					"highway": {"motorway": "major", "trunk": "major"},
+					"level":   {"1": "low"},
+				},
+			},
+			tags:     map[string]interface{}{"highway": "trunk", "level": int64(1), "other": "x"},
+			expected: map[string]interface{}{"highway": "major", "level": "low", "other": "x"},
+		},
+		"round": {
+			attrs:    atlas.Attributes{Round: map[string]int{"height": 1, "name": 1}},
+			tags:     map[string]interface{}{"height": 12.345, "name": "a"},
+			expected: map[string]interface{}{"height": 12.3, "name": "a"},
+		},
+		"zoom in range": {
+			attrs: atlas.Attributes{
+				Zooms: []atlas.AttributeZoom{{MinZoom: 0, MaxZoom: 8, Include: []string{"name"}}},
+			},
+			zoom:     5,
+			tags:     map[string]interface{}{"name": "a", "class": "b"},
+			expected: map[string]interface{}{"name": "a"},
+		},
+		"zoom out of range": {
+			attrs: atlas.Attributes{
+				Zooms: []atlas.AttributeZoom{{MinZoom: 0, MaxZoom: 8, Include: []string{"name"}}},
+			},
+			zoom:     10,
+			tags:     map[string]interface{}{"name": "a", "class": "b"},
+			expected: map[string]interface{}{"name": "a", "class": "b"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestAttributesValidate(t *testing.T) {
+	attrs := atlas.Attributes{Cast: map[string]string{"name": "date"}}
+	if _, ok := attrs.Validate().(atlas.ErrInvalidAttributeCast); !ok {
+		t.Errorf("error, expected ErrInvalidAttributeCast got %v", attrs.Validate())
+	}
+}
@@ -0,0 +1,253 @@
+package atlas
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/mvt"
+)
+
+const (
+	ClusterAggregateSum = "sum"
+	ClusterAggregateMin = "min"
+	ClusterAggregateMax = "max"
+	ClusterAggregateAvg = "avg"
+)
+
+const (
+	// DefaultClusterRadius is the default cluster grid cell size in tile pixels
+	DefaultClusterRadius = 64
+	// ClusterCountTag is the tag holding the number of points in a cluster
+	ClusterCountTag = "point_count"
+	// ClusterIDBase is set on the ids of clusters to keep them apart from the ids of features.
+	// A cluster whose id is also the id of a feature of the layer is encoded without an id.
+	ClusterIDBase = uint64(1) << 63
+)
+
+// Cluster groups the point features of a layer into a grid of cells. The points in
+// each cell are encoded as a single point at their centroid with a point_count tag and
+// the configured aggregates. Cells holding a single point are encoded as the original feature.
+//
+// The grid is aligned to the world pixel grid at the tile's zoom so a cell is the same
+// for every tile. When the Radius is no larger than the tile buffer every cell overlapping
+// a tile is fully inside the buffered area, so clusters agree across tile edges.
+// Cluster ids are the cell's index in the grid with ClusterIDBase set.
+type Cluster struct {
+	// points are clustered in tiles with a zoom at or below MaxZoom
+	MaxZoom uint
+	// Radius is the size of the grid cells in tile pixels (the same units as the tile buffer)
+	Radius float64
+	// Aggregates of numeric tags added to each cluster
+	Aggregates []ClusterAggregate
+}
+
+// ClusterAggregate computes the sum, min, max or avg of a numeric tag of the clustered points
+type ClusterAggregate struct {
+	// Tag is the key of the numeric tag to aggregate
+	Tag string
+	// Func is one of ClusterAggregateSum, ClusterAggregateMin, ClusterAggregateMax or ClusterAggregateAvg
+	Func string
+	// Name is the key of the aggregate tag. Defaults to Func_Tag (i.e. sum_injured)
+	Name string
+}
+
+// Validate checks the radius and aggregates
+func (c *Cluster) Validate() error {
+	if c.Radius <= 0 {
+		return fmt.Errorf("atlas: cluster radius (%v) must be greater than 0", c.Radius)
+	}
+
+	for _, a := range c.Aggregates {
+		switch a.Func {
+		case ClusterAggregateSum, ClusterAggregateMin, ClusterAggregateMax, ClusterAggregateAvg:
+		default:
+			return ErrInvalidClusterAggregate{Tag: a.Tag, Func: a.Func}
+		}
+		if a.Tag == "" {
+			return fmt.Errorf("atlas: cluster aggregate (%v) is missing a tag", a.Func)
+		}
+	}
+
+	return nil
+}
+
+// clusterer collects the points of a layer in a tile
+type clusterer struct {
+	cluster *Cluster
+	z, x, y uint
+	extent  float64
+	cells   map[[2]int64]*clusterCell
+	// ids of the layer's features in the cluster id range
+	ids map[uint64]bool
+}
+
+type clusterCell struct {
+	features []mvt.Feature
+	sumX     float64
+	sumY     float64
+}
+
+// newClusterer returns a clusterer for the tile, or nil if the layer is not clustered at the tile's zoom
+func newClusterer(c *Cluster, z, x, y uint, extent float64) *clusterer {
+	if c == nil || z > c.MaxZoom {
+		return nil
+	}
+
+	return &clusterer{
+		cluster: c,
+		z:       z,
+		x:       x,
+		y:       y,
+		extent:  extent,
+		cells:   map[[2]int64]*clusterCell{},
+		ids:     map[uint64]bool{},
+	}
+}
+
+// add collects point features. false is returned for other geometries which are not clustered.
+// geometries are expected to be in webmercator.
+func (c *clusterer) add(f mvt.Feature) bool {
+	if f.ID != nil && *f.ID&ClusterIDBase != 0 {
+		c.ids[*f.ID] = true
+	}
+
+	pt, ok := f.Geometry.(tegola.Point)
+	if !ok {
+		return false
+	}
+
+	px, py := worldPixel(pt.X(), pt.Y(), c.z, c.extent)
+	key := [2]int64{
+		int64(math.Floor(px / c.cluster.Radius)),
+		int64(math.Floor(py / c.cluster.Radius)),
+	}
+
+	cell, ok := c.cells[key]
+	if !ok {
+		cell = &clusterCell{}
+		c.cells[key] = cell
+	}
+	cell.features = append(cell.features, f)
+	cell.sumX += pt.X()
+	cell.sumY += pt.Y()
+
+	return true
+}
+
+// webMercatorMax is the max webmercator coordinate
+const webMercatorMax = 20037508.34
+
+// worldPixel converts webmercator coordinates into pixels of the world at zoom z for tiles of extent pixels
+func worldPixel(x, y float64, z uint, extent float64) (float64, float64) {
+	size := math.Exp2(float64(z)) * extent
+	return (x + webMercatorMax) / (2 * webMercatorMax) * size, (webMercatorMax - y) / (2 * webMercatorMax) * size
+}
+
+// fromWorldPixel converts pixels of the world at zoom z into webmercator coordinates
+func fromWorldPixel(px, py float64, z uint, extent float64) (float64, float64) {
+	size := math.Exp2(float64(z)) * extent
+	return px/size*2*webMercatorMax - webMercatorMax, webMercatorMax - py/size*2*webMercatorMax
+}
+
+// features returns the clusters of the cells overlapping the tile, ordered by cell
+func (c *clusterer) features() []mvt.Feature {
+	radius := c.cluster.Radius
+	// the tile in world pixels
+	minX, minY := float64(c.x)*c.extent, float64(c.y)*c.extent
+	maxX, maxY := minX+c.extent, minY+c.extent
+	// cells per row of the world
+	cols := int64(math.Ceil(math.Exp2(float64(c.z)) * c.extent / radius))
+
+	keys := make([][2]int64, 0, len(c.cells))
+	for k := range c.cells {
+		// cells entirely in the buffer may be missing points, they're encoded by the neighboring tile
+		cx, cy := float64(k[0])*radius, float64(k[1])*radius
+		if cx+radius <= minX || cx >= maxX || cy+radius <= minY || cy >= maxY {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][1] != keys[j][1] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+
+	features := make([]mvt.Feature, 0, len(keys))
+	for _, k := range keys {
+		cell := c.cells[k]
+		if len(cell.features) == 1 {
+			features = append(features, cell.features[0])
+			continue
+		}
+
+		n := float64(len(cell.features))
+		tags := map[string]interface{}{
+			ClusterCountTag: int64(len(cell.features)),
+		}
+		for _, a := range c.cluster.Aggregates {
+			if v, ok := aggregate(a, cell.features); ok {
+				name := a.Name
+				if name == "" {
+					name = a.Func + "_" + a.Tag
+				}
+				tags[name] = v
+			}
+		}
+
+		cluster := mvt.Feature{
+			Tags:     tags,
+			Geometry: basic.Point{cell.sumX / n, cell.sumY / n},
+		}
+		// a stable id for the cell so the cluster has the same id in every tile
+		if id := ClusterIDBase | uint64(k[1]*cols+k[0]); !c.ids[id] {
+			cluster.ID = &id
+		}
+
+		features = append(features, cluster)
+	}
+
+	return features
+}
+
+// aggregate computes an aggregate of the numeric values of a tag. false is returned if
+// none of the features have a numeric value for the tag.
+func aggregate(a ClusterAggregate, features []mvt.Feature) (float64, bool) {
+	var (
+		result float64
+		count  int
+	)
+
+	for _, f := range features {
+		v, ok := toFloat(f.Tags[a.Tag])
+		if !ok {
+			continue
+		}
+
+		switch {
+		case count == 0:
+			result = v
+		case a.Func == ClusterAggregateMin:
+			result = math.Min(result, v)
+		case a.Func == ClusterAggregateMax:
+			result = math.Max(result, v)
+		default:
+			// sum and avg
+			result += v
+		}
+		count++
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+	if a.Func == ClusterAggregateAvg {
+		result /= float64(count)
+	}
+
+	return result, true
+}
@@ -0,0 +1,147 @@
+package atlas
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/mvt"
+)
+
+func TestClustererFeatures(t *testing.T) {
+	type tcase struct {
+		cluster  Cluster
+		z, x, y  uint
+		points   [][2]float64
+		tags     []map[string]interface{}
+		expected []map[string]interface{}
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		c := newClusterer(&tc.cluster, tc.z, tc.x, tc.y, 4096)
+		if c == nil {
+			if tc.expected != nil {
+				t.Fatalf("expected a clusterer got nil")
+			}
+			return
+		}
+
+		for i, pt := range tc.points {
+			f := mvt.Feature{
+				Tags:     tc.tags[i],
+				Geometry: basic.Point(pt),
+			}
+			if !c.add(f) {
+				t.Fatalf("point not added")
+			}
+		}
+		if c.add(mvt.Feature{Geometry: basic.Line{{0, 0}, {1, 1}}}) {
+			t.Errorf("line added, expected only points to be clustered")
+		}
+
+		var got []map[string]interface{}
+		for _, f := range c.features() {
+			got = append(got, f.Tags)
+		}
+
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("tags, expected %v got %v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"cluster and single point": {
+			cluster: Cluster{
+				Radius: 64,
+				Aggregates: []ClusterAggregate{
+					{Tag: "n", Func: ClusterAggregateSum},
+					{Tag: "n", Func: ClusterAggregateAvg, Name: "mean"},
+					{Tag: "n", Func: ClusterAggregateMax},
+					{Tag: "missing", Func: ClusterAggregateMin},
+				},
+			},
+			points: [][2]float64{{100, -100}, {200, -200}, {300, -300}, {1e7, 1e7}},
+			tags: []map[string]interface{}{
+				{"n": int64(1)},
+				{"n": 2.0},
+				{"n": "6"},
+				{"name": "single"},
+			},
+			expected: []map[string]interface{}{
+				{"name": "single"},
+				{ClusterCountTag: int64(3), "sum_n": 9.0, "mean": 3.0, "max_n": 6.0},
+			},
+		},
+		"cells outside the tile are dropped": {
+			cluster: Cluster{MaxZoom: 1, Radius: 64},
+			z:       1,
+			points:  [][2]float64{{-1e7, 1e7}, {-1e7 + 10, 1e7}, {1e7, 1e7}, {1e7 + 10, 1e7}},
+			tags:    []map[string]interface{}{{}, {}, {}, {}},
+			expected: []map[string]interface{}{
+				{ClusterCountTag: int64(2)},
+			},
+		},
+		"above max zoom": {
+			cluster: Cluster{MaxZoom: 4, Radius: 64},
+			z:       5,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestClustererIDs(t *testing.T) {
+	type tcase struct {
+		ids      []uint64
+		expected *uint64
+	}
+
+	// the cell of the points at zoom 0 is (32, 32) in a grid of 64 columns
+	clusterID := ClusterIDBase | (32*64 + 32)
+
+	fn := func(t *testing.T, tc tcase) {
+		c := newClusterer(&Cluster{Radius: 64}, 0, 0, 0, 4096)
+
+		for i, pt := range [][2]float64{{100, -100}, {200, -200}} {
+			id := uint64(i + 1)
+			c.add(mvt.Feature{ID: &id, Geometry: basic.Point(pt)})
+		}
+		for i := range tc.ids {
+			c.add(mvt.Feature{ID: &tc.ids[i], Geometry: basic.Line{{0, 0}, {1, 1}}})
+		}
+
+		features := c.features()
+		if len(features) != 1 {
+			t.Fatalf("features, expected 1 got %v", len(features))
+		}
+
+		got := features[0].ID
+		switch {
+		case tc.expected == nil && got != nil:
+			t.Errorf("id, expected nil got %v", *got)
+		case tc.expected != nil && got == nil:
+			t.Errorf("id, expected %v got nil", *tc.expected)
+		case tc.expected != nil && *got != *tc.expected:
+			t.Errorf("id, expected %v got %v", *tc.expected, *got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"cluster id range": {
+			// the cell index is the id of a feature
+			ids:      []uint64{32*64 + 32},
+			expected: &clusterID,
+		},
+		"feature with the cluster id": {
+			ids: []uint64{clusterID},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
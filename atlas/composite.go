@@ -0,0 +1,255 @@
+package atlas
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola/mvt/vector_tile"
+)
+
+// Composite is a set of maps whose tiles are served as a single tile holding the layers of all the maps
+type Composite struct {
+	Name string
+	// Maps are the names of the maps, in the order their layers are added to the tile
+	Maps []string
+	// PrefixLayers prefixes the layer names with the name of their map and an underscore
+	// (i.e. transit_stops) so maps with the same layer names can be combined
+	PrefixLayers bool
+}
+
+// layerPrefix returns the prefix of the layers of the map
+func (c Composite) layerPrefix(mapName string) string {
+	if !c.PrefixLayers {
+		return ""
+	}
+	return mapName + "_"
+}
+
+// AddComposite registers a composite map alias. if the composite already exists it will be overwritten
+func (a *Atlas) AddComposite(c Composite) {
+	if a == nil {
+		// Use the default Atlas if a, is nil. This way the empty value is
+		// still useful.
+		defaultAtlas.AddComposite(c)
+		return
+	}
+	a.Lock()
+	defer a.Unlock()
+
+	if a.composites == nil {
+		a.composites = map[string]Composite{}
+	}
+
+	a.composites[c.Name] = c
+}
+
+// AllComposites returns the registered composite map aliases
+func (a *Atlas) AllComposites() []Composite {
+	if a == nil {
+		// Use the default Atlas if a, is nil. This way the empty value is
+		// still useful.
+		return defaultAtlas.AllComposites()
+	}
+
+	a.RLock()
+	defer a.RUnlock()
+
+	var composites []Composite
+	for _, c := range a.composites {
+		composites = append(composites, c)
+	}
+
+	return composites
+}
+
+// Composite looks up a composite map alias by name. A comma separated list of map
+// names (i.e. basemap,transit,poi) is a composite of the listed maps.
+func (a *Atlas) Composite(name string) (Composite, error) {
+	if a == nil {
+		// Use the default Atlas if a, is nil. This way the empty value is
+		// still useful.
+		return defaultAtlas.Composite(name)
+	}
+
+	if strings.Contains(name, ",") {
+		c := Composite{
+			Name: name,
+			Maps: strings.Split(name, ","),
+		}
+		for _, m := range c.Maps {
+			if _, err := a.Map(m); err != nil {
+				return Composite{}, err
+			}
+		}
+		return c, nil
+	}
+
+	a.RLock()
+	defer a.RUnlock()
+
+	c, ok := a.composites[name]
+	if !ok {
+		return Composite{}, ErrMapNotFound{
+			Name: name,
+		}
+	}
+
+	return c, nil
+}
+
+// CompositeMap returns a Map describing the composite. It holds the (prefixed) layers
+// of all the maps and the union of their bounds. It's used to describe the composite
+// and is not encoded itself, tiles are encoded with EncodeComposite.
+func (a *Atlas) CompositeMap(c Composite) (Map, error) {
+	cm := NewWebMercatorMap(c.Name)
+
+	// the map of each layer name, to find collisions
+	owners := map[string]string{}
+	var attributions []string
+
+	for i, name := range c.Maps {
+		m, err := a.Map(name)
+		if err != nil {
+			return Map{}, err
+		}
+
+		if i == 0 {
+			cm.Bounds = m.Bounds
+			cm.Center = m.Center
+			cm.SRID = m.SRID
+			cm.TileExtent = m.TileExtent
+			cm.TileBuffer = m.TileBuffer
+		} else if cm.Bounds != nil && m.Bounds != nil {
+			// a copy so the bounds of the first map aren't modified
+			bounds := cm.Bounds.Clone()
+			bounds.Add(m.Bounds)
+			cm.Bounds = bounds
+		}
+
+		if m.Attribution != "" && !containsString(attributions, m.Attribution) {
+			attributions = append(attributions, m.Attribution)
+		}
+
+		prefix := c.layerPrefix(name)
+		for _, l := range m.Layers {
+			if l.LabelPoints != nil {
+				lp := *l.LabelPoints
+				lp.Name = prefix + lp.LayerName(&l)
+				l.LabelPoints = &lp
+			}
+			l.Name = prefix + l.MVTName()
+
+			// layers of a map may share a name at different zooms
+			if owner, ok := owners[l.Name]; ok && owner != name {
+				return Map{}, ErrCompositeLayerCollision{Layer: l.Name, Map1: owner, Map2: name}
+			}
+			owners[l.Name] = name
+
+			cm.Layers = append(cm.Layers, l)
+		}
+	}
+
+	cm.Attribution = strings.Join(attributions, ", ")
+
+	return cm, nil
+}
+
+// EncodeComposite encodes the tile of each of the composite's maps, reading them from the
+// cache if there is one, and merges their layers into a single tile. The layer geometries are
+// not re-encoded. If layerNames are provided only the layers with those (prefixed) names are
+// added, in the order of layerNames. If debug is true the debug layers are added to the tile.
+func (a *Atlas) EncodeComposite(ctx context.Context, c Composite, tile *slippy.Tile, debug bool, layerNames ...string) ([]byte, error) {
+	z, x, y := tile.ZXY()
+
+	var (
+		wg    sync.WaitGroup
+		tiles = make([][]byte, len(c.Maps))
+		errs  = make([]error, len(c.Maps))
+	)
+
+	for i, name := range c.Maps {
+		m, err := a.Map(name)
+		if err != nil {
+			return nil, err
+		}
+
+		m = m.FilterLayersByZoom(z)
+		if len(m.Layers) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, m Map) {
+			defer wg.Done()
+			tiles[i], errs[i] = m.encodeCached(ctx, z, x, y)
+		}(i, m)
+	}
+
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var merged vectorTile.Tile
+	owners := map[string]string{}
+	// the selected layers by name, added in the order of layerNames
+	selected := map[string]*vectorTile.Tile_Layer{}
+	for i, b := range tiles {
+		if b == nil {
+			continue
+		}
+
+		vtile, err := unmarshalTile(b)
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := c.layerPrefix(c.Maps[i])
+		for _, l := range vtile.Layers {
+			name := prefix + l.GetName()
+			if owner, ok := owners[name]; ok {
+				return nil, ErrCompositeLayerCollision{Layer: name, Map1: owner, Map2: c.Maps[i]}
+			}
+			owners[name] = c.Maps[i]
+
+			l.Name = &name
+			if len(layerNames) > 0 {
+				if containsString(layerNames, name) {
+					selected[name] = l
+				}
+				continue
+			}
+			merged.Layers = append(merged.Layers, l)
+		}
+	}
+
+	for _, name := range layerNames {
+		if l, ok := selected[name]; ok {
+			merged.Layers = append(merged.Layers, l)
+			delete(selected, name)
+		}
+	}
+
+	if debug {
+		b, err := NewWebMercatorMap(c.Name).AddDebugLayers().Encode(ctx, tile)
+		if err != nil {
+			return nil, err
+		}
+		vtile, err := unmarshalTile(b)
+		if err != nil {
+			return nil, err
+		}
+		merged.Layers = append(merged.Layers, vtile.Layers...)
+	}
+
+	return marshalTile(&merged)
+}
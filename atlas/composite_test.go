@@ -0,0 +1,95 @@
+package atlas_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/mvt"
+)
+
+func TestEncodeComposite(t *testing.T) {
+	type tcase struct {
+		composite      atlas.Composite
+		layerNames     []string
+		expectedLayers []string
+		err            bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		prv := &countingProvider{}
+
+		var a atlas.Atlas
+		a.SetCache(&memoryCache{})
+		for _, name := range []string{"basemap", "transit"} {
+			m := atlas.NewWebMercatorMap(name)
+			m.Layers = []atlas.Layer{
+				{Name: "points", Provider: prv},
+				{Name: name, Provider: prv},
+			}
+			a.AddMap(m)
+		}
+
+		tile := slippy.NewTile(2, 2, 1, 64, tegola.WebMercator)
+
+		// encoded twice to check the map tiles are read from the cache
+		for i := 0; i < 2; i++ {
+			b, err := a.EncodeComposite(context.Background(), tc.composite, tile, false, tc.layerNames...)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			decoded, err := mvt.Decode(b, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, l := range decoded.Layers() {
+				names = append(names, l.Name)
+			}
+			if len(names) != len(tc.expectedLayers) {
+				t.Fatalf("layers, expected %v got %v", tc.expectedLayers, names)
+			}
+			for j := range names {
+				if names[j] != tc.expectedLayers[j] {
+					t.Errorf("layers, expected %v got %v", tc.expectedLayers, names)
+				}
+			}
+		}
+
+		// each map layer is queried once
+		if prv.queries != 4 {
+			t.Errorf("provider queries, expected 4 got %v", prv.queries)
+		}
+	}
+
+	tests := map[string]tcase{
+		"collision": {
+			composite: atlas.Composite{Name: "all", Maps: []string{"basemap", "transit"}},
+			err:       true,
+		},
+		"prefixed": {
+			composite:      atlas.Composite{Name: "all", Maps: []string{"basemap", "transit"}, PrefixLayers: true},
+			expectedLayers: []string{"basemap_points", "basemap_basemap", "transit_points", "transit_transit"},
+		},
+		"prefixed layer names": {
+			composite:      atlas.Composite{Name: "all", Maps: []string{"basemap", "transit"}, PrefixLayers: true},
+			layerNames:     []string{"transit_points", "basemap_basemap"},
+			expectedLayers: []string{"transit_points", "basemap_basemap"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -17,3 +17,47 @@ type ErrMapNotFound struct {
 func (e ErrMapNotFound) Error() string {
 	return fmt.Sprintf("atlas: map (%v) not found", e.Name)
 }
+
+type ErrCompositeLayerCollision struct {
+	Layer string
+	Map1  string
+	Map2  string
+}
+
+func (e ErrCompositeLayerCollision) Error() string {
+	return fmt.Sprintf("atlas: maps (%v) and (%v) both have a layer named (%v). set prefix_layers on the composite map to combine them", e.Map1, e.Map2, e.Layer)
+}
+
+type ErrInvalidAttributeCast struct {
+	Key  string
+	Type string
+}
+
+func (e ErrInvalidAttributeCast) Error() string {
+	return fmt.Sprintf("atlas: invalid cast type (%v) for attribute (%v). expected one of string, int, float or bool", e.Type, e.Key)
+}
+
+type ErrInvalidFilter struct {
+	Filter string
+	Pos    int
+	Reason string
+}
+
+func (e ErrInvalidFilter) Error() string {
+	return fmt.Sprintf("atlas: invalid filter (%v) at position %v: %v", e.Filter, e.Pos, e.Reason)
+}
+
+type ErrInvalidTileBudgetStep string
+
+func (e ErrInvalidTileBudgetStep) Error() string {
+	return fmt.Sprintf("atlas: invalid tile budget step (%v)", string(e))
+}
+
+type ErrInvalidClusterAggregate struct {
+	Tag  string
+	Func string
+}
+
+func (e ErrInvalidClusterAggregate) Error() string {
+	return fmt.Sprintf("atlas: invalid cluster aggregate (%v) for tag (%v). expected one of sum, min, max or avg", e.Func, e.Tag)
+}
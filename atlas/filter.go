@@ -0,0 +1,545 @@
+package atlas
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-spatial/geom"
+)
+
+// Filter is a boolean expression evaluated against the tags, zoom and geometry
+// type of a feature. Features which don't match are not encoded.
+//
+// Expressions are made up of comparisons joined with and, or and not and
+// grouped with parentheses:
+//
+//	class in ('motorway', 'trunk') or ($zoom >= 10 and class = 'primary')
+//
+// The supported comparison operators are =, !=, <, <=, >, >=, in and not in.
+// Bare words are tag keys. Keys containing other characters can be double quoted
+// (i.e. "name:en"). Strings are single quoted. $zoom is the zoom of the tile and
+// $geometry_type is the lower case type of the feature's geometry (point,
+// multipoint, linestring, multilinestring, polygon or multipolygon).
+// A missing tag is null and only equals null.
+type Filter struct {
+	src  string
+	root filterNode
+}
+
+// ParseFilter parses a filter expression
+func ParseFilter(s string) (*Filter, error) {
+	p := filterParser{src: s}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.val)
+	}
+
+	return &Filter{src: s, root: root}, nil
+}
+
+// String returns the source of the filter
+func (f *Filter) String() string {
+	return f.src
+}
+
+// Match reports whether a feature with the tags and geometry in a tile at zoom matches the filter
+func (f *Filter) Match(tags map[string]interface{}, zoom uint, g geom.Geometry) bool {
+	return f.root.match(filterEnv{tags: tags, zoom: zoom, geom: g})
+}
+
+// filterEnv holds the feature values a filter is evaluated against
+type filterEnv struct {
+	tags map[string]interface{}
+	zoom uint
+	geom geom.Geometry
+}
+
+type filterNode interface {
+	match(env filterEnv) bool
+}
+
+type filterOr struct{ left, right filterNode }
+
+func (n filterOr) match(env filterEnv) bool { return n.left.match(env) || n.right.match(env) }
+
+type filterAnd struct{ left, right filterNode }
+
+func (n filterAnd) match(env filterEnv) bool { return n.left.match(env) && n.right.match(env) }
+
+type filterNot struct{ node filterNode }
+
+func (n filterNot) match(env filterEnv) bool { return !n.node.match(env) }
+
+// filterValue is an operand of a comparison
+type filterValue interface {
+	value(env filterEnv) interface{}
+}
+
+type filterLiteral struct{ val interface{} }
+
+func (v filterLiteral) value(filterEnv) interface{} { return v.val }
+
+type filterTag struct{ key string }
+
+func (v filterTag) value(env filterEnv) interface{} { return env.tags[v.key] }
+
+type filterZoom struct{}
+
+func (filterZoom) value(env filterEnv) interface{} { return float64(env.zoom) }
+
+type filterGeometryType struct{}
+
+func (filterGeometryType) value(env filterEnv) interface{} { return geometryTypeName(env.geom) }
+
+// filterValueNode matches when a value is truthy, i.e. "oneway" or "$zoom"
+type filterValueNode struct{ val filterValue }
+
+func (n filterValueNode) match(env filterEnv) bool {
+	switch v := n.val.value(env).(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	default:
+		f, ok := toFloat(v)
+		return !ok || f != 0
+	}
+}
+
+type filterCompare struct {
+	op          string
+	left, right filterValue
+}
+
+func (n filterCompare) match(env filterEnv) bool {
+	return compareValues(n.op, n.left.value(env), n.right.value(env))
+}
+
+type filterIn struct {
+	val  filterValue
+	list []filterValue
+}
+
+func (n filterIn) match(env filterEnv) bool {
+	v := n.val.value(env)
+	for i := range n.list {
+		if compareValues("=", v, n.list[i].value(env)) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareValues compares two values. numbers are compared numerically, everything else by its string form.
+// null is only equal to null and is not ordered.
+func compareValues(op string, a, b interface{}) bool {
+	if a == nil || b == nil {
+		switch op {
+		case "=":
+			return a == nil && b == nil
+		case "!=":
+			return (a == nil) != (b == nil)
+		default:
+			return false
+		}
+	}
+
+	var cmp int
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	switch {
+	case aok && bok:
+		switch {
+		case af < bf:
+			cmp = -1
+		case af > bf:
+			cmp = 1
+		}
+	default:
+		cmp = strings.Compare(valueString(a), valueString(b))
+	}
+
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// toFloat converts numeric values and numeric strings into a float64
+func toFloat(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int8:
+		return float64(val), true
+	case int16:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case uint:
+		return float64(val), true
+	case uint8:
+		return float64(val), true
+	case uint16:
+		return float64(val), true
+	case uint32:
+		return float64(val), true
+	case uint64:
+		return float64(val), true
+	case string:
+		f, err := strconv.ParseFloat(val, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func valueString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// geometryTypeName returns the lower case name of the geometry type
+func geometryTypeName(g geom.Geometry) interface{} {
+	switch g.(type) {
+	case geom.Point, *geom.Point:
+		return "point"
+	case geom.MultiPoint, *geom.MultiPoint:
+		return "multipoint"
+	case geom.LineString, *geom.LineString:
+		return "linestring"
+	case geom.MultiLineString, *geom.MultiLineString:
+		return "multilinestring"
+	case geom.Polygon, *geom.Polygon:
+		return "polygon"
+	case geom.MultiPolygon, *geom.MultiPolygon:
+		return "multipolygon"
+	case geom.Collection, *geom.Collection:
+		return "geometrycollection"
+	}
+	return nil
+}
+
+const (
+	tokEOF = iota
+	tokIdent
+	tokVar
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type filterToken struct {
+	kind int
+	val  string
+	pos  int
+}
+
+type filterParser struct {
+	src    string
+	tokens []filterToken
+	i      int
+}
+
+func (p *filterParser) errorf(tok filterToken, format string, args ...interface{}) error {
+	return ErrInvalidFilter{
+		Filter: p.src,
+		Pos:    tok.pos,
+		Reason: fmt.Sprintf(format, args...),
+	}
+}
+
+// lex splits the source into tokens
+func (p *filterParser) lex() error {
+	src := p.src
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			p.tokens = append(p.tokens, filterToken{tokLParen, "(", i})
+			i++
+		case c == ')':
+			p.tokens = append(p.tokens, filterToken{tokRParen, ")", i})
+			i++
+		case c == ',':
+			p.tokens = append(p.tokens, filterToken{tokComma, ",", i})
+			i++
+		case c == '=':
+			p.tokens = append(p.tokens, filterToken{tokOp, "=", i})
+			i++
+		case c == '!' || c == '<' || c == '>':
+			op := string(c)
+			if i+1 < len(src) && (src[i+1] == '=' || (c == '<' && src[i+1] == '>')) {
+				op += string(src[i+1])
+			}
+			switch op {
+			case "!":
+				return p.errorf(filterToken{pos: i}, "unexpected %q", c)
+			case "<>":
+				p.tokens = append(p.tokens, filterToken{tokOp, "!=", i})
+			default:
+				p.tokens = append(p.tokens, filterToken{tokOp, op, i})
+			}
+			i += len(op)
+		case c == '\'' || c == '"':
+			// single quotes are strings, double quotes are tag keys. quotes are escaped by doubling them
+			start := i
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(src) {
+					return p.errorf(filterToken{pos: start}, "unterminated quote")
+				}
+				if src[i] == c {
+					if i+1 < len(src) && src[i+1] == c {
+						sb.WriteByte(c)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteByte(src[i])
+				i++
+			}
+			kind := tokString
+			if c == '"' {
+				kind = tokIdent
+			}
+			p.tokens = append(p.tokens, filterToken{kind, sb.String(), start})
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			start := i
+			i++
+			for i < len(src) && (src[i] == '.' || src[i] == 'e' || src[i] == 'E' || (src[i] >= '0' && src[i] <= '9') ||
+				((src[i] == '-' || src[i] == '+') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				i++
+			}
+			if _, err := strconv.ParseFloat(src[start:i], 64); err != nil {
+				return p.errorf(filterToken{pos: start}, "invalid number %q", src[start:i])
+			}
+			p.tokens = append(p.tokens, filterToken{tokNumber, src[start:i], start})
+		case c == '$' || c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			i++
+			for i < len(src) && (src[i] == '_' || src[i] == ':' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			kind := tokIdent
+			if c == '$' {
+				kind = tokVar
+			}
+			p.tokens = append(p.tokens, filterToken{kind, src[start:i], start})
+		default:
+			return p.errorf(filterToken{pos: i}, "unexpected %q", c)
+		}
+	}
+
+	p.tokens = append(p.tokens, filterToken{tokEOF, "end of filter", len(src)})
+	return nil
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.i]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+// keyword reports whether tok is the unquoted keyword kw. keywords are case insensitive
+func keyword(tok filterToken, kw string) bool {
+	return tok.kind == tokIdent && strings.EqualFold(tok.val, kw)
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for keyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = filterOr{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for keyword(p.peek(), "and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = filterAnd{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (filterNode, error) {
+	if keyword(p.peek(), "not") {
+		p.next()
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{node}, nil
+	}
+
+	if p.peek().kind == tokLParen {
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokRParen {
+			return nil, p.errorf(tok, "expected ) got %q", tok.val)
+		}
+		return node, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterNode, error) {
+	left, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	switch {
+	case tok.kind == tokOp:
+		p.next()
+		right, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return filterCompare{op: tok.val, left: left, right: right}, nil
+
+	case keyword(tok, "in"):
+		p.next()
+		return p.parseIn(left)
+
+	case keyword(tok, "not") && keyword(p.tokens[p.i+1], "in"):
+		p.next()
+		p.next()
+		node, err := p.parseIn(left)
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{node}, nil
+	}
+
+	return filterValueNode{left}, nil
+}
+
+func (p *filterParser) parseIn(val filterValue) (filterNode, error) {
+	if tok := p.next(); tok.kind != tokLParen {
+		return nil, p.errorf(tok, "expected ( got %q", tok.val)
+	}
+
+	in := filterIn{val: val}
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		in.list = append(in.list, v)
+
+		tok := p.next()
+		if tok.kind == tokRParen {
+			break
+		}
+		if tok.kind != tokComma {
+			return nil, p.errorf(tok, "expected , or ) got %q", tok.val)
+		}
+	}
+
+	return in, nil
+}
+
+func (p *filterParser) parseValue() (filterValue, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokString:
+		return filterLiteral{tok.val}, nil
+	case tokNumber:
+		f, _ := strconv.ParseFloat(tok.val, 64)
+		return filterLiteral{f}, nil
+	case tokVar:
+		switch tok.val {
+		case "$zoom":
+			return filterZoom{}, nil
+		case "$geometry_type":
+			return filterGeometryType{}, nil
+		}
+		return nil, p.errorf(tok, "unknown variable %q", tok.val)
+	case tokIdent:
+		// quoted keys are never keywords
+		if p.src[tok.pos] != '"' {
+			switch strings.ToLower(tok.val) {
+			case "true":
+				return filterLiteral{true}, nil
+			case "false":
+				return filterLiteral{false}, nil
+			case "null":
+				return filterLiteral{nil}, nil
+			case "and", "or", "not", "in":
+				return nil, p.errorf(tok, "unexpected %q", tok.val)
+			}
+		}
+		return filterTag{tok.val}, nil
+	}
+
+	return nil, p.errorf(tok, "expected a value got %q", tok.val)
+}
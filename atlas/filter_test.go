@@ -0,0 +1,134 @@
+package atlas_test
+
+import (
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/atlas"
+)
+
+func TestFilterMatch(t *testing.T) {
+	type tcase struct {
+		filter   string
+		tags     map[string]interface{}
+		zoom     uint
+		geom     geom.Geometry
+		expected bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		f, err := atlas.ParseFilter(tc.filter)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := f.Match(tc.tags, tc.zoom, tc.geom); got != tc.expected {
+			t.Errorf("match, expected %v got %v", tc.expected, got)
+		}
+	}
+
+	const example = "class in ('motorway','trunk') or ($zoom >= 10 and class = 'primary')"
+
+	tests := map[string]tcase{
+		"example in": {
+			filter:   example,
+			tags:     map[string]interface{}{"class": "trunk"},
+			expected: true,
+		},
+		"example primary low zoom": {
+			filter:   example,
+			tags:     map[string]interface{}{"class": "primary"},
+			zoom:     9,
+			expected: false,
+		},
+		"example primary high zoom": {
+			filter:   example,
+			tags:     map[string]interface{}{"class": "primary"},
+			zoom:     10,
+			expected: true,
+		},
+		"numeric compare": {
+			filter:   "lanes > 2",
+			tags:     map[string]interface{}{"lanes": int64(3)},
+			expected: true,
+		},
+		"numeric string compare": {
+			filter:   "lanes <= 2.5",
+			tags:     map[string]interface{}{"lanes": "3"},
+			expected: false,
+		},
+		"not in": {
+			filter:   "class not in ('a', 'b')",
+			tags:     map[string]interface{}{"class": "c"},
+			expected: true,
+		},
+		"missing tag": {
+			filter:   "class = 'a'",
+			tags:     map[string]interface{}{},
+			expected: false,
+		},
+		"missing tag is null": {
+			filter:   "class = null",
+			tags:     map[string]interface{}{},
+			expected: true,
+		},
+		"not equal": {
+			filter:   "class <> 'a' and not (name = 'x')",
+			tags:     map[string]interface{}{"class": "b", "name": "y"},
+			expected: true,
+		},
+		"quoted key and escaped string": {
+			filter:   `"name:en" = 'O''Hare'`,
+			tags:     map[string]interface{}{"name:en": "O'Hare"},
+			expected: true,
+		},
+		"bool": {
+			filter:   "oneway = true",
+			tags:     map[string]interface{}{"oneway": true},
+			expected: true,
+		},
+		"truthy tag": {
+			filter:   "bridge",
+			tags:     map[string]interface{}{"bridge": false},
+			expected: false,
+		},
+		"geometry type": {
+			filter:   "$geometry_type = 'polygon'",
+			geom:     geom.Polygon{},
+			expected: true,
+		},
+		"geometry type mismatch": {
+			filter:   "$geometry_type in ('point', 'multipoint')",
+			geom:     geom.LineString{},
+			expected: false,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestParseFilterInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unterminated string": "class = 'a",
+		"missing paren":       "(class = 'a'",
+		"unknown variable":    "$foo = 1",
+		"dangling operator":   "class =",
+		"trailing tokens":     "class = 'a' 'b'",
+		"bad in list":         "class in ('a' 'b')",
+		"bang":                "!class",
+	}
+
+	for name, src := range tests {
+		src := src
+		t.Run(name, func(t *testing.T) {
+			if _, err := atlas.ParseFilter(src); err == nil {
+				t.Errorf("expected error, got nil")
+			} else if _, ok := err.(atlas.ErrInvalidFilter); !ok {
+				t.Errorf("error, expected ErrInvalidFilter got %T", err)
+			}
+		})
+	}
+}
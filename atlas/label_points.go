@@ -0,0 +1,96 @@
+package atlas
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/maths/polylabel"
+	"github.com/go-spatial/tegola/mvt"
+)
+
+const (
+	// LabelPointsAlongside encodes the label points in addition to the polygons
+	LabelPointsAlongside = "alongside"
+	// LabelPointsInstead encodes the label points in place of the polygons
+	LabelPointsInstead = "instead"
+)
+
+const (
+	DefaultLabelPointsMode    = LabelPointsAlongside
+	DefaultLabelPointsAreaTag = "area"
+	// LabelLayerSuffix is appended to a layer's name for the name of its label layer
+	LabelLayerSuffix = "_label"
+)
+
+// LabelPoints configures a companion point layer with a label anchor for each of a
+// layer's polygons. The anchor is the polygon's pole of inaccessibility, which unlike
+// the centroid is always inside the polygon. The points have the polygon's tags and area.
+type LabelPoints struct {
+	// Name of the label layer. Defaults to the layer's name with the LabelLayerSuffix
+	Name string
+	// Mode is LabelPointsAlongside or LabelPointsInstead
+	Mode string
+	// AreaTag is the key of the tag holding the polygon's area in square webmercator units
+	AreaTag string
+}
+
+// Validate checks the mode is supported
+func (lp *LabelPoints) Validate() error {
+	switch lp.Mode {
+	case LabelPointsAlongside, LabelPointsInstead:
+		return nil
+	default:
+		return fmt.Errorf("atlas: invalid label points mode (%v). expected one of %v or %v", lp.Mode, LabelPointsAlongside, LabelPointsInstead)
+	}
+}
+
+// LayerName returns the name of the label layer for the layer
+func (lp *LabelPoints) LayerName(l *Layer) string {
+	if lp.Name != "" {
+		return lp.Name
+	}
+	return l.MVTName() + LabelLayerSuffix
+}
+
+// feature returns the label point of a polygon feature. false is returned if the
+// feature is not a polygon or multipolygon. precision is in map units.
+func (lp *LabelPoints) feature(f mvt.Feature, precision float64) (mvt.Feature, bool) {
+	var (
+		pt   basic.Point
+		area float64
+	)
+
+	switch g := f.Geometry.(type) {
+	case tegola.Polygon:
+		p, _, ok := polylabel.Polygon(g, precision)
+		if !ok {
+			return f, false
+		}
+		pt, area = basic.Point{p.X, p.Y}, polylabel.Area(g)
+	case tegola.MultiPolygon:
+		p, _, ok := polylabel.MultiPolygon(g, precision)
+		if !ok {
+			return f, false
+		}
+		pt = basic.Point{p.X, p.Y}
+		for _, plg := range g.Polygons() {
+			area += polylabel.Area(plg)
+		}
+	default:
+		return f, false
+	}
+
+	tags := make(map[string]interface{}, len(f.Tags)+1)
+	for k, v := range f.Tags {
+		tags[k] = v
+	}
+	tags[lp.AreaTag] = math.Round(area)
+
+	return mvt.Feature{
+		ID:       f.ID,
+		Tags:     tags,
+		Geometry: pt,
+	}, true
+}
@@ -2,6 +2,7 @@ package atlas
 
 import (
 	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/mvt"
 	"github.com/go-spatial/tegola/provider"
 )
 
@@ -19,6 +20,32 @@ type Layer struct {
 	// DontSimplify indicates wheather feature simplification should be applied.
 	// We use a negative in the name so the default is to simplify
 	DontSimplify bool
+	// Simplifier simplifies the layer's lines and polygons. If nil Douglas-Peucker is used.
+	Simplifier mvt.Simplifier
+	// SimplifyTolerance is the simplification tolerance in tile pixels. If zero the map's tolerance is used.
+	SimplifyTolerance float64
+	// SimplifyMaxZoom is the max zoom geometries are simplified at. If nil the mvt default is used.
+	SimplifyMaxZoom *uint
+	// Attributes is an optional pipeline of transformations applied to the tags of the features
+	Attributes *Attributes
+	// Filter is an optional expression features must match to be included in the layer
+	Filter *Filter
+	// Cluster optionally groups the layer's points at low zooms
+	Cluster *Cluster
+	// LabelPoints optionally adds a companion layer with a label point for each polygon
+	LabelPoints *LabelPoints
+	// MinAreaPx drops polygons with an area smaller than this many square tile pixels
+	MinAreaPx float64
+	// MinLengthPx drops lines shorter than this many tile pixels
+	MinLengthPx float64
+	// MergeSmallPolygons merges the polygons dropped by MinAreaPx into a single
+	// feature approximating their coverage
+	MergeSmallPolygons bool
+	// Overzoom serves tiles above MaxZoom from the layer's ancestor tile at MaxZoom,
+	// clipped and scaled to the tile, without querying the provider
+	Overzoom bool
+	// Style optionally sets the paint of the layer in the generated style and raster tiles
+	Style *LayerStyle
 }
 
 // MVTName will return the value that will be encoded in the Name field when the layer is encoded as MVT
@@ -29,3 +56,23 @@ func (l *Layer) MVTName() string {
 
 	return l.ProviderLayerName
 }
+
+// mvtLayer returns an empty mvt layer with the layer's name and simplification options
+func (l *Layer) mvtLayer() mvt.Layer {
+	mvtLayer := mvt.Layer{
+		Name:              l.MVTName(),
+		DontSimplify:      l.DontSimplify,
+		Simplifier:        l.Simplifier,
+		SimplifyTolerance: l.SimplifyTolerance,
+	}
+	if l.SimplifyMaxZoom != nil {
+		// the mvt max simplification zoom is exclusive
+		mvtLayer.MaxSimplificationZoom = *l.SimplifyMaxZoom + 1
+	}
+	return mvtLayer
+}
+
+// hasName reports if the layer's name, or its provider layer name, is name
+func (l *Layer) hasName(name string) bool {
+	return (l.Name != "" && l.Name == name) || (l.ProviderLayerName != "" && l.ProviderLayerName == name)
+}
@@ -5,9 +5,11 @@ import (
 	"compress/gzip"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 
@@ -15,9 +17,12 @@ import (
 	"github.com/go-spatial/geom/slippy"
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/cache"
 	"github.com/go-spatial/tegola/dict"
 	"github.com/go-spatial/tegola/internal/convert"
+	"github.com/go-spatial/tegola/internal/timing"
 	"github.com/go-spatial/tegola/mvt"
+	"github.com/go-spatial/tegola/mvt/vector_tile"
 	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/provider/debug"
 )
@@ -53,6 +58,21 @@ type Map struct {
 	// MVT output values
 	TileExtent uint64
 	TileBuffer uint64
+
+	// AllowedKeys and AllowedClaims restrict access to the map when the server
+	// has authentication enabled. A client is allowed if its key id is in AllowedKeys
+	// or its token holds all of the AllowedClaims. If both are empty any
+	// authenticated client may access the map.
+	AllowedKeys   []string
+	AllowedClaims map[string]interface{}
+
+	// MaxTileBytes is the size budget of an encoded tile. Tiles over the budget are
+	// degraded using the TileBudget steps until they fit. Zero disables the budget.
+	MaxTileBytes int
+	TileBudget   TileBudget
+
+	// the atlas the map was looked up from. overzoomed layers read their ancestor tiles from its cache
+	atlas *Atlas
 }
 
 // AddDebugLayers returns a copy of a Map with the debug layers appended to the layer list
@@ -92,7 +112,7 @@ func (m Map) FilterLayersByZoom(zoom uint) Map {
 	var layers []Layer
 
 	for i := range m.Layers {
-		if (m.Layers[i].MinZoom <= zoom || m.Layers[i].MinZoom == 0) && (m.Layers[i].MaxZoom >= zoom || m.Layers[i].MaxZoom == 0) {
+		if (m.Layers[i].MinZoom <= zoom || m.Layers[i].MinZoom == 0) && (m.Layers[i].MaxZoom >= zoom || m.Layers[i].MaxZoom == 0 || m.Layers[i].overzoomed(zoom)) {
 			layers = append(layers, m.Layers[i])
 			continue
 		}
@@ -104,20 +124,22 @@ func (m Map) FilterLayersByZoom(zoom uint) Map {
 	return m
 }
 
-// FilterLayersByName returns a copy of a Map with a subset of layers that match the supplied list of layer names
+// FilterLayersByName returns a copy of a Map with the layers matching the supplied list of layer names.
+// A name matches a layer's name, or its provider layer name. The layers are in the order of the names.
 func (m Map) FilterLayersByName(names ...string) Map {
 	var layers []Layer
 
-	nameStr := strings.Join(names, ",")
-	for i := range m.Layers {
-		// if we have a name set, use it for the lookup
-		if m.Layers[i].Name != "" && strings.Contains(nameStr, m.Layers[i].Name) {
-			layers = append(layers, m.Layers[i])
-			continue
-		} else if m.Layers[i].ProviderLayerName != "" && strings.Contains(nameStr, m.Layers[i].ProviderLayerName) { // default to using the ProviderLayerName for the lookup
-			layers = append(layers, m.Layers[i])
+	for i, name := range names {
+		// skip repeated names so their layers are added once
+		if containsString(names[:i], name) {
 			continue
 		}
+
+		for j := range m.Layers {
+			if m.Layers[j].hasName(name) {
+				layers = append(layers, m.Layers[j])
+			}
+		}
 	}
 
 	// overwrite the Map's layers with our subset
@@ -126,16 +148,64 @@ func (m Map) FilterLayersByName(names ...string) Map {
 	return m
 }
 
+// HasLayer reports if the map has a layer, at any zoom, matching name as FilterLayersByName does
+func (m Map) HasLayer(name string) bool {
+	for i := range m.Layers {
+		if m.Layers[i].hasName(name) {
+			return true
+		}
+	}
+	return false
+}
+
 // TODO (arolek): support for max zoom
 func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
-	// tile container
-	var mvtTile mvt.Tile
+	b, _, err := m.EncodeBudgeted(ctx, tile)
+	return b, err
+}
+
+// EncodeBudgeted encodes the tile like Encode. If the map has a MaxTileBytes budget and
+// the tile is over it, the tile is degraded using the TileBudget steps until it fits.
+// The steps taken are returned.
+func (m Map) EncodeBudgeted(ctx context.Context, tile *slippy.Tile) ([]byte, []string, error) {
 	// wait group for concurrent layer fetching
 	var wg sync.WaitGroup
 
 	// layer stack
 	mvtLayers := make([]*mvt.Layer, len(m.Layers))
 
+	// the first provider query timeout. it's returned instead of an incomplete tile
+	var timeoutErr error
+	var timeoutOnce sync.Once
+
+	zoom, tileX, tileY := tile.ZXY()
+
+	tileExtent := float64(m.TileExtent)
+	if tileExtent == 0 {
+		tileExtent = tegola.DefaultExtent
+	}
+
+	// the size of a tile pixel in webmercator units
+	pixelSize := (&tegola.Tile{Z: zoom, Extent: tileExtent}).ZRes()
+
+	// the label point layers of layers with LabelPoints, by layer position
+	labelLayers := make([]*mvt.Layer, len(m.Layers))
+
+	// the decoded ancestor tiles of overzoomed layers, by zoom
+	ancestors := map[uint]*mvt.Tile{}
+	for i := range m.Layers {
+		l := &m.Layers[i]
+		if _, ok := ancestors[l.MaxZoom]; ok || !l.overzoomed(zoom) {
+			continue
+		}
+		ancestor, err := m.ancestorTile(ctx, tile, l.MaxZoom)
+		if err != nil {
+			return nil, nil, err
+		}
+		ancestors[l.MaxZoom] = ancestor
+	}
+	bufferedExtent, _ := tile.BufferedExtent()
+
 	// set our waitgroup count
 	wg.Add(len(m.Layers))
 
@@ -144,16 +214,56 @@ func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
 
 		// go routine for fetching the layer concurrently
 		go func(i int, l Layer) {
-			mvtLayer := mvt.Layer{
-				Name:         l.MVTName(),
-				DontSimplify: l.DontSimplify,
-			}
+			mvtLayer := l.mvtLayer()
 
 			// on completion let the wait group know
 			defer wg.Done()
 
+			// the companion layer of polygon label points
+			var labelLayer mvt.Layer
+			if l.LabelPoints != nil {
+				labelLayer.Name = l.LabelPoints.LayerName(&l)
+			}
+
+			// overzoomed layers are cut from their ancestor tile instead of being fetched
+			if l.overzoomed(zoom) {
+				// the features were simplified when the ancestor was encoded
+				mvtLayer.DontSimplify = true
+				addOverzoomFeatures(&mvtLayer, ancestors[l.MaxZoom], mvtLayer.Name, bufferedExtent)
+				mvtLayers[i] = &mvtLayer
+				if l.LabelPoints != nil {
+					addOverzoomFeatures(&labelLayer, ancestors[l.MaxZoom], labelLayer.Name, bufferedExtent)
+					labelLayers[i] = &labelLayer
+				}
+				return
+			}
+
+			// collects the polygons dropped for being smaller than MinAreaPx when they're merged
+			var smallPolygons *smallPolygons
+			if l.MinAreaPx > 0 && l.MergeSmallPolygons {
+				smallPolygons = newSmallPolygons(l.MinAreaPx, zoom, tileExtent)
+			}
+
+			// collects the layer's points when the layer is clustered at this zoom
+			clusters := newClusterer(l.Cluster, zoom, tileX, tileY, tileExtent)
+
+			// the stages of the layer are timed when the context records them
+			lctx := timing.WithLayer(ctx, mvtLayer.Name)
+			// the time spent handling the provider's features, which isn't part of fetching them
+			var handled time.Duration
+			fetchStart := timing.Start(lctx)
+
 			// fetch layer from data provider
 			err := l.Provider.TileFeatures(ctx, l.ProviderLayerName, tile, func(f *provider.Feature) error {
+				if start := timing.Start(lctx); !start.IsZero() {
+					defer func() { handled += time.Since(start) }()
+				}
+
+				if l.Filter != nil && !l.Filter.Match(f.Tags, zoom, f.Geometry) {
+					return nil
+				}
+
+				convertStart := timing.Start(lctx)
 				// TODO: remove this geom conversion step once the mvt package has adopted the new geom package
 				geo, err := convert.ToTegola(f.Geometry)
 				if err != nil {
@@ -169,6 +279,12 @@ func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
 					}
 					geo = g.Geometry
 				}
+				timing.Stop(lctx, timing.Convert, convertStart)
+
+				// transform the provider tags. default tags are added afterwards so they're not transformed
+				if l.Attributes != nil {
+					l.Attributes.Apply(f.Tags, zoom)
+				}
 
 				// add default tags, but don't overwrite a tag that already exists
 				for k, v := range l.DefaultTags {
@@ -177,20 +293,68 @@ func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
 					}
 				}
 
-				mvtLayer.AddFeatures(mvt.Feature{
-					ID:       &f.ID,
+				// features without an id are encoded without the optional id field
+				var id *uint64
+				if !f.NoID {
+					id = &f.ID
+				}
+
+				feature := mvt.Feature{
+					ID:       id,
 					Tags:     f.Tags,
 					Geometry: geo,
-				})
+				}
+
+				// drop features too small to be seen at this zoom
+				if l.MinAreaPx > 0 {
+					if area, ok := polygonArea(geo); ok && area/(pixelSize*pixelSize) < l.MinAreaPx {
+						if smallPolygons != nil {
+							smallPolygons.add(geo, area/(pixelSize*pixelSize))
+						}
+						return nil
+					}
+				}
+				if l.MinLengthPx > 0 {
+					if length, ok := lineLength(geo); ok && length/pixelSize < l.MinLengthPx {
+						return nil
+					}
+				}
+
+				// label points are found to within a pixel
+				if l.LabelPoints != nil {
+					if label, ok := l.LabelPoints.feature(feature, pixelSize); ok {
+						labelLayer.AddFeatures(label)
+						if l.LabelPoints.Mode == LabelPointsInstead {
+							return nil
+						}
+					}
+				}
+
+				// clustered points are added once all the features have been read
+				if clusters != nil && clusters.add(feature) {
+					return nil
+				}
+
+				mvtLayer.AddFeatures(feature)
 
 				return nil
 			})
+			if !fetchStart.IsZero() {
+				timing.Add(lctx, timing.Fetch, time.Since(fetchStart)-handled)
+			}
 			if err != nil {
 				switch err {
 				case context.Canceled:
 					// TODO (arolek): add debug logs
 				default:
 					z, x, y := tile.ZXY()
+
+					if _, ok := err.(provider.ErrQueryTimeout); ok {
+						log.Printf("timeout fetching tile (z: %v, x: %v, y: %v) features: %v", z, x, y, err)
+						timeoutOnce.Do(func() { timeoutErr = err })
+						return
+					}
+
 					// TODO (arolek): should we return an error to the response or just log the error?
 					// we can't just write to the response as the waitgroup is going to write to the response as well
 					log.Printf("err fetching tile (z: %v, x: %v, y: %v) features: %v", z, x, y, err)
@@ -198,8 +362,34 @@ func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
 				return
 			}
 
+			if smallPolygons != nil {
+				if f, ok := smallPolygons.feature(); ok {
+					for k, v := range l.DefaultTags {
+						if _, ok := f.Tags[k]; !ok {
+							f.Tags[k] = v
+						}
+					}
+					mvtLayer.AddFeatures(f)
+				}
+			}
+
+			if clusters != nil {
+				for _, f := range clusters.features() {
+					// add default tags to the clusters
+					for k, v := range l.DefaultTags {
+						if _, ok := f.Tags[k]; !ok {
+							f.Tags[k] = v
+						}
+					}
+					mvtLayer.AddFeatures(f)
+				}
+			}
+
 			// add the layer to the slice position
 			mvtLayers[i] = &mvtLayer
+			if l.LabelPoints != nil {
+				labelLayers[i] = &labelLayer
+			}
 		}(i, layer)
 	}
 
@@ -210,23 +400,62 @@ func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
 	// otherwise the server continues processing even if the request was canceled
 	// as the waitgroup was not notified of the cancel
 	if ctx.Err() != nil {
-		return nil, ctx.Err()
+		return nil, nil, ctx.Err()
 	}
 
-	// add layers to our tile
-	mvtTile.AddLayers(mvtLayers...)
+	if timeoutErr != nil {
+		return nil, nil, timeoutErr
+	}
+
+	// the label layers follow their polygon layers
+	layers := make([]*mvt.Layer, 0, len(mvtLayers))
+	for i := range mvtLayers {
+		layers = append(layers, mvtLayers[i])
+		if labelLayers[i] != nil {
+			layers = append(layers, labelLayers[i])
+		}
+	}
 
 	z, x, y := tile.ZXY()
 
 	// TODO (arolek): change out the tile type for VTile. tegola.Tile will be deprecated
 	tegolaTile := tegola.NewTile(uint(z), uint(x), uint(y))
 
+	b, steps, err := encodeBudgeted(ctx, tegolaTile, layers, m.MaxTileBytes, m.TileBudget)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if m.MaxTileBytes > 0 && len(b) > m.MaxTileBytes {
+		log.Printf("tile (z: %v, x: %v, y: %v) of map (%v) is %v bytes after degrading it (%v). max_tile_bytes is %v", z, x, y, m.Name, len(b), strings.Join(steps, ", "), m.MaxTileBytes)
+	}
+
+	return b, steps, nil
+}
+
+// encodeLayers encodes the layers into a gzipped mvt tile
+func encodeLayers(ctx context.Context, tile *tegola.Tile, layers []*mvt.Layer) ([]byte, error) {
+	// tile container
+	var mvtTile mvt.Tile
+
+	// add layers to our tile
+	mvtTile.AddLayers(layers...)
+
 	// generate our tile
-	vtile, err := mvtTile.VTile(ctx, tegolaTile)
+	vtile, err := mvtTile.VTile(ctx, tile)
 	if err != nil {
 		return nil, err
 	}
 
+	// marshaling is timed for the whole tile
+	start := timing.Start(ctx)
+	defer timing.Stop(ctx, timing.Encode, start)
+
+	return marshalTile(vtile)
+}
+
+// marshalTile encodes and gzips a vector tile
+func marshalTile(vtile *vectorTile.Tile) ([]byte, error) {
 	// encode our mvt tile
 	tileBytes, err := proto.Marshal(vtile)
 	if err != nil {
@@ -251,3 +480,67 @@ func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
 	// return encoded, gzipped tile
 	return gzipBuf.Bytes(), nil
 }
+
+// unmarshalTile decodes an encoded vector tile, which may be gzipped
+func unmarshalTile(b []byte) (*vectorTile.Tile, error) {
+	// gzip magic number
+	if len(b) > 2 && b[0] == 0x1f && b[1] == 0x8b {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		if b, err = ioutil.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+
+	var vtile vectorTile.Tile
+	if err := proto.Unmarshal(b, &vtile); err != nil {
+		return nil, err
+	}
+
+	return &vtile, nil
+}
+
+// encodeCached returns the encoded map tile at z, x, y. When the map was looked up from an
+// atlas with a cache the tile is read from the cache, rendering and caching it on a miss.
+// All of the map's layers are rendered so the tile is the same as the one served for z, x, y.
+func (m Map) encodeCached(ctx context.Context, z, x, y uint) ([]byte, error) {
+	var cacher cache.Interface
+	am := m
+	if m.atlas != nil {
+		cacher = m.atlas.GetCache()
+		if full, err := m.atlas.Map(m.Name); err == nil {
+			am = full
+		}
+	}
+
+	key := cache.Key{
+		MapName: m.Name,
+		Z:       z,
+		X:       x,
+		Y:       y,
+	}
+
+	if cacher != nil {
+		b, hit, err := cacher.Get(&key)
+		if err != nil {
+			log.Printf("error reading tile (z: %v, x: %v, y: %v) of map (%v) from the cache: %v", z, x, y, m.Name, err)
+		} else if hit {
+			return b, nil
+		}
+	}
+
+	b, err := am.FilterLayersByZoom(z).Encode(ctx, slippy.NewTile(z, x, y, float64(m.TileBuffer), m.SRID))
+	if err != nil {
+		return nil, err
+	}
+
+	if cacher != nil {
+		if err := cacher.Set(&key, b); err != nil {
+			log.Printf("error writing tile (z: %v, x: %v, y: %v) of map (%v) to the cache: %v", z, x, y, m.Name, err)
+		}
+	}
+
+	return b, nil
+}
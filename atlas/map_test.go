@@ -6,7 +6,9 @@ import (
 	"context"
 	"io"
 	"reflect"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/internal/p"
 	"github.com/go-spatial/tegola/mvt/vector_tile"
+	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/provider/test"
 )
 
@@ -95,7 +98,7 @@ func TestMapFilterLayersByZoom(t *testing.T) {
 func TestMapFilterLayersByName(t *testing.T) {
 	testcases := []struct {
 		grid     atlas.Map
-		name     string
+		name     []string
 		expected atlas.Map
 	}{
 		{
@@ -113,7 +116,7 @@ func TestMapFilterLayersByName(t *testing.T) {
 					},
 				},
 			},
-			name: "layer1",
+			name: []string{"layer1"},
 			expected: atlas.Map{
 				Layers: []atlas.Layer{
 					{
@@ -124,10 +127,46 @@ func TestMapFilterLayersByName(t *testing.T) {
 				},
 			},
 		},
+		{
+			// names are matched exactly
+			grid: atlas.Map{
+				Layers: []atlas.Layer{
+					{Name: "roads_major"},
+					{Name: "railroad"},
+					{Name: "road"},
+				},
+			},
+			name: []string{"road"},
+			expected: atlas.Map{
+				Layers: []atlas.Layer{
+					{Name: "road"},
+				},
+			},
+		},
+		{
+			// the layers are in the order of the names
+			grid: atlas.Map{
+				Layers: []atlas.Layer{
+					{Name: "water", MaxZoom: 5},
+					{Name: "roads"},
+					{Name: "water", MinZoom: 6},
+					{ProviderLayerName: "buildings"},
+				},
+			},
+			name: []string{"roads", "water", "missing", "roads", "buildings"},
+			expected: atlas.Map{
+				Layers: []atlas.Layer{
+					{Name: "roads"},
+					{Name: "water", MaxZoom: 5},
+					{Name: "water", MinZoom: 6},
+					{ProviderLayerName: "buildings"},
+				},
+			},
+		},
 	}
 
 	for i, tc := range testcases {
-		output := tc.grid.FilterLayersByName(tc.name)
+		output := tc.grid.FilterLayersByName(tc.name...)
 
 		if !reflect.DeepEqual(output, tc.expected) {
 			t.Errorf("testcase (%v) failed. output \n\n%+v\n\n does not match expected \n\n%+v", i, output, tc.expected)
@@ -334,3 +373,131 @@ func TestEncode(t *testing.T) {
 		}
 	}
 }
+
+// timeoutProvider fails every layer with a query timeout
+type timeoutProvider struct {
+	test.TileProvider
+}
+
+func (tp *timeoutProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	return provider.ErrQueryTimeout{
+		Layer:   layer,
+		Timeout: time.Second,
+	}
+}
+
+func TestEncodeQueryTimeout(t *testing.T) {
+	m := atlas.Map{
+		Layers: []atlas.Layer{
+			{
+				Name:     "layer1",
+				Provider: &test.TileProvider{},
+			},
+			{
+				Name:              "layer2",
+				ProviderLayerName: "slow",
+				Provider:          &timeoutProvider{},
+			},
+		},
+	}
+
+	_, err := m.Encode(context.Background(), slippy.NewTile(2, 3, 4, 64, tegola.WebMercator))
+
+	expected := provider.ErrQueryTimeout{Layer: "slow", Timeout: time.Second}
+	if err != expected {
+		t.Errorf("error, expected %v got %v", expected, err)
+	}
+}
+
+func TestEncodeLabelPoints(t *testing.T) {
+	type tcase struct {
+		labelPoints       atlas.LabelPoints
+		expectedLayers    []string
+		expectedFeatures  []int
+		expectedLabelKeys []string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.Map{
+			Layers: []atlas.Layer{
+				{
+					Name:        "layer1",
+					Provider:    &test.TileProvider{},
+					LabelPoints: &tc.labelPoints,
+				},
+			},
+		}
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(2, 3, 4, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		r, err := gzip.NewReader(bytes.NewReader(out))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var buf bytes.Buffer
+		if _, err = io.Copy(&buf, r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var vtile vectorTile.Tile
+		if err = proto.Unmarshal(buf.Bytes(), &vtile); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var (
+			layers   []string
+			features []int
+		)
+		for _, l := range vtile.Layers {
+			layers = append(layers, l.GetName())
+			features = append(features, len(l.Features))
+		}
+
+		if !reflect.DeepEqual(layers, tc.expectedLayers) {
+			t.Fatalf("layers, expected %v got %v", tc.expectedLayers, layers)
+		}
+		if !reflect.DeepEqual(features, tc.expectedFeatures) {
+			t.Errorf("features, expected %v got %v", tc.expectedFeatures, features)
+		}
+
+		label := vtile.Layers[len(vtile.Layers)-1]
+		if len(label.Features) > 0 && label.Features[0].GetType() != vectorTile.Tile_POINT {
+			t.Errorf("label type, expected %v got %v", vectorTile.Tile_POINT, label.Features[0].GetType())
+		}
+		// keys are in no particular order
+		sort.Strings(label.Keys)
+		if !reflect.DeepEqual(label.Keys, tc.expectedLabelKeys) {
+			t.Errorf("label keys, expected %v got %v", tc.expectedLabelKeys, label.Keys)
+		}
+	}
+
+	tests := map[string]tcase{
+		"alongside": {
+			labelPoints: atlas.LabelPoints{
+				Mode:    atlas.LabelPointsAlongside,
+				AreaTag: "area",
+			},
+			expectedLayers:    []string{"layer1", "layer1_label"},
+			expectedFeatures:  []int{1, 1},
+			expectedLabelKeys: []string{"area", "type"},
+		},
+		"instead": {
+			labelPoints: atlas.LabelPoints{
+				Name:    "labels",
+				Mode:    atlas.LabelPointsInstead,
+				AreaTag: "size",
+			},
+			expectedLayers:    []string{"layer1", "labels"},
+			expectedFeatures:  []int{0, 1},
+			expectedLabelKeys: []string{"size", "type"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -0,0 +1,165 @@
+package atlas
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/maths/polylabel"
+	"github.com/go-spatial/tegola/mvt"
+)
+
+const (
+	// MergedTag is set on the feature merging a layer's small polygons
+	MergedTag = "merged"
+	// MergedCountTag is the number of small polygons merged into the feature
+	MergedCountTag = "merged_count"
+)
+
+// polygonArea returns the area of a polygon or multipolygon. false is returned for other geometries
+func polygonArea(g tegola.Geometry) (float64, bool) {
+	switch gg := g.(type) {
+	case tegola.Polygon:
+		return polylabel.Area(gg), true
+	case tegola.MultiPolygon:
+		var area float64
+		for _, p := range gg.Polygons() {
+			area += polylabel.Area(p)
+		}
+		return area, true
+	}
+	return 0, false
+}
+
+// lineLength returns the length of a linestring or multilinestring. false is returned for other geometries
+func lineLength(g tegola.Geometry) (float64, bool) {
+	switch gg := g.(type) {
+	case tegola.LineString:
+		return lineStringLength(gg), true
+	case tegola.MultiLine:
+		var length float64
+		for _, l := range gg.Lines() {
+			length += lineStringLength(l)
+		}
+		return length, true
+	}
+	return 0, false
+}
+
+func lineStringLength(l tegola.LineString) (length float64) {
+	pts := l.Subpoints()
+	for i := 1; i < len(pts); i++ {
+		length += math.Hypot(pts[i].X()-pts[i-1].X(), pts[i].Y()-pts[i-1].Y())
+	}
+	return length
+}
+
+// smallPolygons approximates the coverage of the polygons dropped for being smaller than
+// a layer's MinAreaPx with a grid of cells MinAreaPx in size. A cell is covered when the
+// polygons with their center in it have at least half the area of the cell. The grid is
+// aligned to the world pixel grid so cells match across tiles.
+type smallPolygons struct {
+	z        uint
+	extent   float64
+	cellSize float64
+	// the dropped area of each cell in square pixels
+	cells map[[2]int64]float64
+	count int
+}
+
+func newSmallPolygons(minAreaPx float64, z uint, extent float64) *smallPolygons {
+	return &smallPolygons{
+		z:        z,
+		extent:   extent,
+		cellSize: math.Max(math.Sqrt(minAreaPx), 1),
+		cells:    map[[2]int64]float64{},
+	}
+}
+
+// add collects a dropped polygon with an area of areaPx square pixels
+func (sp *smallPolygons) add(g tegola.Geometry, areaPx float64) {
+	minx, miny, maxx, maxy, ok := bounds(g)
+	if !ok {
+		return
+	}
+
+	px, py := worldPixel((minx+maxx)/2, (miny+maxy)/2, sp.z, sp.extent)
+	key := [2]int64{int64(math.Floor(px / sp.cellSize)), int64(math.Floor(py / sp.cellSize))}
+	sp.cells[key] += areaPx
+	sp.count++
+}
+
+// feature returns a multipolygon of the covered cells. false is returned if no cells are covered
+func (sp *smallPolygons) feature() (mvt.Feature, bool) {
+	keys := make([][2]int64, 0, len(sp.cells))
+	for k, area := range sp.cells {
+		if area >= sp.cellSize*sp.cellSize/2 {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return mvt.Feature{}, false
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][1] != keys[j][1] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+
+	mp := make(basic.MultiPolygon, 0, len(keys))
+	for _, k := range keys {
+		minx, maxy := fromWorldPixel(float64(k[0])*sp.cellSize, float64(k[1])*sp.cellSize, sp.z, sp.extent)
+		maxx, miny := fromWorldPixel(float64(k[0]+1)*sp.cellSize, float64(k[1]+1)*sp.cellSize, sp.z, sp.extent)
+		mp = append(mp, basic.Polygon{basic.Line{{minx, miny}, {maxx, miny}, {maxx, maxy}, {minx, maxy}}})
+	}
+
+	return mvt.Feature{
+		Tags: map[string]interface{}{
+			MergedTag:      true,
+			MergedCountTag: int64(sp.count),
+		},
+		Geometry: mp,
+	}, true
+}
+
+// bounds returns the bounding box of a geometry
+func bounds(g tegola.Geometry) (minx, miny, maxx, maxy float64, ok bool) {
+	minx, miny = math.Inf(1), math.Inf(1)
+	maxx, maxy = math.Inf(-1), math.Inf(-1)
+
+	add := func(pts ...tegola.Point) {
+		for _, pt := range pts {
+			minx, miny = math.Min(minx, pt.X()), math.Min(miny, pt.Y())
+			maxx, maxy = math.Max(maxx, pt.X()), math.Max(maxy, pt.Y())
+			ok = true
+		}
+	}
+
+	switch gg := g.(type) {
+	case tegola.Point:
+		add(gg)
+	case tegola.MultiPoint:
+		add(gg.Points()...)
+	case tegola.LineString:
+		add(gg.Subpoints()...)
+	case tegola.MultiLine:
+		for _, l := range gg.Lines() {
+			add(l.Subpoints()...)
+		}
+	case tegola.Polygon:
+		for _, l := range gg.Sublines() {
+			add(l.Subpoints()...)
+		}
+	case tegola.MultiPolygon:
+		for _, p := range gg.Polygons() {
+			for _, l := range p.Sublines() {
+				add(l.Subpoints()...)
+			}
+		}
+	}
+
+	return minx, miny, maxx, maxy, ok
+}
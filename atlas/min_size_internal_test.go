@@ -0,0 +1,82 @@
+package atlas
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+)
+
+func TestFeatureSize(t *testing.T) {
+	square := basic.Polygon{
+		basic.Line{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+		basic.Line{{2, 2}, {4, 2}, {4, 4}, {2, 4}},
+	}
+
+	if area, ok := polygonArea(square); !ok || area != 96 {
+		t.Errorf("polygon area, expected 96 got %v", area)
+	}
+	if area, ok := polygonArea(basic.MultiPolygon{square, square}); !ok || area != 192 {
+		t.Errorf("multipolygon area, expected 192 got %v", area)
+	}
+	if _, ok := polygonArea(basic.Point{0, 0}); ok {
+		t.Errorf("point area, expected not ok")
+	}
+
+	line := basic.Line{{0, 0}, {3, 4}, {3, 10}}
+	if length, ok := lineLength(line); !ok || length != 11 {
+		t.Errorf("line length, expected 11 got %v", length)
+	}
+	if length, ok := lineLength(basic.MultiLine{line, line}); !ok || length != 22 {
+		t.Errorf("multiline length, expected 22 got %v", length)
+	}
+}
+
+func TestSmallPolygonsFeature(t *testing.T) {
+	const z, extent = 10, 4096
+
+	pixelSize := (&tegola.Tile{Z: z, Extent: extent}).ZRes()
+
+	// a square of side px pixels with its corner at the world pixel x, y
+	square := func(x, y, px float64) basic.Polygon {
+		minx, maxy := fromWorldPixel(x, y, z, extent)
+		maxx, miny := fromWorldPixel(x+px, y+px, z, extent)
+		return basic.Polygon{basic.Line{{minx, miny}, {maxx, miny}, {maxx, maxy}, {minx, maxy}}}
+	}
+
+	sp := newSmallPolygons(4, z, extent)
+
+	// 3 square pixels in the cell at 0, 0 cover it
+	for i := 0; i < 3; i++ {
+		sp.add(square(0.1, 0.1, 1), 1)
+	}
+	// 1 square pixel in the cell at 2, 0 does not
+	sp.add(square(4.1, 0.1, 1), 1)
+
+	f, ok := sp.feature()
+	if !ok {
+		t.Fatalf("expected a merged feature")
+	}
+
+	expectedTags := map[string]interface{}{MergedTag: true, MergedCountTag: int64(4)}
+	if !reflect.DeepEqual(f.Tags, expectedTags) {
+		t.Errorf("tags, expected %v got %v", expectedTags, f.Tags)
+	}
+
+	mp, ok := f.Geometry.(basic.MultiPolygon)
+	if !ok || len(mp) != 1 {
+		t.Fatalf("geometry, expected a multipolygon with 1 polygon got %v", f.Geometry)
+	}
+
+	// the covered cell is 2 x 2 pixels
+	area, _ := polygonArea(mp)
+	if math.Abs(area/(pixelSize*pixelSize)-4) > 0.001 {
+		t.Errorf("area, expected 4 square pixels got %v", area/(pixelSize*pixelSize))
+	}
+
+	if _, ok := newSmallPolygons(4, z, extent).feature(); ok {
+		t.Errorf("expected no merged feature without any polygons")
+	}
+}
@@ -0,0 +1,47 @@
+package atlas
+
+import (
+	"context"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/mvt"
+)
+
+// overzoomed reports if the layer is served from its ancestor tile at MaxZoom for tiles at zoom
+func (l *Layer) overzoomed(zoom uint) bool {
+	return l.Overzoom && l.MaxZoom != 0 && zoom > l.MaxZoom
+}
+
+// ancestorTile returns the decoded tile at zoom z containing the tile. The geometries are in webmercator.
+func (m Map) ancestorTile(ctx context.Context, tile *slippy.Tile, z uint) (*mvt.Tile, error) {
+	zoom, x, y := tile.ZXY()
+	dz := zoom - z
+	x, y = x>>dz, y>>dz
+
+	b, err := m.encodeCached(ctx, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	return mvt.Decode(b, tegola.NewTile(z, x, y))
+}
+
+// addOverzoomFeatures adds the features of the ancestor layer named name which are
+// inside extent to the layer. The encoder clips and scales them to the tile.
+func addOverzoomFeatures(layer *mvt.Layer, ancestor *mvt.Tile, name string, extent *geom.Extent) {
+	for _, l := range ancestor.Layers() {
+		if l.Name != name {
+			continue
+		}
+
+		for _, f := range l.Features() {
+			minx, miny, maxx, maxy, ok := bounds(f.Geometry)
+			if !ok || maxx < extent.MinX() || minx > extent.MaxX() || maxy < extent.MinY() || miny > extent.MaxY() {
+				continue
+			}
+			layer.AddFeatures(f)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package atlas_test
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/mvt"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/test"
+)
+
+// countingProvider returns a single point and counts the provider queries
+type countingProvider struct {
+	test.TileProvider
+	queries int32
+}
+
+func (cp *countingProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	atomic.AddInt32(&cp.queries, 1)
+	return fn(&provider.Feature{
+		ID:       1,
+		Geometry: geom.Point{1000000, 1000000},
+		SRID:     tegola.WebMercator,
+		Tags:     map[string]interface{}{"name": "a"},
+	})
+}
+
+// memoryCache is a cache.Interface backed by a map
+type memoryCache struct {
+	sync.Mutex
+	tiles map[string][]byte
+}
+
+func (mc *memoryCache) Get(key *cache.Key) ([]byte, bool, error) {
+	mc.Lock()
+	defer mc.Unlock()
+	b, ok := mc.tiles[key.String()]
+	return b, ok, nil
+}
+
+func (mc *memoryCache) Set(key *cache.Key, val []byte) error {
+	mc.Lock()
+	defer mc.Unlock()
+	if mc.tiles == nil {
+		mc.tiles = map[string][]byte{}
+	}
+	mc.tiles[key.String()] = val
+	return nil
+}
+
+func (mc *memoryCache) Purge(key *cache.Key) error {
+	mc.Lock()
+	defer mc.Unlock()
+	delete(mc.tiles, key.String())
+	return nil
+}
+
+func TestEncodeOverzoom(t *testing.T) {
+	prv := &countingProvider{}
+
+	m := atlas.NewWebMercatorMap("test")
+	m.Layers = []atlas.Layer{
+		{
+			Name:     "points",
+			MaxZoom:  2,
+			Overzoom: true,
+			Provider: prv,
+		},
+	}
+
+	var a atlas.Atlas
+	a.SetCache(&memoryCache{})
+	a.AddMap(m)
+
+	m, err := a.Map("test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if layers := m.FilterLayersByZoom(4).Layers; len(layers) != 1 {
+		t.Fatalf("layers at zoom 4, expected 1 got %v", len(layers))
+	}
+
+	// the tile holding the point, and its neighbor
+	for _, xyz := range [][3]uint{{4, 8, 7}, {4, 9, 7}} {
+		b, err := m.Encode(context.Background(), slippy.NewTile(xyz[0], xyz[1], xyz[2], 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ttile := tegola.NewTile(xyz[0], xyz[1], xyz[2])
+		decoded, err := mvt.Decode(b, ttile)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		layers := decoded.Layers()
+		if len(layers) != 1 || layers[0].Name != "points" {
+			t.Fatalf("tile %v, expected the points layer got %v", xyz, layers)
+		}
+
+		features := layers[0].Features()
+		if xyz[1] == 9 {
+			if len(features) != 0 {
+				t.Errorf("tile %v, expected no features got %v", xyz, len(features))
+			}
+			continue
+		}
+
+		if len(features) != 1 {
+			t.Fatalf("tile %v, expected 1 feature got %v", xyz, len(features))
+		}
+		pt, ok := features[0].Geometry.(basic.Point)
+		// the point is as precise as a pixel of the ancestor tile
+		tolerance := tegola.NewTile(2, 2, 1).ZRes()
+		if !ok || math.Abs(pt.X()-1000000) > tolerance || math.Abs(pt.Y()-1000000) > tolerance {
+			t.Errorf("tile %v, expected a point near 1000000, 1000000 got %v", xyz, features[0].Geometry)
+		}
+		if features[0].Tags["name"] != "a" {
+			t.Errorf("tile %v, expected name tag a got %v", xyz, features[0].Tags)
+		}
+	}
+
+	// both tiles are cut from the cached ancestor
+	if prv.queries != 1 {
+		t.Errorf("provider queries, expected 1 got %v", prv.queries)
+	}
+}
@@ -0,0 +1,85 @@
+package atlas
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/internal/convert"
+	"github.com/go-spatial/tegola/mvt"
+	"github.com/go-spatial/tegola/provider"
+)
+
+// LayerStages are the encoding stages of the features of a map layer
+type LayerStages struct {
+	Name     string
+	Features []FeatureStages
+}
+
+// FeatureStages are the encoding stages of the geometry of a feature
+type FeatureStages struct {
+	ID uint64
+	mvt.GeometryStages
+}
+
+// EncodeStages fetches the features of the map's layers for the tile and returns the stages of the
+// encoding of their geometries. It's meant for debugging: the features are filtered and reprojected
+// like Encode does, but they're not clustered, dropped by size or given label points, and overzoomed
+// layers are fetched from their provider.
+func (m Map) EncodeStages(ctx context.Context, tile *slippy.Tile) ([]LayerStages, error) {
+	zoom, x, y := tile.ZXY()
+	tegolaTile := tegola.NewTile(zoom, x, y)
+
+	var layers []LayerStages
+	for i := range m.Layers {
+		l := &m.Layers[i]
+		// the debug layers have no provider
+		if l.Provider == nil {
+			continue
+		}
+
+		mvtLayer := l.mvtLayer()
+		stages := LayerStages{
+			Name: mvtLayer.Name,
+		}
+
+		err := l.Provider.TileFeatures(ctx, l.ProviderLayerName, tile, func(f *provider.Feature) error {
+			if l.Filter != nil && !l.Filter.Match(f.Tags, zoom, f.Geometry) {
+				return nil
+			}
+
+			geo, err := convert.ToTegola(f.Geometry)
+			if err != nil {
+				return err
+			}
+
+			if f.SRID != m.SRID {
+				g, err := basic.ToWebMercator(f.SRID, geo)
+				if err != nil {
+					return fmt.Errorf("unable to transform geometry to webmercator from SRID (%v) for feature %v due to error: %v", f.SRID, f.ID, err)
+				}
+				geo = g.Geometry
+			}
+
+			gs, err := mvtLayer.GeometryStages(ctx, geo, tegolaTile)
+			if err != nil {
+				return err
+			}
+
+			stages.Features = append(stages.Features, FeatureStages{
+				ID:             f.ID,
+				GeometryStages: gs,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, stages)
+	}
+
+	return layers, nil
+}
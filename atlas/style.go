@@ -0,0 +1,39 @@
+package atlas
+
+import (
+	"fmt"
+
+	"gopkg.in/go-playground/colors.v1"
+)
+
+// LayerStyle is the paint of a layer in the generated Mapbox style and in raster tiles.
+// Colors are css colors (i.e. #ff0000, rgb(255,0,0) or rgba(255,0,0,0.5)). Unset values
+// default to the generated style, which picks a color from the layer name.
+type LayerStyle struct {
+	FillColor        string
+	FillOutlineColor string
+	LineColor        string
+	// LineWidth is the width of lines in pixels
+	LineWidth   float64
+	CircleColor string
+	// CircleRadius is the radius of points in pixels
+	CircleRadius float64
+}
+
+// Validate checks the colors can be parsed and the sizes are not negative
+func (s *LayerStyle) Validate() error {
+	for _, c := range []string{s.FillColor, s.FillOutlineColor, s.LineColor, s.CircleColor} {
+		if c == "" {
+			continue
+		}
+		if _, err := colors.Parse(c); err != nil {
+			return fmt.Errorf("atlas: invalid style color (%v). expected a hex, rgb or rgba color", c)
+		}
+	}
+
+	if s.LineWidth < 0 || s.CircleRadius < 0 {
+		return fmt.Errorf("atlas: style line width (%v) and circle radius (%v) can not be negative", s.LineWidth, s.CircleRadius)
+	}
+
+	return nil
+}
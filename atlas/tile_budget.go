@@ -0,0 +1,225 @@
+package atlas
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/maths"
+	"github.com/go-spatial/tegola/mvt"
+)
+
+const (
+	// BudgetStepSimplify doubles the simplification tolerance, up to 8 times the layer's simplify
+	// tolerance or budgetSimplifyTolerance for layers without one
+	BudgetStepSimplify = "simplify"
+	// BudgetStepDropSmallPolygons drops polygons smaller than 4, 16 and finally 64 square pixels
+	BudgetStepDropSmallPolygons = "drop_small_polygons"
+	// BudgetStepThinPoints keeps every 2nd, 4th and finally 8th point feature of each layer
+	BudgetStepThinPoints = "thin_points"
+	// BudgetStepDropAttributes drops the TileBudget.DropAttributes tags one at a time
+	BudgetStepDropAttributes = "drop_attributes"
+	// BudgetStepDropLayers drops the TileBudget.DropLayers layers one at a time
+	BudgetStepDropLayers = "drop_layers"
+)
+
+// DefaultTileBudgetSteps is the order the budget steps are applied in when TileBudget.Steps is empty
+var DefaultTileBudgetSteps = []string{
+	BudgetStepSimplify,
+	BudgetStepDropSmallPolygons,
+	BudgetStepThinPoints,
+	BudgetStepDropAttributes,
+	BudgetStepDropLayers,
+}
+
+// the number of increments of the repeatable budget steps
+const budgetStepIncrements = 3
+
+// budgetSimplifyTolerance is the tolerance in tile pixels the simplify step doubles for
+// layers without a simplify tolerance. the default tile tolerance is a fraction of a pixel
+// so doubling it would barely change the geometries
+const budgetSimplifyTolerance = 1.0
+
+// TileBudget configures how a tile larger than Map.MaxTileBytes is degraded
+type TileBudget struct {
+	// Steps in the order they're applied. If empty DefaultTileBudgetSteps is used
+	Steps []string
+	// DropLayers are the names of low priority layers, in the order they're dropped
+	DropLayers []string
+	// DropAttributes are the keys of low priority tags, in the order they're dropped
+	DropAttributes []string
+}
+
+// Validate checks the steps are supported
+func (b TileBudget) Validate() error {
+	for _, s := range b.Steps {
+		switch s {
+		case BudgetStepSimplify, BudgetStepDropSmallPolygons, BudgetStepThinPoints, BudgetStepDropAttributes, BudgetStepDropLayers:
+		default:
+			return ErrInvalidTileBudgetStep(s)
+		}
+	}
+	return nil
+}
+
+// budgetIncrements returns the state of each increment of the budget steps, in order.
+// The states are cumulative so each one degrades the tile further than the previous.
+func (b TileBudget) budgetIncrements() []budgetState {
+	steps := b.Steps
+	if len(steps) == 0 {
+		steps = DefaultTileBudgetSteps
+	}
+
+	var (
+		states []budgetState
+		state  = budgetState{tolerance: 1, thin: 1}
+	)
+
+	// states share the backing arrays of their slices so appends always copy
+	add := func(desc string) {
+		state.steps = append(state.steps[:len(state.steps):len(state.steps)], desc)
+		states = append(states, state)
+	}
+
+	for _, s := range steps {
+		switch s {
+		case BudgetStepSimplify:
+			for i := 0; i < budgetStepIncrements; i++ {
+				state.tolerance *= 2
+				add(fmt.Sprintf("%v=%vx", BudgetStepSimplify, state.tolerance))
+			}
+		case BudgetStepDropSmallPolygons:
+			for i, area := 0, 4.0; i < budgetStepIncrements; i, area = i+1, area*4 {
+				state.minArea = area
+				add(fmt.Sprintf("%v=%vpx", BudgetStepDropSmallPolygons, area))
+			}
+		case BudgetStepThinPoints:
+			for i := 0; i < budgetStepIncrements; i++ {
+				state.thin *= 2
+				add(fmt.Sprintf("%v=1/%v", BudgetStepThinPoints, state.thin))
+			}
+		case BudgetStepDropAttributes:
+			for _, k := range b.DropAttributes {
+				state.dropAttributes = append(state.dropAttributes[:len(state.dropAttributes):len(state.dropAttributes)], k)
+				add(fmt.Sprintf("drop_attribute=%v", k))
+			}
+		case BudgetStepDropLayers:
+			for _, l := range b.DropLayers {
+				state.dropLayers = append(state.dropLayers[:len(state.dropLayers):len(state.dropLayers)], l)
+				add(fmt.Sprintf("drop_layer=%v", l))
+			}
+		}
+	}
+
+	return states
+}
+
+// budgetState is the accumulated degradation of a tile
+type budgetState struct {
+	// descriptions of the steps taken
+	steps []string
+	// simplification tolerance multiplier of the layers' tolerance in pixels
+	tolerance float64
+	// polygons smaller than minArea square pixels are dropped
+	minArea float64
+	// only every thin-th point feature is kept
+	thin int
+	// tag keys and layer names to drop
+	dropAttributes []string
+	dropLayers     []string
+}
+
+// apply returns degraded copies of the layers. The layers are not modified.
+func (s budgetState) apply(tile *tegola.Tile, layers []*mvt.Layer) []*mvt.Layer {
+	// the area of a pixel in webmercator units
+	pixelArea := tile.ZRes() * tile.ZRes()
+
+	degraded := make([]*mvt.Layer, 0, len(layers))
+	for _, l := range layers {
+		if l == nil || containsString(s.dropLayers, l.Name) {
+			continue
+		}
+
+		nl := mvt.Layer{
+			Name:                  l.Name,
+			DontSimplify:          l.DontSimplify,
+			MaxSimplificationZoom: l.MaxSimplificationZoom,
+			Simplifier:            l.Simplifier,
+			SimplifyTolerance:     l.SimplifyTolerance,
+		}
+		if s.tolerance > 1 {
+			// simplify at every zoom, unless the layer opted out of simplification
+			nl.MaxSimplificationZoom = tegola.MaxZ + 1
+			if nl.SimplifyTolerance <= 0 {
+				nl.SimplifyTolerance = budgetSimplifyTolerance
+			}
+			nl.SimplifyTolerance *= s.tolerance
+		}
+
+		var points int
+		for _, f := range l.Features() {
+			switch g := f.Geometry.(type) {
+			case tegola.Point, tegola.Point3, tegola.MultiPoint:
+				points++
+				if s.thin > 1 && (points-1)%s.thin != 0 {
+					continue
+				}
+			case tegola.Polygon:
+				if s.minArea > 0 && maths.AreaOfPolygon(g)/pixelArea < s.minArea {
+					continue
+				}
+			case tegola.MultiPolygon:
+				if s.minArea > 0 {
+					var area float64
+					for _, p := range g.Polygons() {
+						area += maths.AreaOfPolygon(p)
+					}
+					if area/pixelArea < s.minArea {
+						continue
+					}
+				}
+			}
+
+			if len(s.dropAttributes) > 0 {
+				tags := make(map[string]interface{}, len(f.Tags))
+				for k, v := range f.Tags {
+					if !containsString(s.dropAttributes, k) {
+						tags[k] = v
+					}
+				}
+				f.Tags = tags
+			}
+
+			nl.AddFeatures(f)
+		}
+
+		degraded = append(degraded, &nl)
+	}
+
+	return degraded
+}
+
+// encodeBudgeted encodes the layers, degrading the tile until it's no larger than maxBytes.
+// The steps taken are returned. If the tile can't be degraded enough the most degraded
+// encoding is returned.
+func encodeBudgeted(ctx context.Context, tile *tegola.Tile, layers []*mvt.Layer, maxBytes int, budget TileBudget) ([]byte, []string, error) {
+	b, err := encodeLayers(ctx, tile, layers)
+	if err != nil || maxBytes <= 0 || len(b) <= maxBytes {
+		return b, nil, err
+	}
+
+	var steps []string
+	for _, state := range budget.budgetIncrements() {
+		b, err = encodeLayers(ctx, tile, state.apply(tile, layers))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		steps = state.steps
+		if len(b) <= maxBytes {
+			break
+		}
+	}
+
+	return b, steps, nil
+}
@@ -0,0 +1,177 @@
+package atlas_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/test"
+)
+
+// pointsProvider returns a grid of tagged points for every layer
+type pointsProvider struct {
+	test.TileProvider
+}
+
+func (pp *pointsProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	var id uint64
+	for x := -20; x < 20; x++ {
+		for y := -10; y < 10; y++ {
+			id++
+			f := provider.Feature{
+				ID:       id,
+				Geometry: geom.Point{float64(x) * 100000, float64(y) * 100000},
+				SRID:     tegola.WebMercator,
+				Tags: map[string]interface{}{
+					"name":        fmt.Sprintf("point %v", id),
+					"description": fmt.Sprintf("a point at %v, %v", x, y),
+				},
+			}
+			if err := fn(&f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestEncodeBudgeted(t *testing.T) {
+	type tcase struct {
+		maxBytes      int
+		budget        atlas.TileBudget
+		expectedSteps []string
+		fits          bool
+	}
+
+	tile := slippy.NewTile(0, 0, 0, 64, tegola.WebMercator)
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("test")
+		m.MaxTileBytes = tc.maxBytes
+		m.TileBudget = tc.budget
+		m.Layers = []atlas.Layer{
+			{
+				Name:     "points",
+				Provider: &pointsProvider{},
+			},
+		}
+
+		b, steps, err := m.EncodeBudgeted(context.Background(), tile)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(steps, tc.expectedSteps) {
+			t.Errorf("steps, expected %v got %v", tc.expectedSteps, steps)
+		}
+
+		if tc.fits && len(b) > tc.maxBytes {
+			t.Errorf("size, expected at most %v got %v", tc.maxBytes, len(b))
+		}
+	}
+
+	tests := map[string]tcase{
+		"no budget": {},
+		"under budget": {
+			maxBytes: 10000000,
+			fits:     true,
+		},
+		"thin points": {
+			maxBytes: 1,
+			budget: atlas.TileBudget{
+				Steps: []string{atlas.BudgetStepThinPoints},
+			},
+			expectedSteps: []string{"thin_points=1/2", "thin_points=1/4", "thin_points=1/8"},
+		},
+		"drop attributes": {
+			maxBytes: 1,
+			budget: atlas.TileBudget{
+				Steps:          []string{atlas.BudgetStepDropAttributes},
+				DropAttributes: []string{"description", "name"},
+			},
+			expectedSteps: []string{"drop_attribute=description", "drop_attribute=name"},
+		},
+		"drop layers": {
+			maxBytes: 100,
+			budget: atlas.TileBudget{
+				Steps:      []string{atlas.BudgetStepDropAttributes, atlas.BudgetStepDropLayers},
+				DropLayers: []string{"points"},
+			},
+			expectedSteps: []string{"drop_layer=points"},
+			fits:          true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+// jaggedProvider returns a polygon with a ring of many jagged vertices for every layer
+type jaggedProvider struct {
+	test.TileProvider
+}
+
+func (jp *jaggedProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	var ring [][2]float64
+	for i := 0; i < 2000; i++ {
+		// about 500 pixels at z0, alternating by half a pixel
+		r := 5000000.0
+		if i%2 == 0 {
+			r += 5000
+		}
+		a := 2 * math.Pi * float64(i) / 2000
+		ring = append(ring, [2]float64{r * math.Cos(a), r * math.Sin(a)})
+	}
+
+	return fn(&provider.Feature{
+		ID:       1,
+		Geometry: geom.Polygon{ring},
+		SRID:     tegola.WebMercator,
+	})
+}
+
+func TestEncodeBudgetedSimplify(t *testing.T) {
+	tile := slippy.NewTile(0, 0, 0, 64, tegola.WebMercator)
+
+	m := atlas.NewWebMercatorMap("test")
+	m.Layers = []atlas.Layer{
+		{
+			Name:     "polygons",
+			Provider: &jaggedProvider{},
+		},
+	}
+
+	full, _, err := m.EncodeBudgeted(context.Background(), tile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.MaxTileBytes = 1
+	m.TileBudget = atlas.TileBudget{
+		Steps: []string{atlas.BudgetStepSimplify},
+	}
+
+	simplified, steps, err := m.EncodeBudgeted(context.Background(), tile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedSteps := []string{"simplify=2x", "simplify=4x", "simplify=8x"}
+	if !reflect.DeepEqual(steps, expectedSteps) {
+		t.Errorf("steps, expected %v got %v", expectedSteps, steps)
+	}
+
+	// the simplified tile should be a fraction of the size
+	if len(simplified) > len(full)/2 {
+		t.Errorf("size, expected at most %v got %v", len(full)/2, len(simplified))
+	}
+}
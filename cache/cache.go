@@ -48,7 +48,7 @@ func ParseKey(str string) (*Key, error) {
 	switch len(keyParts) {
 	case 5: // map, layer, z, x, y
 		key.MapName = keyParts[0]
-		key.LayerName = keyParts[1]
+		key.LayerName = strings.Join(SplitLayerNames(keyParts[1]), ",")
 		zxy = keyParts[2:]
 	case 4: // map, z, x, y
 		key.MapName = keyParts[0]
@@ -90,7 +90,16 @@ func ParseKey(str string) (*Key, error) {
 
 	// trim the extension if it exists
 	yParts := strings.Split(zxy[2], ".")
-	placeholder, err = strconv.ParseUint(yParts[0], 10, 64)
+	y := yParts[0]
+	// png and svg renderings (i.e. 3.png or 3@2x.png) are variants of the tile
+	if len(yParts) == 2 && (yParts[1] == "png" || yParts[1] == "svg") {
+		key.Variant = "." + yParts[1]
+		if i := strings.Index(y, "@"); i != -1 {
+			key.Variant = y[i:] + key.Variant
+			y = y[:i]
+		}
+	}
+	placeholder, err = strconv.ParseUint(y, 10, 64)
 	if err != nil || placeholder > maxXYatZ {
 		err = ErrInvalidFileKey{
 			path: str,
@@ -106,12 +115,30 @@ func ParseKey(str string) (*Key, error) {
 	return &key, nil
 }
 
+// SplitLayerNames splits a comma separated list of layer names. Empty and repeated names
+// are dropped (i.e. roads,,water,roads) so requests for the same layers share a key
+func SplitLayerNames(s string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(s, ",") {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
 type Key struct {
 	MapName   string
 	LayerName string
 	Z         uint
 	X         uint
 	Y         uint
+	// Variant is the suffix of a rendering of the tile other than the vector tile (i.e. .png, @2x.png or .svg).
+	// It's empty for vector tiles.
+	Variant string
 }
 
 func (k Key) String() string {
@@ -120,7 +147,7 @@ func (k Key) String() string {
 		k.LayerName,
 		strconv.FormatUint(uint64(k.Z), 10),
 		strconv.FormatUint(uint64(k.X), 10),
-		strconv.FormatUint(uint64(k.Y), 10))
+		strconv.FormatUint(uint64(k.Y), 10)+k.Variant)
 }
 
 // InitFunc initilize a cache given a config map.
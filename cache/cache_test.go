@@ -39,6 +39,26 @@ func TestParseKey(t *testing.T) {
 				LayerName: "buildings",
 			},
 		},
+		{
+			input: "/osm/roads,,water,roads/12/11/123",
+			expected: &cache.Key{
+				Z:         12,
+				X:         11,
+				Y:         123,
+				MapName:   "osm",
+				LayerName: "roads,water",
+			},
+		},
+		{
+			input: "/osm/12/11/123@2x.png",
+			expected: &cache.Key{
+				Z:       12,
+				X:       11,
+				Y:       123,
+				MapName: "osm",
+				Variant: "@2x.png",
+			},
+		},
 	}
 
 	for i, tc := range testcases {
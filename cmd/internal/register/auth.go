@@ -0,0 +1,87 @@
+package register
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-spatial/tegola/config"
+	"github.com/go-spatial/tegola/internal/env"
+	"github.com/go-spatial/tegola/server/auth"
+)
+
+var (
+	ErrAuthNotConfigured = errors.New("register: 'auth' requires at least one of 'api_keys', 'hmac_keys', 'jwt_secret' or 'jwt_jwks_file'")
+)
+
+// Auth builds an authenticator from the webserver auth config. If the
+// config is nil, nil is returned and authentication is disabled.
+func Auth(conf *config.Auth) (auth.Authenticator, error) {
+	if conf == nil {
+		return nil, nil
+	}
+
+	var chain auth.Chain
+
+	// signed URLs are checked first as they are the most specific
+	if len(conf.HMACKeys) > 0 {
+		keys, err := authKeys(conf.HMACKeys)
+		if err != nil {
+			return nil, fmt.Errorf("register: 'hmac_keys' %v", err)
+		}
+
+		chain = append(chain, auth.HMAC{Keys: keys})
+	}
+
+	if conf.JWTSecret != "" || conf.JWKSFile != "" {
+		a := auth.JWT{
+			Secret: []byte(conf.JWTSecret),
+			Param:  string(conf.JWTParam),
+		}
+
+		if conf.JWKSFile != "" {
+			keys, err := auth.LoadJWKS(string(conf.JWKSFile))
+			if err != nil {
+				return nil, err
+			}
+			a.Keys = keys
+		}
+
+		chain = append(chain, a)
+	}
+
+	if len(conf.APIKeys) > 0 {
+		keys, err := authKeys(conf.APIKeys)
+		if err != nil {
+			return nil, fmt.Errorf("register: 'api_keys' %v", err)
+		}
+
+		chain = append(chain, auth.APIKey{
+			Header: string(conf.APIKeyHeader),
+			Param:  string(conf.APIKeyParam),
+			Keys:   keys,
+		})
+	}
+
+	if len(chain) == 0 {
+		return nil, ErrAuthNotConfigured
+	}
+
+	return chain, nil
+}
+
+// authKeys converts a table of key ids to secrets
+func authKeys(d env.Dict) (map[string]string, error) {
+	keys := make(map[string]string, len(d))
+	for k := range d {
+		v, err := d.String(k, nil)
+		if err != nil {
+			return nil, err
+		}
+		if v == "" {
+			return nil, fmt.Errorf("key (%v) is empty", k)
+		}
+		keys[k] = v
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,53 @@
+package register
+
+import (
+	"math"
+	"time"
+
+	"github.com/go-spatial/tegola/config"
+	"github.com/go-spatial/tegola/server"
+)
+
+// RateLimit builds the per client rate limiter from the webserver config. If
+// rate_limit is not set, nil is returned and rate limiting is disabled.
+func RateLimit(conf config.Webserver) (*server.RateLimiter, error) {
+	if conf.RateLimit == nil {
+		return nil, nil
+	}
+
+	burst := int(math.Ceil(float64(*conf.RateLimit)))
+	if conf.RateLimitBurst != nil {
+		burst = int(*conf.RateLimitBurst)
+	}
+
+	var proxies []string
+	for _, p := range conf.TrustedProxies {
+		proxies = append(proxies, string(p))
+	}
+	trusted, err := server.ParseTrustedProxies(proxies)
+	if err != nil {
+		return nil, err
+	}
+
+	l := server.NewRateLimiter(float64(*conf.RateLimit), burst)
+	l.TrustedProxies = trusted
+	return l, nil
+}
+
+// RenderLimit builds the render concurrency limiter from the webserver config. If
+// max_concurrent_renders is not set, nil is returned and renders are not limited.
+func RenderLimit(conf config.Webserver) *server.RenderLimiter {
+	if conf.MaxConcurrentRenders == nil {
+		return nil
+	}
+
+	queueSize := server.DefaultRenderQueueSize
+	if conf.RenderQueueSize != nil {
+		queueSize = int(*conf.RenderQueueSize)
+	}
+	timeout := server.DefaultRenderQueueTimeout
+	if conf.RenderQueueTimeout != nil {
+		timeout = time.Duration(float64(*conf.RenderQueueTimeout) * float64(time.Second))
+	}
+	return server.NewRenderLimiter(int(*conf.MaxConcurrentRenders), queueSize, timeout)
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/config"
+	"github.com/go-spatial/tegola/internal/env"
+	"github.com/go-spatial/tegola/mvt"
 	"github.com/go-spatial/tegola/provider"
 )
 
@@ -55,6 +57,224 @@ func (e ErrDefaultTagsInvalid) Error() string {
 	return fmt.Sprintf("'default_tags' for 'provider_layer' (%v) should be a TOML table", e.ProviderLayer)
 }
 
+type ErrMaxTileBytesInvalid struct {
+	Map string
+}
+
+func (e ErrMaxTileBytesInvalid) Error() string {
+	return fmt.Sprintf("'max_tile_bytes' for map (%v) can not be negative", e.Map)
+}
+
+type ErrMinSizeInvalid struct {
+	ProviderLayer string
+}
+
+func (e ErrMinSizeInvalid) Error() string {
+	return fmt.Sprintf("'min_area_px' and 'min_length_px' for 'provider_layer' (%v) can not be negative", e.ProviderLayer)
+}
+
+type ErrSimplifyInvalid struct {
+	ProviderLayer string
+	Err           error
+}
+
+func (e ErrSimplifyInvalid) Error() string {
+	return fmt.Sprintf("simplification options for 'provider_layer' (%v) are invalid: %v", e.ProviderLayer, e.Err)
+}
+
+type ErrStyleInvalid struct {
+	ProviderLayer string
+	Err           error
+}
+
+func (e ErrStyleInvalid) Error() string {
+	return fmt.Sprintf("style for 'provider_layer' (%v) is invalid: %v", e.ProviderLayer, e.Err)
+}
+
+type ErrCompositeMapInvalid struct {
+	Name string
+	Err  error
+}
+
+func (e ErrCompositeMapInvalid) Error() string {
+	return fmt.Sprintf("composite map (%v) is invalid: %v", e.Name, e.Err)
+}
+
+type ErrAttributesInvalid struct {
+	ProviderLayer string
+	Err           error
+}
+
+func (e ErrAttributesInvalid) Error() string {
+	return fmt.Sprintf("invalid 'attributes' for 'provider_layer' (%v): %v", e.ProviderLayer, e.Err)
+}
+
+type ErrFilterInvalid struct {
+	ProviderLayer string
+	Err           error
+}
+
+func (e ErrFilterInvalid) Error() string {
+	return fmt.Sprintf("invalid 'filter' for 'provider_layer' (%v): %v", e.ProviderLayer, e.Err)
+}
+
+type ErrClusterInvalid struct {
+	ProviderLayer string
+	Err           error
+}
+
+func (e ErrClusterInvalid) Error() string {
+	return fmt.Sprintf("invalid 'cluster' for 'provider_layer' (%v): %v", e.ProviderLayer, e.Err)
+}
+
+// layerCluster converts the cluster config into an atlas cluster
+func layerCluster(cfg *config.MapLayerCluster) (*atlas.Cluster, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	cluster := atlas.Cluster{
+		MaxZoom: tegola.MaxZ,
+		Radius:  atlas.DefaultClusterRadius,
+	}
+	if cfg.MaxZoom != nil {
+		cluster.MaxZoom = uint(*cfg.MaxZoom)
+	}
+	if cfg.Radius != nil {
+		cluster.Radius = float64(*cfg.Radius)
+	}
+
+	for _, a := range cfg.Aggregates {
+		cluster.Aggregates = append(cluster.Aggregates, atlas.ClusterAggregate{
+			Tag:  string(a.Tag),
+			Func: string(a.Func),
+			Name: string(a.Name),
+		})
+	}
+
+	if err := cluster.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cluster, nil
+}
+
+// layerStyle converts the style config into an atlas layer style
+func layerStyle(cfg *config.MapLayerStyle) (*atlas.LayerStyle, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	style := atlas.LayerStyle{
+		FillColor:        string(cfg.FillColor),
+		FillOutlineColor: string(cfg.FillOutlineColor),
+		LineColor:        string(cfg.LineColor),
+		CircleColor:      string(cfg.CircleColor),
+	}
+	if cfg.LineWidth != nil {
+		style.LineWidth = float64(*cfg.LineWidth)
+	}
+	if cfg.CircleRadius != nil {
+		style.CircleRadius = float64(*cfg.CircleRadius)
+	}
+
+	if err := style.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &style, nil
+}
+
+// layerAttributes converts the attributes config into an atlas attribute pipeline
+func layerAttributes(cfg *config.MapLayerAttributes) (*atlas.Attributes, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	attrs := atlas.Attributes{
+		Include: envStrings(cfg.Include),
+		Exclude: envStrings(cfg.Exclude),
+	}
+
+	var err error
+	if attrs.Rename, err = stringMap(cfg.Rename); err != nil {
+		return nil, err
+	}
+	if attrs.Cast, err = stringMap(cfg.Cast); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Round) > 0 {
+		attrs.Round = make(map[string]int, len(cfg.Round))
+		for k := range cfg.Round {
+			places, err := cfg.Round.Int(k, nil)
+			if err != nil {
+				return nil, err
+			}
+			if places < 0 {
+				return nil, fmt.Errorf("round for (%v) can not be negative", k)
+			}
+			attrs.Round[k] = places
+		}
+	}
+
+	if len(cfg.Map) > 0 {
+		attrs.Map = make(map[string]map[string]interface{}, len(cfg.Map))
+		for k, table := range cfg.Map {
+			attrs.Map[k] = map[string]interface{}(table)
+		}
+	}
+
+	for _, z := range cfg.Zooms {
+		zoom := atlas.AttributeZoom{
+			MaxZoom: tegola.MaxZ,
+			Include: envStrings(z.Include),
+			Exclude: envStrings(z.Exclude),
+		}
+		if z.MinZoom != nil {
+			zoom.MinZoom = uint(*z.MinZoom)
+		}
+		if z.MaxZoom != nil {
+			zoom.MaxZoom = uint(*z.MaxZoom)
+		}
+		attrs.Zooms = append(attrs.Zooms, zoom)
+	}
+
+	if err := attrs.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &attrs, nil
+}
+
+func envStrings(vals []env.String) []string {
+	if len(vals) == 0 {
+		return nil
+	}
+
+	strs := make([]string, len(vals))
+	for i := range vals {
+		strs[i] = string(vals[i])
+	}
+	return strs
+}
+
+func stringMap(d env.Dict) (map[string]string, error) {
+	if len(d) == 0 {
+		return nil, nil
+	}
+
+	m := make(map[string]string, len(d))
+	for k := range d {
+		v, err := d.String(k, nil)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
 // Maps registers maps with with atlas
 func Maps(a *atlas.Atlas, maps []config.Map, providers map[string]provider.Tiler) error {
 
@@ -78,6 +298,34 @@ func Maps(a *atlas.Atlas, maps []config.Map, providers map[string]provider.Tiler
 			)
 		}
 
+		if m.Auth != nil {
+			for _, k := range m.Auth.Keys {
+				newMap.AllowedKeys = append(newMap.AllowedKeys, string(k))
+			}
+
+			if len(m.Auth.Claims) > 0 {
+				newMap.AllowedClaims = map[string]interface{}(m.Auth.Claims)
+			}
+		}
+
+		if m.MaxTileBytes != nil {
+			if *m.MaxTileBytes < 0 {
+				return ErrMaxTileBytesInvalid{Map: string(m.Name)}
+			}
+			newMap.MaxTileBytes = int(*m.MaxTileBytes)
+		}
+
+		if m.TileBudget != nil {
+			newMap.TileBudget = atlas.TileBudget{
+				Steps:          envStrings(m.TileBudget.Steps),
+				DropLayers:     envStrings(m.TileBudget.DropLayers),
+				DropAttributes: envStrings(m.TileBudget.DropAttributes),
+			}
+			if err := newMap.TileBudget.Validate(); err != nil {
+				return err
+			}
+		}
+
 		// iterate our layers
 		for _, l := range m.Layers {
 			// split our provider name (provider.layer) into [provider,layer]
@@ -136,6 +384,96 @@ func Maps(a *atlas.Atlas, maps []config.Map, providers map[string]provider.Tiler
 				}
 			}
 
+			attributes, err := layerAttributes(l.Attributes)
+			if err != nil {
+				return ErrAttributesInvalid{
+					ProviderLayer: string(l.ProviderLayer),
+					Err:           err,
+				}
+			}
+
+			var filter *atlas.Filter
+			if l.Filter != "" {
+				if filter, err = atlas.ParseFilter(string(l.Filter)); err != nil {
+					return ErrFilterInvalid{
+						ProviderLayer: string(l.ProviderLayer),
+						Err:           err,
+					}
+				}
+			}
+
+			cluster, err := layerCluster(l.Cluster)
+			if err != nil {
+				return ErrClusterInvalid{
+					ProviderLayer: string(l.ProviderLayer),
+					Err:           err,
+				}
+			}
+
+			var labelPoints *atlas.LabelPoints
+			if l.LabelPoints != nil {
+				labelPoints = &atlas.LabelPoints{
+					Name:    string(l.LabelPoints.Name),
+					Mode:    string(l.LabelPoints.Mode),
+					AreaTag: string(l.LabelPoints.AreaTag),
+				}
+				if labelPoints.Mode == "" {
+					labelPoints.Mode = atlas.DefaultLabelPointsMode
+				}
+				if labelPoints.AreaTag == "" {
+					labelPoints.AreaTag = atlas.DefaultLabelPointsAreaTag
+				}
+				if err := labelPoints.Validate(); err != nil {
+					return err
+				}
+			}
+
+			var minAreaPx, minLengthPx float64
+			if l.MinAreaPx != nil {
+				minAreaPx = float64(*l.MinAreaPx)
+			}
+			if l.MinLengthPx != nil {
+				minLengthPx = float64(*l.MinLengthPx)
+			}
+			if minAreaPx < 0 || minLengthPx < 0 {
+				return ErrMinSizeInvalid{
+					ProviderLayer: string(l.ProviderLayer),
+				}
+			}
+
+			simplifier, err := mvt.NewSimplifier(string(l.Simplifier))
+			if err != nil {
+				return ErrSimplifyInvalid{
+					ProviderLayer: string(l.ProviderLayer),
+					Err:           err,
+				}
+			}
+
+			var simplifyTolerance float64
+			if l.SimplifyTolerance != nil {
+				simplifyTolerance = float64(*l.SimplifyTolerance)
+			}
+			if simplifyTolerance < 0 {
+				return ErrSimplifyInvalid{
+					ProviderLayer: string(l.ProviderLayer),
+					Err:           fmt.Errorf("'simplify_tolerance' (%v) can not be negative", simplifyTolerance),
+				}
+			}
+
+			var simplifyMaxZoom *uint
+			if l.SimplifyMaxZoom != nil {
+				z := uint(*l.SimplifyMaxZoom)
+				simplifyMaxZoom = &z
+			}
+
+			style, err := layerStyle(l.Style)
+			if err != nil {
+				return ErrStyleInvalid{
+					ProviderLayer: string(l.ProviderLayer),
+					Err:           err,
+				}
+			}
+
 			var minZoom uint
 			if l.MinZoom != nil {
 				minZoom = uint(*l.MinZoom)
@@ -148,14 +486,26 @@ func Maps(a *atlas.Atlas, maps []config.Map, providers map[string]provider.Tiler
 
 			// add our layer to our layers slice
 			newMap.Layers = append(newMap.Layers, atlas.Layer{
-				Name:              string(l.Name),
-				ProviderLayerName: providerLayer[1],
-				MinZoom:           minZoom,
-				MaxZoom:           maxZoom,
-				Provider:          provider,
-				DefaultTags:       defaultTags,
-				GeomType:          layerGeomType,
-				DontSimplify:      bool(l.DontSimplify),
+				Name:               string(l.Name),
+				ProviderLayerName:  providerLayer[1],
+				MinZoom:            minZoom,
+				MaxZoom:            maxZoom,
+				Provider:           provider,
+				DefaultTags:        defaultTags,
+				GeomType:           layerGeomType,
+				DontSimplify:       bool(l.DontSimplify),
+				Simplifier:         simplifier,
+				SimplifyTolerance:  simplifyTolerance,
+				SimplifyMaxZoom:    simplifyMaxZoom,
+				Attributes:         attributes,
+				Filter:             filter,
+				Cluster:            cluster,
+				LabelPoints:        labelPoints,
+				MinAreaPx:          minAreaPx,
+				MinLengthPx:        minLengthPx,
+				MergeSmallPolygons: bool(l.MergeSmallPolygons),
+				Overzoom:           bool(l.Overzoom) || bool(m.Overzoom),
+				Style:              style,
 			})
 		}
 
@@ -164,3 +514,40 @@ func Maps(a *atlas.Atlas, maps []config.Map, providers map[string]provider.Tiler
 
 	return nil
 }
+
+// CompositeMaps registers the composite map aliases. The maps must be registered first.
+func CompositeMaps(a *atlas.Atlas, composites []config.CompositeMap) error {
+	for _, c := range composites {
+		name := string(c.Name)
+
+		switch {
+		case name == "":
+			return ErrCompositeMapInvalid{Name: name, Err: fmt.Errorf("missing name")}
+		case strings.Contains(name, ","):
+			return ErrCompositeMapInvalid{Name: name, Err: fmt.Errorf("the name can not contain a comma")}
+		case len(c.Maps) == 0:
+			return ErrCompositeMapInvalid{Name: name, Err: fmt.Errorf("missing maps")}
+		}
+
+		if _, err := a.Map(name); err == nil {
+			return ErrCompositeMapInvalid{Name: name, Err: fmt.Errorf("a map with the same name exists")}
+		}
+
+		composite := atlas.Composite{
+			Name:         name,
+			PrefixLayers: bool(c.PrefixLayers),
+		}
+		for _, m := range c.Maps {
+			composite.Maps = append(composite.Maps, string(m))
+		}
+
+		// checks the maps exist and their layer names don't collide
+		if _, err := a.CompositeMap(composite); err != nil {
+			return ErrCompositeMapInvalid{Name: name, Err: err}
+		}
+
+		a.AddComposite(composite)
+	}
+
+	return nil
+}
@@ -1,12 +1,14 @@
 package register_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/cmd/internal/register"
 	"github.com/go-spatial/tegola/config"
 	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/internal/env"
 )
 
 func TestMaps(t *testing.T) {
@@ -128,6 +130,106 @@ func TestMaps(t *testing.T) {
 				ProviderLayer: "test.debug-tile-outline",
 			},
 		},
+		"attributes invalid cast": {
+			maps: []config.Map{
+				{
+					Name: "foo",
+					Layers: []config.MapLayer{
+						{
+							ProviderLayer: "test.debug-tile-outline",
+							Attributes: &config.MapLayerAttributes{
+								Cast: env.Dict{"name": "date"},
+							},
+						},
+					},
+				},
+			},
+			providers: []dict.Dict{
+				{
+					"name": "test",
+					"type": "debug",
+				},
+			},
+			expectedErr: register.ErrAttributesInvalid{
+				ProviderLayer: "test.debug-tile-outline",
+				Err:           atlas.ErrInvalidAttributeCast{Key: "name", Type: "date"},
+			},
+		},
+		"filter invalid": {
+			maps: []config.Map{
+				{
+					Name: "foo",
+					Layers: []config.MapLayer{
+						{
+							ProviderLayer: "test.debug-tile-outline",
+							Filter:        "class = ",
+						},
+					},
+				},
+			},
+			providers: []dict.Dict{
+				{
+					"name": "test",
+					"type": "debug",
+				},
+			},
+			expectedErr: register.ErrFilterInvalid{
+				ProviderLayer: "test.debug-tile-outline",
+				Err: atlas.ErrInvalidFilter{
+					Filter: "class = ",
+					Pos:    8,
+					Reason: `expected a value got "end of filter"`,
+				},
+			},
+		},
+		"simplifier invalid": {
+			maps: []config.Map{
+				{
+					Name: "foo",
+					Layers: []config.MapLayer{
+						{
+							ProviderLayer: "test.debug-tile-outline",
+							Simplifier:    "bezier",
+						},
+					},
+				},
+			},
+			providers: []dict.Dict{
+				{
+					"name": "test",
+					"type": "debug",
+				},
+			},
+			expectedErr: register.ErrSimplifyInvalid{
+				ProviderLayer: "test.debug-tile-outline",
+				Err:           errors.New("mvt: unsupported simplifier (bezier). supported simplifiers: douglas_peucker, visvalingam"),
+			},
+		},
+		"style invalid": {
+			maps: []config.Map{
+				{
+					Name: "foo",
+					Layers: []config.MapLayer{
+						{
+							ProviderLayer: "test.debug-tile-outline",
+							Style: &config.MapLayerStyle{
+								LineColor: "red",
+							},
+						},
+					},
+				},
+			},
+			providers: []dict.Dict{
+				{
+					"name": "test",
+					"type": "debug",
+				},
+			},
+			expectedErr: register.ErrStyleInvalid{
+				ProviderLayer: "test.debug-tile-outline",
+				Err:           errors.New("atlas: invalid style color (red). expected a hex, rgb or rgba color"),
+			},
+		},
 		"success": {
 			maps: []config.Map{},
 			providers: []dict.Dict{
@@ -144,3 +246,86 @@ func TestMaps(t *testing.T) {
 		t.Run(name, func(t *testing.T) { fn(t, tc) })
 	}
 }
+
+func TestCompositeMaps(t *testing.T) {
+	type tcase struct {
+		composites  []config.CompositeMap
+		expectedErr error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		var a atlas.Atlas
+		for _, name := range []string{"basemap", "transit"} {
+			m := atlas.NewWebMercatorMap(name)
+			m.Layers = []atlas.Layer{{Name: "roads"}, {Name: name}}
+			a.AddMap(m)
+		}
+
+		err := register.CompositeMaps(&a, tc.composites)
+		if tc.expectedErr != nil {
+			if err == nil || err.Error() != tc.expectedErr.Error() {
+				t.Errorf("invalid error. expected: %v, got: %v", tc.expectedErr, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Errorf("unexpected err: %v", err)
+			return
+		}
+
+		for _, c := range tc.composites {
+			if _, err := a.Composite(string(c.Name)); err != nil {
+				t.Errorf("composite (%v) not registered: %v", c.Name, err)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"comma in name": {
+			composites: []config.CompositeMap{
+				{Name: "a,b", Maps: []env.String{"basemap"}},
+			},
+			expectedErr: register.ErrCompositeMapInvalid{
+				Name: "a,b",
+				Err:  errors.New("the name can not contain a comma"),
+			},
+		},
+		"map name clash": {
+			composites: []config.CompositeMap{
+				{Name: "basemap", Maps: []env.String{"transit"}},
+			},
+			expectedErr: register.ErrCompositeMapInvalid{
+				Name: "basemap",
+				Err:  errors.New("a map with the same name exists"),
+			},
+		},
+		"map not found": {
+			composites: []config.CompositeMap{
+				{Name: "all", Maps: []env.String{"basemap", "poi"}},
+			},
+			expectedErr: register.ErrCompositeMapInvalid{
+				Name: "all",
+				Err:  atlas.ErrMapNotFound{Name: "poi"},
+			},
+		},
+		"layer collision": {
+			composites: []config.CompositeMap{
+				{Name: "all", Maps: []env.String{"basemap", "transit"}},
+			},
+			expectedErr: register.ErrCompositeMapInvalid{
+				Name: "all",
+				Err:  atlas.ErrCompositeLayerCollision{Layer: "roads", Map1: "basemap", Map2: "transit"},
+			},
+		},
+		"success prefixed": {
+			composites: []config.CompositeMap{
+				{Name: "all", Maps: []env.String{"basemap", "transit"}, PrefixLayers: true},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -0,0 +1,169 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-spatial/cobra"
+	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	cachecmd "github.com/go-spatial/tegola/cmd/tegola/cmd/cache"
+	gdcmd "github.com/go-spatial/tegola/internal/cmd"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/internal/timing"
+	"github.com/go-spatial/tegola/provider"
+)
+
+// flag parameters
+var (
+	// name of the map to render
+	benchMap string
+	// file of tile names, - for stdin
+	benchTileList string
+	// format of the tile names
+	benchFormat string
+	// the number of tiles rendered at once
+	benchConcurrency int
+	// the number of slowest tiles reported
+	benchWorst int
+)
+
+var Cmd = &cobra.Command{
+	Use:   "bench",
+	Short: "benchmark rendering a list of tiles",
+	Long: `benchmark rendering a list of tiles of a map. The tiles are rendered like the server does, without
+reading or writing the cache. The render time is reported by zoom, along with the time spent by each
+layer fetching features from the provider, converting, simplifying, cleaning (clipping and validating)
+and encoding them, and the slowest tiles.`,
+	Example: "tegola bench --map osm --tile-list tiles.txt --concurrency 4",
+	PreRunE: benchValidate,
+	RunE:    benchCommand,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&benchMap, "map", "", "", "map name as defined in the config")
+	Cmd.Flags().StringVarP(&benchTileList, "tile-list", "", "-", "file with a tile name per line. - for stdin")
+	Cmd.Flags().StringVarP(&benchFormat, "format", "", "/zxy", "4 character string where the first character is a non-numeric delimiter followed by 'z', 'x' and 'y' defining the coordinate order")
+	Cmd.Flags().IntVarP(&benchConcurrency, "concurrency", "", runtime.NumCPU(), "the number of tiles rendered at once. defaults to the number of CPUs on the machine")
+	Cmd.Flags().IntVarP(&benchWorst, "worst", "", 10, "the number of slowest tiles to report")
+}
+
+func benchValidate(cmd *cobra.Command, args []string) error {
+	if benchMap == "" {
+		return fmt.Errorf("--map is required")
+	}
+	if benchConcurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	return nil
+}
+
+func benchCommand(cmd *cobra.Command, args []string) error {
+	format, err := cachecmd.NewFormat(benchFormat)
+	if err != nil {
+		return err
+	}
+
+	m, err := atlas.GetMap(benchMap)
+	if err != nil {
+		return err
+	}
+	// the map is rendered from an atlas without a cache so overzoomed layers don't read or
+	// write their ancestor tiles from the cache
+	var a atlas.Atlas
+	a.AddMap(m)
+	if m, err = a.Map(benchMap); err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if strings.TrimSpace(benchTileList) != "-" {
+		f, err := os.Open(benchTileList)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer gdcmd.New().Complete()
+	gdcmd.OnComplete(provider.Cleanup)
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-gdcmd.Cancelled():
+			cancel()
+		}
+	}()
+
+	tiles := cachecmd.GenerateTilesForTileList(ctx, in, true, nil, format)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results []TileResult
+	)
+
+	start := time.Now()
+	wg.Add(benchConcurrency)
+	for i := 0; i < benchConcurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for tile := range tiles.Channel() {
+				res := renderTile(ctx, m, tile)
+				if res.Err != nil {
+					log.Errorf("error rendering tile (%v/%v/%v): %v", res.Z, res.X, res.Y, res.Err)
+				}
+
+				mu.Lock()
+				results = append(results, res)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if err := tiles.Err(); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "rendered %v tiles of map (%v) in %v with a concurrency of %v\n\n", len(results), benchMap, elapsed, benchConcurrency)
+	NewReport(results, benchWorst).Print(out)
+
+	return nil
+}
+
+// renderTile encodes the tile of the map like the server does, timing the stages of its layers
+func renderTile(ctx context.Context, m atlas.Map, tile *slippy.Tile) TileResult {
+	z, x, y := tile.ZXY()
+	res := TileResult{Z: z, X: x, Y: y}
+
+	rec := timing.NewRecorder()
+	ctx = timing.NewContext(ctx, rec)
+
+	start := time.Now()
+	b, err := m.FilterLayersByZoom(z).Encode(ctx, slippy.NewTile(z, x, y, cachecmd.TileBuffer(), tegola.WebMercator))
+	res.Duration = time.Since(start)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	res.Bytes = len(b)
+	res.Stages = rec.Durations()
+	return res
+}
@@ -0,0 +1,175 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/go-spatial/tegola/internal/timing"
+)
+
+// TileResult is the result of rendering a tile
+type TileResult struct {
+	Z, X, Y  uint
+	Duration time.Duration
+	// the size of the encoded, gzipped tile
+	Bytes int
+	Err   error
+	// the time spent in each stage by layer name
+	Stages map[string]map[timing.Stage]time.Duration
+}
+
+// Report summarizes the results of rendering tiles
+type Report struct {
+	Tiles  int
+	Errors int
+	Zooms  []ZoomReport
+	Layers []LayerReport
+	// the slowest tiles, slowest first
+	Worst []TileResult
+}
+
+// ZoomReport summarizes the render times of the tiles of a zoom
+type ZoomReport struct {
+	Zoom          uint
+	Tiles         int
+	P50, P95, P99 time.Duration
+	Max           time.Duration
+	MeanBytes     int
+}
+
+// LayerReport is the time spent in each stage for a layer over all the tiles
+type LayerReport struct {
+	Name   string
+	Stages map[timing.Stage]time.Duration
+}
+
+// NewReport summarizes the results, keeping the worst slowest tiles. Tiles which failed to
+// render are only counted.
+func NewReport(results []TileResult, worst int) Report {
+	var (
+		r      = Report{Tiles: len(results)}
+		zooms  = map[uint][]TileResult{}
+		layers = map[string]map[timing.Stage]time.Duration{}
+		ok     []TileResult
+	)
+
+	for _, res := range results {
+		if res.Err != nil {
+			r.Errors++
+			continue
+		}
+		ok = append(ok, res)
+		zooms[res.Z] = append(zooms[res.Z], res)
+
+		for name, stages := range res.Stages {
+			if layers[name] == nil {
+				layers[name] = map[timing.Stage]time.Duration{}
+			}
+			for stage, d := range stages {
+				layers[name][stage] += d
+			}
+		}
+	}
+
+	for z, tiles := range zooms {
+		durations := make([]time.Duration, len(tiles))
+		var bytes int
+		for i := range tiles {
+			durations[i] = tiles[i].Duration
+			bytes += tiles[i].Bytes
+		}
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+		r.Zooms = append(r.Zooms, ZoomReport{
+			Zoom:      z,
+			Tiles:     len(tiles),
+			P50:       percentile(durations, 50),
+			P95:       percentile(durations, 95),
+			P99:       percentile(durations, 99),
+			Max:       durations[len(durations)-1],
+			MeanBytes: bytes / len(tiles),
+		})
+	}
+	sort.Slice(r.Zooms, func(i, j int) bool { return r.Zooms[i].Zoom < r.Zooms[j].Zoom })
+
+	for name, stages := range layers {
+		r.Layers = append(r.Layers, LayerReport{Name: name, Stages: stages})
+	}
+	sort.Slice(r.Layers, func(i, j int) bool { return r.Layers[i].Name < r.Layers[j].Name })
+
+	sort.Slice(ok, func(i, j int) bool { return ok[i].Duration > ok[j].Duration })
+	if len(ok) > worst {
+		ok = ok[:worst]
+	}
+	r.Worst = ok
+
+	return r
+}
+
+// percentile returns the nearest rank percentile p of the sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// layerName returns the name a layer is printed with
+func layerName(name string) string {
+	if name == timing.TileLayer {
+		return "(tile)"
+	}
+	return name
+}
+
+// Print writes the report in a human readable form
+func (r Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "tiles: %v, errors: %v\n", r.Tiles, r.Errors)
+
+	fmt.Fprintf(w, "\nrender time by zoom:\n")
+	fmt.Fprintf(w, "  %-5v %8v %12v %12v %12v %12v %12v\n", "zoom", "tiles", "p50", "p95", "p99", "max", "mean bytes")
+	for _, z := range r.Zooms {
+		fmt.Fprintf(w, "  %-5v %8v %12v %12v %12v %12v %12v\n", z.Zoom, z.Tiles, z.P50, z.P95, z.P99, z.Max, z.MeanBytes)
+	}
+
+	// layers are fetched concurrently so their times add up to more than the render time
+	fmt.Fprintf(w, "\ntime by layer and stage (all tiles):\n")
+	fmt.Fprintf(w, "  %-24v", "layer")
+	for _, stage := range timing.Stages {
+		fmt.Fprintf(w, " %12v", stage)
+	}
+	fmt.Fprintln(w)
+	for _, l := range r.Layers {
+		fmt.Fprintf(w, "  %-24v", layerName(l.Name))
+		for _, stage := range timing.Stages {
+			fmt.Fprintf(w, " %12v", l.Stages[stage])
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "\nslowest tiles:\n")
+	for _, t := range r.Worst {
+		name, stage, d := slowestStage(t.Stages)
+		fmt.Fprintf(w, "  %v/%v/%v: %v, %v bytes, slowest: %v %v (%v)\n", t.Z, t.X, t.Y, t.Duration, t.Bytes, layerName(name), stage, d)
+	}
+}
+
+// slowestStage returns the layer and stage the most time was spent in
+func slowestStage(stages map[string]map[timing.Stage]time.Duration) (layer string, stage timing.Stage, max time.Duration) {
+	for name, s := range stages {
+		for _, st := range timing.Stages {
+			// ties are broken by name so the output is stable
+			if d := s[st]; d > max || (d == max && d > 0 && name < layer) {
+				layer, stage, max = name, st, d
+			}
+		}
+	}
+	return layer, stage, max
+}
@@ -0,0 +1,94 @@
+package bench
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/internal/timing"
+)
+
+func TestNewReport(t *testing.T) {
+	ms := time.Millisecond
+
+	results := []TileResult{
+		{Z: 1, X: 0, Y: 0, Duration: 4 * ms, Bytes: 100, Stages: map[string]map[timing.Stage]time.Duration{
+			"roads": {timing.Fetch: 3 * ms, timing.Clean: 1 * ms},
+		}},
+		{Z: 1, X: 1, Y: 0, Duration: 2 * ms, Bytes: 300, Stages: map[string]map[timing.Stage]time.Duration{
+			"roads":          {timing.Fetch: 1 * ms},
+			timing.TileLayer: {timing.Encode: 1 * ms},
+		}},
+		{Z: 1, X: 1, Y: 1, Duration: 1 * ms, Bytes: 200},
+		{Z: 2, X: 0, Y: 0, Duration: 9 * ms, Bytes: 50},
+		{Z: 2, X: 0, Y: 1, Err: errors.New("timeout")},
+	}
+
+	r := NewReport(results, 2)
+
+	if r.Tiles != 5 || r.Errors != 1 {
+		t.Errorf("tiles and errors, expected 5 and 1 got %v and %v", r.Tiles, r.Errors)
+	}
+
+	expectedZooms := []ZoomReport{
+		{Zoom: 1, Tiles: 3, P50: 2 * ms, P95: 4 * ms, P99: 4 * ms, Max: 4 * ms, MeanBytes: 200},
+		{Zoom: 2, Tiles: 1, P50: 9 * ms, P95: 9 * ms, P99: 9 * ms, Max: 9 * ms, MeanBytes: 50},
+	}
+	if !reflect.DeepEqual(r.Zooms, expectedZooms) {
+		t.Errorf("zooms, expected %+v got %+v", expectedZooms, r.Zooms)
+	}
+
+	expectedLayers := []LayerReport{
+		{Name: timing.TileLayer, Stages: map[timing.Stage]time.Duration{timing.Encode: 1 * ms}},
+		{Name: "roads", Stages: map[timing.Stage]time.Duration{timing.Fetch: 4 * ms, timing.Clean: 1 * ms}},
+	}
+	if !reflect.DeepEqual(r.Layers, expectedLayers) {
+		t.Errorf("layers, expected %+v got %+v", expectedLayers, r.Layers)
+	}
+
+	if len(r.Worst) != 2 || r.Worst[0].Z != 2 || r.Worst[1].X != 0 || r.Worst[1].Z != 1 {
+		t.Errorf("worst, expected 2/0/0 and 1/0/0 got %+v", r.Worst)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	type tcase struct {
+		durations []time.Duration
+		p         float64
+		expected  time.Duration
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		if got := percentile(tc.durations, tc.p); got != tc.expected {
+			t.Errorf("expected %v got %v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"empty": {
+			p:        50,
+			expected: 0,
+		},
+		"p50": {
+			durations: []time.Duration{1, 2, 3, 4},
+			p:         50,
+			expected:  2,
+		},
+		"p99": {
+			durations: []time.Duration{1, 2, 3, 4},
+			p:         99,
+			expected:  4,
+		},
+		"p0": {
+			durations: []time.Duration{1, 2, 3, 4},
+			p:         0,
+			expected:  1,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-spatial/cobra" // The config from the main app
 	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/config"
 	"github.com/go-spatial/tegola/internal/log"
@@ -20,6 +21,14 @@ import (
 var Config *config.Config
 var RequireCache bool
 
+// TileBuffer returns the tile_buffer of the Config, or the default tile buffer when it's not set
+func TileBuffer() float64 {
+	if Config != nil && Config.TileBuffer != nil {
+		return float64(*Config.TileBuffer)
+	}
+	return tegola.DefaultTileBuffer
+}
+
 var Cmd = &cobra.Command{
 	Use:   "cache",
 	Short: "command to manage the cache",
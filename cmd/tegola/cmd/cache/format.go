@@ -93,7 +93,7 @@ func (f Format) Parse(val string) (z, x, y uint, err error) {
 }
 
 func (f Format) ParseTile(val string) (tile *slippy.Tile, err error) {
-	z, x, y, err := format.Parse(val)
+	z, x, y, err := f.Parse(val)
 	if err != nil {
 		return nil, err
 	}
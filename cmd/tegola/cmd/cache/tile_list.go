@@ -90,15 +90,15 @@ func tileListCommand(cmd *cobra.Command, args []string) (err error) {
 
 	log.Info("zoom list: ", zooms)
 
-	tilechannel := generateTilesForTileList(ctx, in, explicit, zooms, format)
+	tilechannel := GenerateTilesForTileList(ctx, in, explicit, zooms, format)
 
 	// start up workers here
 	return doWork(ctx, tilechannel, seedPurgeMaps, cacheConcurrency, seedPurgeWorker)
 }
 
-// generateTilesForTileList will return a channel where all the tiles in the list will be published
+// GenerateTilesForTileList will return a channel where all the tiles in the list will be published
 // if explicit is false and zooms is not empty, it will include the tiles above and below with in the provided zooms
-func generateTilesForTileList(ctx context.Context, tilelist io.Reader, explicit bool, zooms []uint, format Format) *TileChannel {
+func GenerateTilesForTileList(ctx context.Context, tilelist io.Reader, explicit bool, zooms []uint, format Format) *TileChannel {
 	tce := &TileChannel{
 		channel: make(chan *slippy.Tile),
 	}
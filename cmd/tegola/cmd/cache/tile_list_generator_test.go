@@ -49,7 +49,7 @@ func TestGenerateTilesForTileList(t *testing.T) {
 				in = strings.NewReader(tc.tileList)
 			}
 
-			tilechannel := generateTilesForTileList(context.Background(), in, tc.explicit, tc.zooms, tc.format)
+			tilechannel := GenerateTilesForTileList(context.Background(), in, tc.explicit, tc.zooms, tc.format)
 			tiles := make(sTiles, 0, len(tc.tiles))
 			for tile := range tilechannel.Channel() {
 				tiles = append(tiles, tile)
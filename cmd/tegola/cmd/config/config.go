@@ -0,0 +1,20 @@
+package config
+
+import (
+	"github.com/go-spatial/cobra"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "config",
+	Short: "command to manage config files",
+	Long:  "command to manage config files",
+	// the config commands write configs so they don't load one
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+func init() {
+	Cmd.AddCommand(InitCmd)
+	Cmd.AddCommand(ConvertCmd)
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-spatial/cobra"
+	"github.com/go-spatial/tegola/config"
+)
+
+// flag parameters
+var (
+	// format of the input, by its extension if empty
+	convertFrom string
+	// format of the output, by the extension of the output file if empty
+	convertTo string
+	// file to write the config to, stdout if empty
+	convertOutput string
+)
+
+var ConvertCmd = &cobra.Command{
+	Use:   "convert [file]",
+	Short: "convert a config between toml, yaml and json",
+	Long: `convert a config between toml, yaml and json. The config is read from the file, or stdin if the
+file is - or missing. The formats default to the extension of the input and output files.
+
+Environment variables (i.e. ${POSTGIS_PASSWORD}) are kept as they are. Comments are not.`,
+	Example: `  tegola config convert config.toml --to yaml > config.yaml
+  tegola config convert config.yaml --output config.json
+  cat config.json | tegola config convert --from json --to toml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: convertCommand,
+}
+
+func init() {
+	ConvertCmd.Flags().StringVarP(&convertFrom, "from", "", "", "format of the input: toml, yaml or json. defaults to the extension of the file, or toml")
+	ConvertCmd.Flags().StringVarP(&convertTo, "to", "", "", "format of the output: toml, yaml or json. defaults to the extension of --output")
+	ConvertCmd.Flags().StringVarP(&convertOutput, "output", "o", "", "file to write the config to. defaults to stdout")
+}
+
+func convertCommand(cmd *cobra.Command, args []string) (err error) {
+	input := "-"
+	if len(args) > 0 {
+		input = args[0]
+	}
+
+	from := config.FormatFromLocation(input)
+	if convertFrom != "" {
+		if from, err = config.ParseFormat(convertFrom); err != nil {
+			return err
+		}
+	}
+
+	var to config.Format
+	switch {
+	case convertTo != "":
+		if to, err = config.ParseFormat(convertTo); err != nil {
+			return err
+		}
+	case convertOutput != "":
+		to = config.FormatFromLocation(convertOutput)
+	default:
+		return fmt.Errorf("--to is required when writing to stdout")
+	}
+
+	var in io.Reader = os.Stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	doc, err := config.Decode(in, from)
+	if err != nil {
+		return fmt.Errorf("error decoding %v config (%v): %v", from, input, err)
+	}
+
+	var out io.Writer = cmd.OutOrStdout()
+	if convertOutput != "" {
+		f, err := os.Create(convertOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return config.Encode(out, doc, to)
+}
@@ -0,0 +1,122 @@
+package inspect
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+)
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         *uint64                `json:"id,omitempty"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// WriteGeoJSON writes the features of the summarized tile as a GeoJSON feature collection. The
+// name of a feature's layer is its "layer" property. If tile is nil the coordinates are tile
+// pixels, otherwise they're longitudes and latitudes.
+func WriteGeoJSON(w io.Writer, s *Summary, tile *tegola.Tile) error {
+	fc := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []geoJSONFeature{},
+	}
+
+	for _, ls := range s.Layers {
+		// pixels are converted at the layer's resolution
+		var t tegola.Tile
+		if tile != nil {
+			t = *tile
+			t.Extent = float64(ls.Extent)
+		}
+		pt := func(p basic.Point) [2]float64 {
+			if tile == nil {
+				return [2]float64{p[0], p[1]}
+			}
+			// FromPixel only errors for unsupported srids
+			ll, _ := t.FromPixel(tegola.WGS84, [2]float64{p[0], p[1]})
+			return ll
+		}
+
+		for _, f := range ls.features {
+			if f == nil {
+				continue
+			}
+
+			props := map[string]interface{}{}
+			for k, v := range f.Tags {
+				props[k] = v
+			}
+			props["layer"] = ls.Name
+
+			fc.Features = append(fc.Features, geoJSONFeature{
+				Type:       "Feature",
+				ID:         f.ID,
+				Geometry:   toGeoJSON(f.Geometry, pt),
+				Properties: props,
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fc)
+}
+
+func toGeoJSON(g tegola.Geometry, pt func(basic.Point) [2]float64) geoJSONGeometry {
+	line := func(l basic.Line) [][2]float64 {
+		coords := make([][2]float64, len(l))
+		for i := range l {
+			coords[i] = pt(l[i])
+		}
+		return coords
+	}
+	// geojson rings are closed
+	polygon := func(p basic.Polygon) [][][2]float64 {
+		rings := make([][][2]float64, len(p))
+		for i, r := range p {
+			rings[i] = line(r)
+			if len(r) > 0 && r[0] != r[len(r)-1] {
+				rings[i] = append(rings[i], pt(r[0]))
+			}
+		}
+		return rings
+	}
+
+	switch g := g.(type) {
+	case basic.Point:
+		return geoJSONGeometry{Type: "Point", Coordinates: pt(g)}
+	case basic.MultiPoint:
+		return geoJSONGeometry{Type: "MultiPoint", Coordinates: line(basic.Line(g))}
+	case basic.Line:
+		return geoJSONGeometry{Type: "LineString", Coordinates: line(g)}
+	case basic.MultiLine:
+		coords := make([][][2]float64, len(g))
+		for i := range g {
+			coords[i] = line(g[i])
+		}
+		return geoJSONGeometry{Type: "MultiLineString", Coordinates: coords}
+	case basic.Polygon:
+		return geoJSONGeometry{Type: "Polygon", Coordinates: polygon(g)}
+	case basic.MultiPolygon:
+		coords := make([][][][2]float64, len(g))
+		for i := range g {
+			coords[i] = polygon(g[i])
+		}
+		return geoJSONGeometry{Type: "MultiPolygon", Coordinates: coords}
+	default:
+		return geoJSONGeometry{Type: "GeometryCollection"}
+	}
+}
@@ -0,0 +1,254 @@
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-spatial/cobra"
+	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
+	cachecmd "github.com/go-spatial/tegola/cmd/tegola/cmd/cache"
+)
+
+// RequireCache is set when the tile is read from the cache
+var RequireCache bool
+
+// flag parameters
+var (
+	// name of the map to render the tile of
+	inspectMap string
+	// z/x/y of the tile
+	inspectTile string
+	// cache key of the tile
+	inspectCacheKey string
+	// print the summary as json
+	inspectJSON bool
+	// print the features as geojson
+	inspectGeoJSON bool
+	// the tile buffer in pixels, coordinates outside of it are reported
+	inspectBuffer int
+	// the max size of the encoded tile, 0 for no limit
+	inspectMaxBytes int
+)
+
+var Cmd = &cobra.Command{
+	Use:   "inspect [url or file]",
+	Short: "decode and summarize a vector tile",
+	Long: `decode and summarize a vector tile: its layers, their size, features by geometry type,
+vertices, attribute keys and the number of their values, and geometry issues such as
+self-intersecting rings, wrong winding and coordinates outside of the tile extent.
+
+The tile is read from a URL or a file, from the cache with --cache-key, or rendered from a map of
+the config with --map and --tile.`,
+	Example: `  tegola inspect https://example.com/maps/osm/10/163/395.pbf
+  tegola inspect --map osm --tile 10/163/395 --max-bytes 500000
+  tegola inspect --cache-key osm/10/163/395 --json`,
+	Args: cobra.MaximumNArgs(1),
+	// a tile over --max-bytes is not a usage error
+	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// the config is only needed for maps and the cache
+		if inspectMap == "" && inspectCacheKey == "" {
+			return nil
+		}
+		RequireCache = inspectCacheKey != ""
+
+		if cmd.HasParent() {
+			// run the parents Persistent Run commands.
+			pcmd := cmd.Parent()
+			if pcmd.PersistentPreRunE != nil {
+				if err := pcmd.PersistentPreRunE(pcmd, args); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+	RunE: inspectCommand,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&inspectMap, "map", "", "", "map name as defined in the config to render the tile of")
+	Cmd.Flags().StringVarP(&inspectTile, "tile", "", "", "z/x/y of the tile. required with --map, optional for a url or file to output geojson in longitude and latitude")
+	Cmd.Flags().StringVarP(&inspectCacheKey, "cache-key", "", "", "cache key of the tile (i.e. osm/10/163/395 or osm/roads/10/163/395)")
+	Cmd.Flags().BoolVarP(&inspectJSON, "json", "", false, "print the summary as json")
+	Cmd.Flags().BoolVarP(&inspectGeoJSON, "geojson", "", false, "print the features as a geojson feature collection")
+	Cmd.Flags().IntVarP(&inspectBuffer, "buffer", "", tegola.DefaultTileBuffer, "the tile buffer in pixels. coordinates outside of it are reported. defaults to the tile_buffer of the config with --map or --cache-key")
+	Cmd.Flags().IntVarP(&inspectMaxBytes, "max-bytes", "", 0, "fail if the encoded tile is larger than max-bytes. 0 for no limit")
+}
+
+func inspectCommand(cmd *cobra.Command, args []string) error {
+	sources := len(args)
+	if inspectMap != "" {
+		sources++
+	}
+	if inspectCacheKey != "" {
+		sources++
+	}
+	if sources != 1 {
+		return fmt.Errorf("expected one of a url, a file, --map or --cache-key")
+	}
+
+	var tile *slippy.Tile
+	if inspectTile != "" {
+		format, err := cachecmd.NewFormat("")
+		if err != nil {
+			return err
+		}
+		z, x, y, err := format.Parse(inspectTile)
+		if err != nil {
+			return err
+		}
+		tile = slippy.NewTile(z, x, y, 0, tegola.WebMercator)
+	}
+
+	ctx := context.Background()
+
+	var (
+		b   []byte
+		err error
+	)
+	switch {
+	case inspectMap != "":
+		if tile == nil {
+			return fmt.Errorf("--tile is required with --map")
+		}
+		b, err = renderTile(ctx, inspectMap, tile)
+	case inspectCacheKey != "":
+		b, tile, err = cachedTile(inspectCacheKey)
+	case strings.HasPrefix(args[0], "http://") || strings.HasPrefix(args[0], "https://"):
+		b, err = fetchTile(ctx, args[0])
+	default:
+		b, err = ioutil.ReadFile(args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	// tiles of the config's maps are encoded with its tile_buffer
+	buffer := inspectBuffer
+	if (inspectMap != "" || inspectCacheKey != "") && !cmd.Flags().Changed("buffer") {
+		buffer = int(cachecmd.TileBuffer())
+	}
+
+	summary, err := Summarize(b, buffer)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	switch {
+	case inspectGeoJSON:
+		var t *tegola.Tile
+		if tile != nil {
+			t = tegola.NewTile(tile.ZXY())
+		}
+		err = WriteGeoJSON(out, summary, t)
+	case inspectJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(summary)
+	default:
+		PrintSummary(out, summary)
+	}
+	if err != nil {
+		return err
+	}
+
+	if inspectMaxBytes > 0 && summary.Bytes > inspectMaxBytes {
+		return fmt.Errorf("tile is %v bytes, over the max of %v bytes", summary.Bytes, inspectMaxBytes)
+	}
+
+	return nil
+}
+
+// renderTile encodes the tile of the map like the server does
+func renderTile(ctx context.Context, mapName string, tile *slippy.Tile) ([]byte, error) {
+	m, err := atlas.GetMap(mapName)
+	if err != nil {
+		return nil, err
+	}
+
+	z, x, y := tile.ZXY()
+	return m.FilterLayersByZoom(z).Encode(ctx, slippy.NewTile(z, x, y, cachecmd.TileBuffer(), tegola.WebMercator))
+}
+
+// cachedTile reads the tile of the cache key from the cache
+func cachedTile(str string) ([]byte, *slippy.Tile, error) {
+	key, err := cache.ParseKey(str)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := atlas.GetCache()
+	if c == nil {
+		return nil, nil, fmt.Errorf("no cache configured")
+	}
+
+	b, hit, err := c.Get(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !hit {
+		return nil, nil, fmt.Errorf("tile (%v) is not in the cache", key)
+	}
+
+	return b, slippy.NewTile(key.Z, key.X, key.Y, 0, tegola.WebMercator), nil
+}
+
+// fetchTile requests the tile from the url
+func fetchTile(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("requesting tile (%v): %v", url, resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// PrintSummary writes the summary of the tile in a human readable form
+func PrintSummary(w io.Writer, s *Summary) {
+	fmt.Fprintf(w, "tile: %v bytes (%v gzipped), %v layers\n", s.Bytes, s.GzipBytes, len(s.Layers))
+
+	for _, l := range s.Layers {
+		fmt.Fprintf(w, "\nlayer %v: extent %v, %v bytes, %v features, %v vertices\n", l.Name, l.Extent, l.Bytes, l.Features, l.Vertices)
+
+		fmt.Fprintf(w, "  geometries:\n")
+		for _, k := range Keys(l.Geometries) {
+			fmt.Fprintf(w, "    %v: %v\n", k, l.Geometries[k])
+		}
+
+		if len(l.Attributes) > 0 {
+			fmt.Fprintf(w, "  attributes (distinct values):\n")
+			for _, k := range Keys(l.Attributes) {
+				fmt.Fprintf(w, "    %v: %v\n", k, l.Attributes[k])
+			}
+		}
+
+		if len(l.Issues) > 0 {
+			fmt.Fprintf(w, "  issues:\n")
+			for _, i := range l.Issues {
+				fmt.Fprintf(w, "    %v\n", i)
+			}
+		}
+	}
+}
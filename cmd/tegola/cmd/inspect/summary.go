@@ -0,0 +1,302 @@
+package inspect
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/maths"
+	"github.com/go-spatial/tegola/mvt"
+	"github.com/go-spatial/tegola/mvt/vector_tile"
+)
+
+// Summary describes an encoded vector tile
+type Summary struct {
+	// the size of the encoded tile
+	Bytes int `json:"bytes"`
+	// the size of the gzipped tile
+	GzipBytes int            `json:"gzip_bytes"`
+	Layers    []LayerSummary `json:"layers"`
+}
+
+// LayerSummary describes a layer of a vector tile
+type LayerSummary struct {
+	Name     string `json:"name"`
+	Extent   int    `json:"extent"`
+	Bytes    int    `json:"bytes"`
+	Features int    `json:"features"`
+	Vertices int    `json:"vertices"`
+	// the number of features by geometry type
+	Geometries map[string]int `json:"geometries"`
+	// the number of distinct values by attribute key
+	Attributes map[string]int `json:"attributes"`
+	Issues     []Issue        `json:"issues,omitempty"`
+
+	// the decoded features, nil for the features which failed to decode
+	features []*mvt.Feature
+}
+
+// Issue is a problem with the geometry of a feature
+type Issue struct {
+	// the index of the feature in the layer
+	Feature int     `json:"feature"`
+	ID      *uint64 `json:"id,omitempty"`
+	Reason  string  `json:"reason"`
+}
+
+func (i Issue) String() string {
+	if i.ID != nil {
+		return fmt.Sprintf("feature %v (id %v): %v", i.Feature, *i.ID, i.Reason)
+	}
+	return fmt.Sprintf("feature %v: %v", i.Feature, i.Reason)
+}
+
+// Summarize decodes the vector tile, which may be gzipped, and summarizes its layers. Coordinates
+// further than buffer pixels outside of a layer's extent are reported as issues.
+func Summarize(b []byte, buffer int) (*Summary, error) {
+	var s Summary
+
+	// gzip magic number
+	if len(b) > 2 && b[0] == 0x1f && b[1] == 0x8b {
+		s.GzipBytes = len(b)
+
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("decompressing tile: %v", err)
+		}
+		if b, err = ioutil.ReadAll(r); err != nil {
+			return nil, fmt.Errorf("decompressing tile: %v", err)
+		}
+	} else {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(b); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		s.GzipBytes = buf.Len()
+	}
+	s.Bytes = len(b)
+
+	var vt vectorTile.Tile
+	if err := proto.Unmarshal(b, &vt); err != nil {
+		return nil, fmt.Errorf("unmarshaling tile: %v", err)
+	}
+
+	for _, vtl := range vt.GetLayers() {
+		s.Layers = append(s.Layers, summarizeLayer(vtl, buffer))
+	}
+
+	return &s, nil
+}
+
+func summarizeLayer(vtl *vectorTile.Tile_Layer, buffer int) LayerSummary {
+	ls := LayerSummary{
+		Name:       vtl.GetName(),
+		Extent:     int(vtl.GetExtent()),
+		Bytes:      proto.Size(vtl),
+		Features:   len(vtl.GetFeatures()),
+		Geometries: map[string]int{},
+		Attributes: map[string]int{},
+	}
+
+	// a layer which doesn't decode is decoded a feature at a time to find the invalid features
+	ls.features = make([]*mvt.Feature, len(vtl.GetFeatures()))
+	if l, err := mvt.DecodeLayer(vtl, nil); err == nil {
+		for i, f := range l.Features() {
+			f := f
+			ls.features[i] = &f
+		}
+	} else {
+		for i, vtf := range vtl.GetFeatures() {
+			single := *vtl
+			single.Features = []*vectorTile.Tile_Feature{vtf}
+
+			l, err := mvt.DecodeLayer(&single, nil)
+			if err != nil {
+				ls.Issues = append(ls.Issues, Issue{Feature: i, ID: vtf.Id, Reason: err.Error()})
+				continue
+			}
+			f := l.Features()[0]
+			ls.features[i] = &f
+		}
+	}
+
+	values := map[string]map[interface{}]struct{}{}
+	for i, f := range ls.features {
+		if f == nil {
+			ls.Geometries["invalid"]++
+			continue
+		}
+
+		ls.Geometries[geometryType(f.Geometry)]++
+		ls.Vertices += vertexCount(f.Geometry)
+
+		for k, v := range f.Tags {
+			if values[k] == nil {
+				values[k] = map[interface{}]struct{}{}
+			}
+			values[k][v] = struct{}{}
+		}
+
+		for _, reason := range geometryIssues(f.Geometry, ls.Extent, buffer) {
+			ls.Issues = append(ls.Issues, Issue{Feature: i, ID: f.ID, Reason: reason})
+		}
+	}
+
+	for k, vals := range values {
+		ls.Attributes[k] = len(vals)
+	}
+
+	return ls
+}
+
+// Keys returns the sorted keys of the counts
+func Keys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func geometryType(g tegola.Geometry) string {
+	switch g.(type) {
+	case tegola.Point:
+		return "point"
+	case tegola.MultiPoint:
+		return "multipoint"
+	case tegola.LineString:
+		return "linestring"
+	case tegola.MultiLine:
+		return "multilinestring"
+	case tegola.Polygon:
+		return "polygon"
+	case tegola.MultiPolygon:
+		return "multipolygon"
+	default:
+		return "unknown"
+	}
+}
+
+// lines returns the point lists of the geometry, and whether they're polygon rings
+func lines(g tegola.Geometry) ([]basic.Line, bool) {
+	switch g := g.(type) {
+	case basic.Point:
+		return []basic.Line{{g}}, false
+	case basic.MultiPoint:
+		return []basic.Line{basic.Line(g)}, false
+	case basic.Line:
+		return []basic.Line{g}, false
+	case basic.MultiLine:
+		return g, false
+	case basic.Polygon:
+		return g, true
+	case basic.MultiPolygon:
+		var rings []basic.Line
+		for _, p := range g {
+			rings = append(rings, p...)
+		}
+		return rings, true
+	default:
+		return nil, false
+	}
+}
+
+func vertexCount(g tegola.Geometry) (n int) {
+	ls, _ := lines(g)
+	for _, l := range ls {
+		n += len(l)
+	}
+	return n
+}
+
+// geometryIssues returns the problems of a geometry in tile pixel coordinates: coordinates outside
+// of the buffered extent, polygons wound the wrong way and self-intersecting rings
+func geometryIssues(g tegola.Geometry, extent, buffer int) (issues []string) {
+	ls, rings := lines(g)
+
+	min, max := float64(-buffer), float64(extent+buffer)
+outside:
+	for _, l := range ls {
+		for _, pt := range l {
+			if pt[0] < min || pt[0] > max || pt[1] < min || pt[1] > max {
+				issues = append(issues, fmt.Sprintf("coordinate (%v %v) is outside of the extent and buffer", pt[0], pt[1]))
+				break outside
+			}
+		}
+	}
+
+	if !rings {
+		return issues
+	}
+
+	var polygons []basic.Polygon
+	switch g := g.(type) {
+	case basic.Polygon:
+		polygons = []basic.Polygon{g}
+	case basic.MultiPolygon:
+		polygons = g
+	}
+	for i, p := range polygons {
+		// the decoder groups rings by the winding of the first one, so only the exteriors need checking.
+		// exterior rings are clockwise in tile coordinates, which is a positive area.
+		if len(p) > 0 && ringArea(p[0]) < 0 {
+			issues = append(issues, fmt.Sprintf("polygon %v exterior ring is wound counter-clockwise", i))
+		}
+	}
+
+	for i, ring := range ls {
+		if selfIntersects(ring) {
+			issues = append(issues, fmt.Sprintf("ring %v self-intersects", i))
+		}
+	}
+
+	return issues
+}
+
+// ringArea returns twice the signed area of the ring
+func ringArea(ring basic.Line) (area float64) {
+	for i := range ring {
+		j := (i + 1) % len(ring)
+		area += ring[i][0]*ring[j][1] - ring[j][0]*ring[i][1]
+	}
+	return area
+}
+
+// selfIntersects reports whether non consecutive segments of the ring intersect
+func selfIntersects(ring basic.Line) bool {
+	// repeated points would make zero length segments touching their neighbours' neighbours
+	var pts []maths.Pt
+	for _, pt := range ring {
+		p := maths.Pt{X: pt[0], Y: pt[1]}
+		if len(pts) > 0 && pts[len(pts)-1].IsEqual(p) {
+			continue
+		}
+		pts = append(pts, p)
+	}
+	if len(pts) > 1 && pts[0].IsEqual(pts[len(pts)-1]) {
+		pts = pts[:len(pts)-1]
+	}
+
+	segments := make([]maths.Line, len(pts))
+	for i := range pts {
+		segments[i] = maths.Line{pts[i], pts[(i+1)%len(pts)]}
+	}
+
+	var intersects bool
+	maths.FindPolygonIntersects(segments, func(src, dest int, ptfn func() maths.Pt) bool {
+		intersects = true
+		return false
+	})
+	return intersects
+}
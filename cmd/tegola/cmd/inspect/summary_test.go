@@ -0,0 +1,169 @@
+package inspect
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/go-spatial/tegola/internal/p"
+	"github.com/go-spatial/tegola/mvt"
+	"github.com/go-spatial/tegola/mvt/vector_tile"
+)
+
+const (
+	moveTo    = 1
+	lineTo    = 2
+	closePath = 7
+)
+
+// cmd encodes a geometry command with the zigzag encoded deltas of its parameters
+func cmd(id uint32, count int, deltas ...int32) []uint32 {
+	c := []uint32{uint32(mvt.NewCommand(id, count))}
+	for _, d := range deltas {
+		c = append(c, uint32((d<<1)^(d>>31)))
+	}
+	return c
+}
+
+func geometry(cmds ...[]uint32) (g []uint32) {
+	for _, c := range cmds {
+		g = append(g, c...)
+	}
+	return g
+}
+
+func TestSummarize(t *testing.T) {
+	type tcase struct {
+		features []*vectorTile.Tile_Feature
+		expected LayerSummary
+	}
+
+	var (
+		point   = vectorTile.Tile_POINT
+		line    = vectorTile.Tile_LINESTRING
+		polygon = vectorTile.Tile_POLYGON
+	)
+
+	fn := func(t *testing.T, tc tcase) {
+		vt := vectorTile.Tile{
+			Layers: []*vectorTile.Tile_Layer{
+				{
+					Version:  p.Uint32(mvt.Version),
+					Name:     p.String("test"),
+					Features: tc.features,
+					Keys:     []string{"name", "rank"},
+					Values: []*vectorTile.Tile_Value{
+						{StringValue: p.String("park")},
+						{StringValue: p.String("river")},
+						{IntValue: p.Int64(3)},
+					},
+					Extent: p.Uint32(mvt.DefaultExtent),
+				},
+			},
+		}
+		b, err := proto.Marshal(&vt)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s, err := Summarize(b, 64)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if s.Bytes != len(b) {
+			t.Errorf("bytes, expected %v got %v", len(b), s.Bytes)
+		}
+		if len(s.Layers) != 1 {
+			t.Fatalf("layers, expected 1 got %v", len(s.Layers))
+		}
+
+		got := s.Layers[0]
+		if got.Bytes != proto.Size(vt.Layers[0]) {
+			t.Errorf("layer bytes, expected %v got %v", proto.Size(vt.Layers[0]), got.Bytes)
+		}
+		got.Bytes, got.features = 0, nil
+
+		tc.expected.Name = "test"
+		tc.expected.Extent = mvt.DefaultExtent
+		tc.expected.Features = len(tc.features)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("summary, expected %+v got %+v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"attributes": {
+			features: []*vectorTile.Tile_Feature{
+				{Tags: []uint32{0, 0, 1, 2}, Type: &point, Geometry: cmd(moveTo, 1, 10, 10)},
+				{Tags: []uint32{0, 1, 1, 2}, Type: &point, Geometry: cmd(moveTo, 2, 10, 10, 5, 5)},
+			},
+			expected: LayerSummary{
+				Vertices:   3,
+				Geometries: map[string]int{"point": 1, "multipoint": 1},
+				Attributes: map[string]int{"name": 2, "rank": 1},
+			},
+		},
+		"valid polygon": {
+			features: []*vectorTile.Tile_Feature{
+				{Type: &polygon, Geometry: geometry(cmd(moveTo, 1, 0, 0), cmd(lineTo, 3, 10, 0, 0, 10, -10, 0), cmd(closePath, 1))},
+			},
+			expected: LayerSummary{
+				Vertices:   4,
+				Geometries: map[string]int{"polygon": 1},
+				Attributes: map[string]int{},
+			},
+		},
+		"wrong winding": {
+			features: []*vectorTile.Tile_Feature{
+				{Id: p.Uint64(7), Type: &polygon, Geometry: geometry(cmd(moveTo, 1, 0, 0), cmd(lineTo, 3, 0, 10, 10, 0, 0, -10), cmd(closePath, 1))},
+			},
+			expected: LayerSummary{
+				Vertices:   4,
+				Geometries: map[string]int{"polygon": 1},
+				Attributes: map[string]int{},
+				Issues:     []Issue{{Feature: 0, ID: p.Uint64(7), Reason: "polygon 0 exterior ring is wound counter-clockwise"}},
+			},
+		},
+		"self intersection": {
+			features: []*vectorTile.Tile_Feature{
+				{Type: &polygon, Geometry: geometry(cmd(moveTo, 1, 0, 0), cmd(lineTo, 3, 10, 10, 0, -10, -10, 20), cmd(closePath, 1))},
+			},
+			expected: LayerSummary{
+				Vertices:   4,
+				Geometries: map[string]int{"polygon": 1},
+				Attributes: map[string]int{},
+				Issues:     []Issue{{Feature: 0, Reason: "ring 0 self-intersects"}},
+			},
+		},
+		"outside of the extent": {
+			features: []*vectorTile.Tile_Feature{
+				{Type: &line, Geometry: geometry(cmd(moveTo, 1, 10, 10), cmd(lineTo, 1, 5000, 0))},
+			},
+			expected: LayerSummary{
+				Vertices:   2,
+				Geometries: map[string]int{"linestring": 1},
+				Attributes: map[string]int{},
+				Issues:     []Issue{{Feature: 0, Reason: "coordinate (5010 10) is outside of the extent and buffer"}},
+			},
+		},
+		"invalid geometry": {
+			features: []*vectorTile.Tile_Feature{
+				{Type: &point, Geometry: cmd(moveTo, 1, 10, 10)},
+				{Type: &line, Geometry: cmd(lineTo, 1, 10, 10)},
+			},
+			expected: LayerSummary{
+				Vertices:   1,
+				Geometries: map[string]int{"point": 1, "invalid": 1},
+				Attributes: map[string]int{},
+				Issues:     []Issue{{Feature: 1, Reason: "mvt: layer (test) feature 0: mvt: invalid LINESTRING geometry: line to before a move to"}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -6,10 +6,14 @@ import (
 	"github.com/go-spatial/cobra"
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/cmd/internal/register"
+	benchcmd "github.com/go-spatial/tegola/cmd/tegola/cmd/bench"
 	cachecmd "github.com/go-spatial/tegola/cmd/tegola/cmd/cache"
+	configcmd "github.com/go-spatial/tegola/cmd/tegola/cmd/config"
+	inspectcmd "github.com/go-spatial/tegola/cmd/tegola/cmd/inspect"
 	"github.com/go-spatial/tegola/config"
 	"github.com/go-spatial/tegola/dict"
 	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/provider"
 )
 
 var (
@@ -18,6 +22,8 @@ var (
 	Version = "version not set"
 	// parsed config
 	conf config.Config
+	// registered providers, keyed by provider name
+	providers map[string]provider.Tiler
 
 	// require cache
 	RequireCache bool
@@ -33,6 +39,12 @@ func init() {
 	// cache seed / purge
 	cachecmd.Config = &conf
 	RootCmd.AddCommand(cachecmd.Cmd)
+	// inspect
+	RootCmd.AddCommand(inspectcmd.Cmd)
+	// bench
+	RootCmd.AddCommand(benchcmd.Cmd)
+	// config init
+	RootCmd.AddCommand(configcmd.Cmd)
 	// version
 	RootCmd.AddCommand(versionCmd)
 
@@ -47,7 +59,7 @@ Version: %v`, Version),
 }
 
 func rootCmdValidatePersistent(cmd *cobra.Command, args []string) (err error) {
-	requireCache := RequireCache || cachecmd.RequireCache
+	requireCache := RequireCache || cachecmd.RequireCache || inspectcmd.RequireCache
 
 	return initConfig(configFile, requireCache)
 }
@@ -68,7 +80,7 @@ func initConfig(configFile string, cacheRequired bool) (err error) {
 		provArr[i] = conf.Providers[i]
 	}
 
-	providers, err := register.Providers(provArr)
+	providers, err = register.Providers(provArr)
 	if err != nil {
 		return fmt.Errorf("could not register providers: %v", err)
 	}
@@ -77,6 +89,9 @@ func initConfig(configFile string, cacheRequired bool) (err error) {
 	if err = register.Maps(nil, conf.Maps, providers); err != nil {
 		return fmt.Errorf("could not register maps: %v", err)
 	}
+	if err = register.CompositeMaps(nil, conf.CompositeMaps); err != nil {
+		return fmt.Errorf("could not register composite maps: %v", err)
+	}
 	if len(conf.Cache) == 0 && cacheRequired {
 		return fmt.Errorf("No cache defined in config, please check your config (%v).", configFile)
 	}
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/go-spatial/cobra"
+	"github.com/go-spatial/tegola/cmd/internal/register"
 	gdcmd "github.com/go-spatial/tegola/internal/cmd"
+	"github.com/go-spatial/tegola/internal/log"
 	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/server"
 )
@@ -38,6 +40,24 @@ var serverCmd = &cobra.Command{
 		// set the http reply headers
 		server.Headers = conf.Webserver.Headers
 
+		// set the providers checked by the readiness endpoint
+		server.Providers = providers
+
+		// setup authentication
+		authenticator, err := register.Auth(conf.Webserver.Auth)
+		if err != nil {
+			log.Fatal(err)
+		}
+		server.Authenticator = authenticator
+
+		// setup per client rate limiting
+		if server.RateLimit, err = register.RateLimit(conf.Webserver); err != nil {
+			log.Fatal(err)
+		}
+
+		// setup the render concurrency limit
+		server.RenderLimit = register.RenderLimit(conf.Webserver)
+
 		// set tile buffer
 		if conf.TileBuffer != nil {
 			server.TileBuffer = float64(*conf.TileBuffer)
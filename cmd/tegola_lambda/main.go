@@ -61,6 +61,9 @@ func main() {
 	if err = register.Maps(nil, conf.Maps, providers); err != nil {
 		log.Fatal(err)
 	}
+	if err = register.CompositeMaps(nil, conf.CompositeMaps); err != nil {
+		log.Fatal(err)
+	}
 
 	// check if a cache backend is provided
 	if len(conf.Cache) != 0 {
@@ -83,6 +86,23 @@ func main() {
 	// set the http reply headers
 	server.Headers = conf.Webserver.Headers
 
+	// set the providers checked by the readiness endpoint
+	server.Providers = providers
+
+	// setup authentication
+	authenticator, err := register.Auth(conf.Webserver.Auth)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server.Authenticator = authenticator
+
+	// setup per client rate limiting and the render concurrency limit. both are kept in
+	// memory, so they apply to each instance of the function rather than across instances
+	if server.RateLimit, err = register.RateLimit(conf.Webserver); err != nil {
+		log.Fatal(err)
+	}
+	server.RenderLimit = register.RenderLimit(conf.Webserver)
+
 	// set tile buffer
 	if conf.TileBuffer != nil {
 		server.TileBuffer = float64(*conf.TileBuffer)
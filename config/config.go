@@ -6,12 +6,12 @@ package config
 import (
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
-	"github.com/BurntSushi/toml"
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/internal/env"
 	"github.com/go-spatial/tegola/internal/log"
@@ -32,12 +32,43 @@ type Config struct {
 	// Map of providers.
 	Providers []env.Dict
 	Maps      []Map
+	// CompositeMaps are aliases for serving the tiles of several maps as a single tile
+	CompositeMaps []CompositeMap `toml:"composite_maps"`
 }
 
 type Webserver struct {
 	HostName env.String `toml:"hostname"`
 	Port     env.String `toml:"port"`
 	Headers  env.Dict   `toml:"headers"`
+	Auth     *Auth      `toml:"auth"`
+	// RateLimit is the number of requests per second allowed per client
+	RateLimit      *env.Float `toml:"rate_limit"`
+	RateLimitBurst *env.Int   `toml:"rate_limit_burst"`
+	// TrustedProxies are the IP addresses or CIDR ranges of proxies whose X-Forwarded-For
+	// header is used for the client IP address of rate limiting
+	TrustedProxies []env.String `toml:"trusted_proxies"`
+	// MaxConcurrentRenders caps the number of tiles rendered at once. Cache hits are not limited.
+	MaxConcurrentRenders *env.Int `toml:"max_concurrent_renders"`
+	// RenderQueueSize is the number of requests which can wait for a render slot
+	RenderQueueSize *env.Int `toml:"render_queue_size"`
+	// RenderQueueTimeout is the max number of seconds a request waits for a render slot
+	RenderQueueTimeout *env.Float `toml:"render_queue_timeout"`
+}
+
+// Auth configures authentication for the webserver. Any combination of
+// API keys, HMAC signed URLs and JWTs can be enabled.
+type Auth struct {
+	// APIKeys maps key ids to static API keys
+	APIKeys      env.Dict   `toml:"api_keys"`
+	APIKeyHeader env.String `toml:"api_key_header"`
+	APIKeyParam  env.String `toml:"api_key_param"`
+	// HMACKeys maps key ids to URL signing secrets
+	HMACKeys env.Dict `toml:"hmac_keys"`
+	// JWTSecret is the shared secret for HMAC signed JWTs
+	JWTSecret env.String `toml:"jwt_secret"`
+	// JWKSFile is the path to a local JSON Web Key Set for RSA or ECDSA signed JWTs
+	JWKSFile env.String `toml:"jwt_jwks_file"`
+	JWTParam env.String `toml:"jwt_param"`
 }
 
 // A Map represents a map in the Tegola Config file.
@@ -47,6 +78,39 @@ type Map struct {
 	Bounds      []env.Float  `toml:"bounds"`
 	Center      [3]env.Float `toml:"center"`
 	Layers      []MapLayer   `toml:"layers"`
+	Auth        *MapAuth     `toml:"auth"`
+	// MaxTileBytes is an optional size budget for the map's encoded tiles
+	MaxTileBytes *env.Int       `toml:"max_tile_bytes"`
+	TileBudget   *MapTileBudget `toml:"tile_budget"`
+	// Overzoom serves tiles above each layer's max_zoom from the layer's tile at max_zoom
+	Overzoom env.Bool `toml:"overzoom"`
+}
+
+// CompositeMap is an alias for serving the tiles of several maps as a single tile
+type CompositeMap struct {
+	Name env.String   `toml:"name"`
+	Maps []env.String `toml:"maps"`
+	// PrefixLayers prefixes the layer names with their map name so maps with the same layer names can be combined
+	PrefixLayers env.Bool `toml:"prefix_layers"`
+}
+
+// MapTileBudget configures how tiles over a map's MaxTileBytes are degraded
+type MapTileBudget struct {
+	// Steps in the order they're applied: simplify, drop_small_polygons,
+	// thin_points, drop_attributes and drop_layers
+	Steps []env.String `toml:"steps"`
+	// DropLayers are low priority layer names, in the order they're dropped
+	DropLayers []env.String `toml:"drop_layers"`
+	// DropAttributes are low priority tag keys, in the order they're dropped
+	DropAttributes []env.String `toml:"drop_attributes"`
+}
+
+// MapAuth lists the clients which may access a map when authentication is enabled.
+type MapAuth struct {
+	// Keys is a list of API key ids, HMAC key ids or JWT subjects
+	Keys []env.String `toml:"keys"`
+	// Claims which a JWT must hold
+	Claims env.Dict `toml:"claims"`
 }
 
 type MapLayer struct {
@@ -60,6 +124,93 @@ type MapLayer struct {
 	// DontSimplify indicates wheather feature simplification should be applied.
 	// We use a negative in the name so the default is to simplify
 	DontSimplify env.Bool `toml:"dont_simplify"`
+	// Simplifier is douglas_peucker (the default) or visvalingam
+	Simplifier env.String `toml:"simplifier"`
+	// SimplifyTolerance is the simplification tolerance in tile pixels
+	SimplifyTolerance *env.Float `toml:"simplify_tolerance"`
+	// SimplifyMaxZoom is the max zoom geometries are simplified at
+	SimplifyMaxZoom *env.Uint `toml:"simplify_max_zoom"`
+	// Attributes is an optional pipeline of transformations applied to the feature tags
+	Attributes *MapLayerAttributes `toml:"attributes"`
+	// Filter is an optional expression features must match to be included in the layer.
+	// i.e. class in ('motorway', 'trunk') or ($zoom >= 10 and class = 'primary')
+	Filter env.String `toml:"filter"`
+	// Cluster optionally groups the layer's points at low zooms
+	Cluster *MapLayerCluster `toml:"cluster"`
+	// LabelPoints optionally adds a companion layer with a label point for each polygon
+	LabelPoints *MapLayerLabelPoints `toml:"label_points"`
+	// MinAreaPx drops polygons with an area smaller than this many square tile pixels
+	MinAreaPx *env.Float `toml:"min_area_px"`
+	// MinLengthPx drops lines shorter than this many tile pixels
+	MinLengthPx *env.Float `toml:"min_length_px"`
+	// MergeSmallPolygons merges the polygons dropped by MinAreaPx into a single feature
+	MergeSmallPolygons env.Bool `toml:"merge_small_polygons"`
+	// Overzoom serves tiles above max_zoom from the layer's tile at max_zoom
+	Overzoom env.Bool `toml:"overzoom"`
+	// Style optionally sets the paint of the layer in the generated style and raster tiles
+	Style *MapLayerStyle `toml:"style"`
+}
+
+// MapLayerStyle configures the paint of a map layer. Colors are hex, rgb or rgba css colors
+type MapLayerStyle struct {
+	FillColor        env.String `toml:"fill_color"`
+	FillOutlineColor env.String `toml:"fill_outline_color"`
+	LineColor        env.String `toml:"line_color"`
+	LineWidth        *env.Float `toml:"line_width"`
+	CircleColor      env.String `toml:"circle_color"`
+	CircleRadius     *env.Float `toml:"circle_radius"`
+}
+
+// MapLayerLabelPoints configures the label point layer of a map layer's polygons
+type MapLayerLabelPoints struct {
+	// Name of the label layer. Defaults to the layer name with a _label suffix
+	Name env.String `toml:"name"`
+	// Mode is alongside (the default) or instead of the polygons
+	Mode env.String `toml:"mode"`
+	// AreaTag is the key of the polygon area tag. Defaults to area
+	AreaTag env.String `toml:"area_tag"`
+}
+
+// MapLayerCluster configures the clustering of a map layer's points
+type MapLayerCluster struct {
+	// points are clustered at zooms up to and including MaxZoom
+	MaxZoom *env.Uint `toml:"max_zoom"`
+	// Radius is the size of the cluster grid cells in tile pixels
+	Radius     *env.Float                 `toml:"radius"`
+	Aggregates []MapLayerClusterAggregate `toml:"aggregates"`
+}
+
+// MapLayerClusterAggregate is an aggregate of a numeric tag of the clustered points
+type MapLayerClusterAggregate struct {
+	Tag env.String `toml:"tag"`
+	// Func is one of sum, min, max or avg
+	Func env.String `toml:"func"`
+	// Name is the key of the aggregate tag. Defaults to func_tag
+	Name env.String `toml:"name"`
+}
+
+// MapLayerAttributes configures the transformation of a map layer's feature tags
+type MapLayerAttributes struct {
+	Include []env.String `toml:"include"`
+	Exclude []env.String `toml:"exclude"`
+	// Rename maps keys to new keys
+	Rename env.Dict `toml:"rename"`
+	// Cast maps keys to a type: string, int, float or bool
+	Cast env.Dict `toml:"cast"`
+	// Map maps keys to tables of replacement values
+	Map map[string]env.Dict `toml:"map"`
+	// Round maps keys to the number of decimal places to round to
+	Round env.Dict `toml:"round"`
+	// Zooms are key filters for zoom ranges
+	Zooms []MapLayerAttributeZoom `toml:"zooms"`
+}
+
+// MapLayerAttributeZoom filters the keys of a map layer's feature tags between MinZoom and MaxZoom
+type MapLayerAttributeZoom struct {
+	MinZoom *env.Uint    `toml:"min_zoom"`
+	MaxZoom *env.Uint    `toml:"max_zoom"`
+	Include []env.String `toml:"include"`
+	Exclude []env.String `toml:"exclude"`
 }
 
 // GetName helper to get the name we care about.
@@ -127,6 +278,19 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// a rate limit of 0 would reject every request
+	if c.Webserver.RateLimit != nil && *c.Webserver.RateLimit <= 0 {
+		return ErrInvalidWebserverValue{Key: "rate_limit", Value: *c.Webserver.RateLimit, Reason: "must be greater than 0"}
+	}
+	if c.Webserver.RateLimitBurst != nil && *c.Webserver.RateLimitBurst < 1 {
+		return ErrInvalidWebserverValue{Key: "rate_limit_burst", Value: *c.Webserver.RateLimitBurst, Reason: "must be at least 1"}
+	}
+	for _, p := range c.Webserver.TrustedProxies {
+		if _, _, err := net.ParseCIDR(string(p)); err != nil && net.ParseIP(string(p)) == nil {
+			return ErrInvalidWebserverValue{Key: "trusted_proxies", Value: p, Reason: "must be an IP address or CIDR range"}
+		}
+	}
+
 	// check for blacklisted headers
 	for k := range c.Webserver.Headers {
 		for _, v := range blacklistHeaders {
@@ -139,10 +303,15 @@ func (c *Config) Validate() error {
 	return nil
 }
 
-// Parse will parse the Tegola config file provided by the io.Reader.
+// Parse will parse the Tegola config file provided by the io.Reader. The format is chosen by
+// the extension of the location: .yaml, .yml and .json, otherwise TOML.
 func Parse(reader io.Reader, location string) (conf Config, err error) {
-	// decode conf file, don't care about the meta data.
-	_, err = toml.DecodeReader(reader, &conf)
+	return ParseAs(reader, location, FormatFromLocation(location))
+}
+
+// ParseAs will parse the Tegola config file provided by the io.Reader in the format.
+func ParseAs(reader io.Reader, location string, format Format) (conf Config, err error) {
+	err = parse(reader, format, &conf)
 	conf.LocationName = location
 
 	return conf, err
@@ -167,6 +336,13 @@ func Load(location string) (conf Config, err error) {
 			return conf, fmt.Errorf("error fetching remote config file (%v): %v ", location, err)
 		}
 
+		defer res.Body.Close()
+
+		// the Content-Type takes precedence over the extension
+		if format, ok := FormatFromContentType(res.Header.Get("Content-Type")); ok {
+			return ParseAs(res.Body, location, format)
+		}
+
 		// set the reader to the response body
 		reader = res.Body
 	} else {
@@ -711,6 +711,43 @@ func TestValidate(t *testing.T) {
 				Header: "Content-Encoding",
 			},
 		},
+		"7 zero rate limit": {
+			config: config.Config{
+				Webserver: config.Webserver{
+					RateLimit: env.FloatPtr(0),
+				},
+			},
+			expectedErr: config.ErrInvalidWebserverValue{
+				Key:    "rate_limit",
+				Value:  env.Float(0),
+				Reason: "must be greater than 0",
+			},
+		},
+		"8 zero rate limit burst": {
+			config: config.Config{
+				Webserver: config.Webserver{
+					RateLimit:      env.FloatPtr(0.5),
+					RateLimitBurst: env.IntPtr(0),
+				},
+			},
+			expectedErr: config.ErrInvalidWebserverValue{
+				Key:    "rate_limit_burst",
+				Value:  env.Int(0),
+				Reason: "must be at least 1",
+			},
+		},
+		"9 invalid trusted proxy": {
+			config: config.Config{
+				Webserver: config.Webserver{
+					TrustedProxies: []env.String{"10.0.0.0/8", "lb.internal"},
+				},
+			},
+			expectedErr: config.ErrInvalidWebserverValue{
+				Key:    "trusted_proxies",
+				Value:  env.String("lb.internal"),
+				Reason: "must be an IP address or CIDR range",
+			},
+		},
 	}
 
 	for name, tc := range tests {
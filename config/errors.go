@@ -53,6 +53,16 @@ func (e ErrMissingEnvVar) Error() string {
 	return fmt.Sprintf("config: config file is referencing an environment variable that is not set (%v)", e.EnvVar)
 }
 
+type ErrInvalidWebserverValue struct {
+	Key    string
+	Value  interface{}
+	Reason string
+}
+
+func (e ErrInvalidWebserverValue) Error() string {
+	return fmt.Sprintf("config: webserver %v (%v) %v", e.Key, e.Value, e.Reason)
+}
+
 type ErrInvalidHeader struct {
 	Header string
 }
@@ -60,3 +70,11 @@ type ErrInvalidHeader struct {
 func (e ErrInvalidHeader) Error() string {
 	return fmt.Sprintf("config: header (%v) blacklisted", e.Header)
 }
+
+type ErrUnknownFormat struct {
+	Format string
+}
+
+func (e ErrUnknownFormat) Error() string {
+	return fmt.Sprintf("config: unknown format (%v). expected toml, yaml or json", e.Format)
+}
@@ -0,0 +1,333 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// Format is the encoding of a config file
+type Format string
+
+const (
+	FormatTOML Format = "toml"
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat returns the format of a name such as "yaml" or "yml"
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "toml":
+		return FormatTOML, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return "", ErrUnknownFormat{Format: name}
+	}
+}
+
+// FormatFromLocation returns the format of the config file or URL by its extension.
+// Locations without a known extension are TOML.
+func FormatFromLocation(location string) Format {
+	p := location
+	if u, err := url.Parse(location); err == nil && u.Scheme != "" && u.Host != "" {
+		p = u.Path
+	}
+
+	format, err := ParseFormat(path.Ext(p))
+	if err != nil {
+		return FormatTOML
+	}
+	return format
+}
+
+// FormatFromContentType returns the format of a Content-Type header value. ok is false
+// if the media type is not a config format, i.e. text/plain.
+func FormatFromContentType(contentType string) (format Format, ok bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+
+	switch mediaType {
+	case "application/toml", "application/x-toml", "text/toml", "text/x-toml":
+		return FormatTOML, true
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return FormatYAML, true
+	case "application/json", "text/json":
+		return FormatJSON, true
+	default:
+		return "", false
+	}
+}
+
+// Decode decodes a config file of the format into a document of plain values: maps, slices,
+// strings, numbers and bools. Environment variables are not replaced.
+func Decode(reader io.Reader, format Format) (doc map[string]interface{}, err error) {
+	switch format {
+	case FormatTOML:
+		_, err = toml.DecodeReader(reader, &doc)
+		return doc, err
+
+	case FormatYAML:
+		b, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		if err = yaml.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return document(v)
+
+	case FormatJSON:
+		dec := json.NewDecoder(reader)
+		// keep integers apart from floats
+		dec.UseNumber()
+		var v interface{}
+		if err = dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		return document(v)
+
+	default:
+		return nil, ErrUnknownFormat{Format: string(format)}
+	}
+}
+
+// Encode writes the document in the format. The sections of the config are written in the
+// order of the tegola docs, the keys within them are sorted.
+func Encode(w io.Writer, doc map[string]interface{}, format Format) error {
+	switch format {
+	case FormatTOML:
+		return encodeTOML(w, doc)
+
+	case FormatYAML:
+		ordered := yaml.MapSlice{}
+		for _, k := range orderedKeys(doc) {
+			ordered = append(ordered, yaml.MapItem{Key: k, Value: doc[k]})
+		}
+		b, err := yaml.Marshal(ordered)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+
+	case FormatJSON:
+		// encoding/json sorts the keys of maps
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+
+	default:
+		return ErrUnknownFormat{Format: string(format)}
+	}
+}
+
+// the order the sections of a config are written in
+var sections = []string{"tile_buffer", "webserver", "cache", "providers", "maps", "composite_maps"}
+
+// orderedKeys returns the keys of the document with the sections first
+func orderedKeys(doc map[string]interface{}) []string {
+	keys := make([]string, 0, len(doc))
+	seen := map[string]bool{}
+	for _, k := range sections {
+		if _, ok := doc[k]; ok {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+
+	var rest []string
+	for k := range doc {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
+
+// encodeTOML writes the document's plain keys, which have to come before any table in TOML,
+// followed by its tables in order
+func encodeTOML(w io.Writer, doc map[string]interface{}) error {
+	plain := map[string]interface{}{}
+	var tables []string
+	for _, k := range orderedKeys(doc) {
+		if isTable(doc[k]) {
+			tables = append(tables, k)
+		} else {
+			plain[k] = doc[k]
+		}
+	}
+
+	encode := func(v map[string]interface{}) error {
+		enc := toml.NewEncoder(w)
+		enc.Indent = "  "
+		return enc.Encode(v)
+	}
+
+	if len(plain) > 0 {
+		if err := encode(plain); err != nil {
+			return err
+		}
+	}
+	for i, k := range tables {
+		if i > 0 || len(plain) > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if err := encode(map[string]interface{}{k: doc[k]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isTable reports if the value is encoded as a TOML table or array of tables
+func isTable(v interface{}) bool {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return true
+	case []map[string]interface{}:
+		return len(val) > 0
+	case []interface{}:
+		if len(val) == 0 {
+			return false
+		}
+		for _, e := range val {
+			if _, ok := e.(map[string]interface{}); !ok {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// document converts a decoded YAML or JSON value into a config document
+func document(v interface{}) (map[string]interface{}, error) {
+	v, err := plainValue(v)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	doc, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config: expected a map at the top level, got %T", v)
+	}
+	return doc, nil
+}
+
+// plainValue converts YAML maps to string keyed maps and JSON numbers to int64 or float64.
+// Arrays mixing integers and floats are converted to floats as TOML arrays have a single type.
+func plainValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			pv, err := plainValue(e)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(k)] = pv
+		}
+		return m, nil
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			pv, err := plainValue(e)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = pv
+		}
+		return m, nil
+
+	case []interface{}:
+		s := make([]interface{}, len(val))
+		var ints, floats bool
+		for i, e := range val {
+			pv, err := plainValue(e)
+			if err != nil {
+				return nil, err
+			}
+			switch pv.(type) {
+			case int, int64, uint64:
+				ints = true
+			case float64:
+				floats = true
+			}
+			s[i] = pv
+		}
+		if ints && floats {
+			for i := range s {
+				switch n := s[i].(type) {
+				case int:
+					s[i] = float64(n)
+				case int64:
+					s[i] = float64(n)
+				case uint64:
+					s[i] = float64(n)
+				}
+			}
+		}
+		return s, nil
+
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i, nil
+		}
+		return val.Float64()
+
+	default:
+		return v, nil
+	}
+}
+
+// parse decodes the config in the format into conf. YAML and JSON are converted to TOML so
+// they are decoded like a TOML config, including the env types.
+func parse(reader io.Reader, format Format, conf *Config) error {
+	switch format {
+	case FormatTOML:
+		_, err := toml.DecodeReader(reader, conf)
+		return err
+
+	case FormatYAML, FormatJSON:
+		doc, err := Decode(reader, format)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err = encodeTOML(&buf, doc); err != nil {
+			return fmt.Errorf("config: converting %v: %v", format, err)
+		}
+		_, err = toml.DecodeReader(&buf, conf)
+		return err
+
+	default:
+		return ErrUnknownFormat{Format: string(format)}
+	}
+}
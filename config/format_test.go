@@ -0,0 +1,318 @@
+package config_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-spatial/tegola/config"
+)
+
+const formatTOML = `
+tile_buffer = 12
+
+[webserver]
+port = "${ENV_TEST_FORMAT_PORT}"
+
+  [webserver.headers]
+  Access-Control-Allow-Origin = "*"
+
+[cache]
+type = "file"
+basepath = "/tmp/tegola-cache"
+
+[[providers]]
+name = "provider1"
+type = "postgis"
+host = "localhost"
+port = 5432
+password = "${ENV_TEST_FORMAT_PASSWORD}"
+
+  [[providers.layers]]
+  name = "water"
+  geometry_fieldname = "geom"
+  fields = ["name", "class"]
+  sql = "SELECT gid, geom FROM river WHERE geom && !BBOX!"
+
+[[maps]]
+name = "osm"
+center = [-76.275329586789, 39.153492567373, 8.0]
+
+  [[maps.layers]]
+  provider_layer = "provider1.water"
+  min_zoom = 10
+  max_zoom = 20
+`
+
+const formatYAML = `
+tile_buffer: 12
+webserver:
+  port: ${ENV_TEST_FORMAT_PORT}
+  headers:
+    Access-Control-Allow-Origin: "*"
+cache:
+  type: file
+  basepath: /tmp/tegola-cache
+providers:
+  - name: provider1
+    type: postgis
+    host: localhost
+    port: 5432
+    password: ${ENV_TEST_FORMAT_PASSWORD}
+    layers:
+      - name: water
+        geometry_fieldname: geom
+        fields: [name, class]
+        sql: SELECT gid, geom FROM river WHERE geom && !BBOX!
+maps:
+  - name: osm
+    center: [-76.275329586789, 39.153492567373, 8]
+    layers:
+      - provider_layer: provider1.water
+        min_zoom: 10
+        max_zoom: 20
+`
+
+const formatJSON = `{
+  "tile_buffer": 12,
+  "webserver": {
+    "port": "${ENV_TEST_FORMAT_PORT}",
+    "headers": {"Access-Control-Allow-Origin": "*"}
+  },
+  "cache": {"type": "file", "basepath": "/tmp/tegola-cache"},
+  "providers": [{
+    "name": "provider1",
+    "type": "postgis",
+    "host": "localhost",
+    "port": 5432,
+    "password": "${ENV_TEST_FORMAT_PASSWORD}",
+    "layers": [{
+      "name": "water",
+      "geometry_fieldname": "geom",
+      "fields": ["name", "class"],
+      "sql": "SELECT gid, geom FROM river WHERE geom && !BBOX!"
+    }]
+  }],
+  "maps": [{
+    "name": "osm",
+    "center": [-76.275329586789, 39.153492567373, 8],
+    "layers": [{"provider_layer": "provider1.water", "min_zoom": 10, "max_zoom": 20}]
+  }]
+}`
+
+func setFormatEnv() func() {
+	os.Setenv("ENV_TEST_FORMAT_PORT", ":9090")
+	os.Setenv("ENV_TEST_FORMAT_PASSWORD", "secret")
+	return func() {
+		os.Unsetenv("ENV_TEST_FORMAT_PORT")
+		os.Unsetenv("ENV_TEST_FORMAT_PASSWORD")
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	type tcase struct {
+		config   string
+		location string
+	}
+
+	defer setFormatEnv()()
+
+	expected, err := config.Parse(strings.NewReader(formatTOML), "")
+	if err != nil {
+		t.Fatalf("unexpected error parsing toml: %v", err)
+	}
+	if err = expected.Validate(); err != nil {
+		t.Fatalf("unexpected error validating toml: %v", err)
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		conf, err := config.Parse(strings.NewReader(tc.config), tc.location)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if conf.LocationName != tc.location {
+			t.Errorf("expected LocationName %v, got %v", tc.location, conf.LocationName)
+		}
+
+		conf.LocationName = ""
+		if !reflect.DeepEqual(conf, expected) {
+			t.Errorf("expected \n\n (%+v) \n\n got \n\n (%+v) ", expected, conf)
+		}
+	}
+
+	tests := map[string]tcase{
+		"toml": {
+			config:   formatTOML,
+			location: "config.toml",
+		},
+		"yaml": {
+			config:   formatYAML,
+			location: "config.yaml",
+		},
+		"yml": {
+			config:   formatYAML,
+			location: "/etc/tegola/config.yml",
+		},
+		"json": {
+			config:   formatJSON,
+			location: "https://example.com/tegola.json?version=2",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestLoadContentType(t *testing.T) {
+	type tcase struct {
+		contentType string
+		path        string
+		body        string
+	}
+
+	defer setFormatEnv()()
+
+	fn := func(t *testing.T, tc tcase) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", tc.contentType)
+			w.Write([]byte(tc.body))
+		}))
+		defer srv.Close()
+
+		conf, err := config.Load(srv.URL + tc.path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(conf.Maps) != 1 || conf.Maps[0].Name != "osm" {
+			t.Errorf("expected the osm map, got %+v", conf.Maps)
+		}
+	}
+
+	tests := map[string]tcase{
+		"yaml content type": {
+			contentType: "application/x-yaml",
+			path:        "/config",
+			body:        formatYAML,
+		},
+		"json content type": {
+			contentType: "application/json; charset=utf-8",
+			path:        "/config.toml",
+			body:        formatJSON,
+		},
+		"extension": {
+			contentType: "text/plain",
+			path:        "/config.yaml",
+			body:        formatYAML,
+		},
+		"toml default": {
+			contentType: "text/plain",
+			path:        "/config",
+			body:        formatTOML,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestConvert(t *testing.T) {
+	type tcase struct {
+		from, to config.Format
+	}
+
+	sources := map[config.Format]string{
+		config.FormatTOML: formatTOML,
+		config.FormatYAML: formatYAML,
+		config.FormatJSON: formatJSON,
+	}
+
+	defer setFormatEnv()()
+
+	expected, err := config.Parse(strings.NewReader(formatTOML), "")
+	if err != nil {
+		t.Fatalf("unexpected error parsing toml: %v", err)
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		doc, err := config.Decode(strings.NewReader(sources[tc.from]), tc.from)
+		if err != nil {
+			t.Fatalf("unexpected error decoding: %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err = config.Encode(&buf, doc, tc.to); err != nil {
+			t.Fatalf("unexpected error encoding: %v", err)
+		}
+		// environment variables are kept for the deployment to set
+		if !strings.Contains(buf.String(), "${ENV_TEST_FORMAT_PASSWORD}") {
+			t.Errorf("expected the environment variable to be kept, got\n%v", buf.String())
+		}
+
+		conf, err := config.ParseAs(&buf, "", tc.to)
+		if err != nil {
+			t.Fatalf("unexpected error parsing the converted config: %v", err)
+		}
+		if !reflect.DeepEqual(conf, expected) {
+			t.Errorf("expected \n\n (%+v) \n\n got \n\n (%+v) ", expected, conf)
+		}
+	}
+
+	tests := map[string]tcase{
+		"toml to yaml": {from: config.FormatTOML, to: config.FormatYAML},
+		"toml to json": {from: config.FormatTOML, to: config.FormatJSON},
+		"yaml to toml": {from: config.FormatYAML, to: config.FormatTOML},
+		"yaml to json": {from: config.FormatYAML, to: config.FormatJSON},
+		"json to toml": {from: config.FormatJSON, to: config.FormatTOML},
+		"json to yaml": {from: config.FormatJSON, to: config.FormatYAML},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	type tcase struct {
+		name     string
+		expected config.Format
+		err      bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		format, err := config.ParseFormat(tc.name)
+		if tc.err {
+			if _, ok := err.(config.ErrUnknownFormat); !ok {
+				t.Errorf("expected ErrUnknownFormat, got %v", err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if format != tc.expected {
+			t.Errorf("expected %v, got %v", tc.expected, format)
+		}
+	}
+
+	tests := map[string]tcase{
+		"toml":      {name: "toml", expected: config.FormatTOML},
+		"yml":       {name: "yml", expected: config.FormatYAML},
+		"extension": {name: ".YAML", expected: config.FormatYAML},
+		"json":      {name: "json", expected: config.FormatJSON},
+		"unknown":   {name: "ini", err: true},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -0,0 +1,254 @@
+// Package raster is a small anti-aliased 2D rasterizer for drawing tile features into images.
+// Paths are filled with the non-zero winding rule so polygon holes, wound opposite to their
+// exterior, are left empty and overlapping strokes are drawn once.
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// subSamples is the number of scanlines sampled per pixel row for anti-aliasing
+const subSamples = 4
+
+// circleSegments is the number of segments of the largest circles
+const circleSegments = 32
+
+// Point is a point in pixel coordinates, with y pointing down
+type Point [2]float64
+
+// Canvas is an image features are drawn on
+type Canvas struct {
+	img *image.RGBA
+	// coverage of the pixels of a row, reused between rows
+	cover []float64
+}
+
+// NewCanvas returns a transparent canvas of width by height pixels
+func NewCanvas(width, height int) *Canvas {
+	return &Canvas{
+		img:   image.NewRGBA(image.Rect(0, 0, width, height)),
+		cover: make([]float64, width),
+	}
+}
+
+// Image returns the image drawn on the canvas
+func (c *Canvas) Image() *image.RGBA {
+	return c.img
+}
+
+// FillPath fills the area enclosed by the rings with the color
+func (c *Canvas) FillPath(rings [][]Point, col color.NRGBA) {
+	if col.A == 0 {
+		return
+	}
+
+	type edge struct {
+		x0, y0, x1, y1 float64
+		dir            int
+	}
+
+	var (
+		edges      []edge
+		minY, maxY = math.Inf(1), math.Inf(-1)
+	)
+	for _, ring := range rings {
+		for i := range ring {
+			a, b := ring[i], ring[(i+1)%len(ring)]
+			if a[1] == b[1] {
+				// horizontal edges don't cross scanlines
+				continue
+			}
+			e := edge{x0: a[0], y0: a[1], x1: b[0], y1: b[1], dir: 1}
+			if e.y0 > e.y1 {
+				e.x0, e.y0, e.x1, e.y1, e.dir = e.x1, e.y1, e.x0, e.y0, -1
+			}
+			edges = append(edges, e)
+			minY, maxY = math.Min(minY, e.y0), math.Max(maxY, e.y1)
+		}
+	}
+	if len(edges) == 0 {
+		return
+	}
+
+	bounds := c.img.Bounds()
+	rowMin := int(math.Max(math.Floor(minY), 0))
+	rowMax := int(math.Min(math.Ceil(maxY), float64(bounds.Dy())))
+
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	var crossings []crossing
+
+	for row := rowMin; row < rowMax; row++ {
+		covered := false
+		for s := 0; s < subSamples; s++ {
+			y := float64(row) + (float64(s)+0.5)/subSamples
+
+			crossings = crossings[:0]
+			for _, e := range edges {
+				if y < e.y0 || y >= e.y1 {
+					continue
+				}
+				x := e.x0 + (y-e.y0)*(e.x1-e.x0)/(e.y1-e.y0)
+				crossings = append(crossings, crossing{x: x, dir: e.dir})
+			}
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			var (
+				winding int
+				start   float64
+			)
+			for _, cr := range crossings {
+				if winding == 0 {
+					start = cr.x
+				}
+				winding += cr.dir
+				if winding == 0 {
+					c.addSpan(start, cr.x, 1.0/subSamples)
+					covered = true
+				}
+			}
+		}
+
+		if covered {
+			c.blendRow(row, col)
+		}
+	}
+}
+
+// addSpan adds the coverage of the horizontal span from x0 to x1 to the row's coverage
+func (c *Canvas) addSpan(x0, x1, weight float64) {
+	width := float64(len(c.cover))
+	x0, x1 = math.Max(x0, 0), math.Min(x1, width)
+	if x1 <= x0 {
+		return
+	}
+
+	i0, i1 := int(x0), int(x1)
+	if i0 == i1 {
+		c.cover[i0] += (x1 - x0) * weight
+		return
+	}
+
+	c.cover[i0] += (float64(i0+1) - x0) * weight
+	for i := i0 + 1; i < i1; i++ {
+		c.cover[i] += weight
+	}
+	if i1 < len(c.cover) {
+		c.cover[i1] += (x1 - float64(i1)) * weight
+	}
+}
+
+// blendRow draws the color over the row using its coverage, and resets the coverage
+func (c *Canvas) blendRow(row int, col color.NRGBA) {
+	for x, cover := range c.cover {
+		if cover <= 0 {
+			continue
+		}
+		c.cover[x] = 0
+
+		a := math.Min(cover, 1) * float64(col.A) / 255
+		i := c.img.PixOffset(x, row)
+		pix := c.img.Pix[i : i+4 : i+4]
+		// the image is alpha premultiplied
+		pix[0] = uint8(float64(col.R)*a + float64(pix[0])*(1-a) + 0.5)
+		pix[1] = uint8(float64(col.G)*a + float64(pix[1])*(1-a) + 0.5)
+		pix[2] = uint8(float64(col.B)*a + float64(pix[2])*(1-a) + 0.5)
+		pix[3] = uint8(255*a + float64(pix[3])*(1-a) + 0.5)
+	}
+}
+
+// StrokeLine draws the line with the width and color. Segments are joined with round joins.
+func (c *Canvas) StrokeLine(line []Point, width float64, col color.NRGBA) {
+	c.FillPath(strokeRings(line, width, false), col)
+}
+
+// StrokeRing draws the outline of a closed ring with the width and color
+func (c *Canvas) StrokeRing(ring []Point, width float64, col color.NRGBA) {
+	c.FillPath(strokeRings(ring, width, true), col)
+}
+
+// FillCircle draws a filled circle centered on the point
+func (c *Canvas) FillCircle(center Point, radius float64, col color.NRGBA) {
+	c.FillPath([][]Point{circle(center, radius)}, col)
+}
+
+// strokeRings returns the rings of the area covered by the stroke of the line. The rings are
+// wound the same way so the non-zero fill joins them without overlaps.
+func strokeRings(line []Point, width float64, closed bool) [][]Point {
+	if len(line) == 0 || width <= 0 {
+		return nil
+	}
+
+	half := width / 2
+	var rings [][]Point
+
+	segments := len(line) - 1
+	if closed {
+		segments = len(line)
+	}
+
+	for i := 0; i < segments; i++ {
+		a, b := line[i], line[(i+1)%len(line)]
+		dx, dy := b[0]-a[0], b[1]-a[1]
+		length := math.Hypot(dx, dy)
+		if length == 0 {
+			continue
+		}
+		// the normal of the segment scaled to half the width
+		nx, ny := -dy/length*half, dx/length*half
+		rings = append(rings, orient([]Point{
+			{a[0] + nx, a[1] + ny},
+			{b[0] + nx, b[1] + ny},
+			{b[0] - nx, b[1] - ny},
+			{a[0] - nx, a[1] - ny},
+		}))
+	}
+
+	// round joins at the inner points, and all the points of a closed ring
+	start, end := 1, len(line)-1
+	if closed {
+		start, end = 0, len(line)
+	}
+	for i := start; i < end; i++ {
+		rings = append(rings, circle(line[i], half))
+	}
+
+	// a line of a single point is drawn as a dot
+	if len(rings) == 0 {
+		rings = append(rings, circle(line[0], half))
+	}
+
+	return rings
+}
+
+// circle returns a ring approximating the circle, wound like the rings returned by orient
+func circle(center Point, radius float64) []Point {
+	// small circles need fewer segments
+	n := int(math.Min(circleSegments, math.Max(8, radius*4)))
+	ring := make([]Point, n)
+	for i := range ring {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		ring[i] = Point{center[0] + radius*math.Cos(angle), center[1] + radius*math.Sin(angle)}
+	}
+	return orient(ring)
+}
+
+// orient winds the ring so it has a positive signed area
+func orient(ring []Point) []Point {
+	var area float64
+	for i := range ring {
+		j := (i + 1) % len(ring)
+		area += ring[i][0]*ring[j][1] - ring[j][0]*ring[i][1]
+	}
+	if area < 0 {
+		for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+			ring[i], ring[j] = ring[j], ring[i]
+		}
+	}
+	return ring
+}
@@ -0,0 +1,84 @@
+package raster_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/go-spatial/tegola/draw/raster"
+)
+
+func TestCanvas(t *testing.T) {
+	type tcase struct {
+		draw func(c *raster.Canvas)
+		// the expected alpha of pixels
+		expected map[[2]int]uint8
+	}
+
+	red := color.NRGBA{R: 255, A: 255}
+	square := func(x0, y0, x1, y1 float64) []raster.Point {
+		return []raster.Point{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}}
+	}
+	reverse := func(ring []raster.Point) []raster.Point {
+		for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+			ring[i], ring[j] = ring[j], ring[i]
+		}
+		return ring
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		c := raster.NewCanvas(16, 16)
+		tc.draw(c)
+
+		for px, alpha := range tc.expected {
+			got := c.Image().RGBAAt(px[0], px[1]).A
+			// allow for rounding
+			if diff := int(got) - int(alpha); diff < -1 || diff > 1 {
+				t.Errorf("pixel %v alpha, expected %v got %v", px, alpha, got)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"fill": {
+			draw: func(c *raster.Canvas) {
+				c.FillPath([][]raster.Point{square(2, 2, 10, 10)}, red)
+			},
+			expected: map[[2]int]uint8{{2, 2}: 255, {9, 9}: 255, {1, 5}: 0, {10, 5}: 0},
+		},
+		"anti aliased edge": {
+			draw: func(c *raster.Canvas) {
+				c.FillPath([][]raster.Point{square(2.5, 2, 10, 10)}, red)
+			},
+			expected: map[[2]int]uint8{{2, 5}: 128, {3, 5}: 255},
+		},
+		"hole": {
+			draw: func(c *raster.Canvas) {
+				c.FillPath([][]raster.Point{square(2, 2, 14, 14), reverse(square(6, 6, 10, 10))}, red)
+			},
+			expected: map[[2]int]uint8{{3, 3}: 255, {7, 7}: 0, {12, 12}: 255},
+		},
+		"translucent": {
+			draw: func(c *raster.Canvas) {
+				c.FillPath([][]raster.Point{square(0, 0, 16, 16)}, color.NRGBA{R: 255, A: 128})
+			},
+			expected: map[[2]int]uint8{{8, 8}: 128},
+		},
+		"line joins not overlapping": {
+			draw: func(c *raster.Canvas) {
+				c.StrokeLine([]raster.Point{{2, 8}, {8, 8}, {8, 14}}, 2, color.NRGBA{R: 255, A: 128})
+			},
+			expected: map[[2]int]uint8{{4, 7}: 128, {4, 8}: 128, {8, 8}: 128, {8, 12}: 128, {4, 4}: 0},
+		},
+		"circle": {
+			draw: func(c *raster.Canvas) {
+				c.FillCircle(raster.Point{8, 8}, 4, red)
+			},
+			expected: map[[2]int]uint8{{8, 8}: 255, {2, 2}: 0, {14, 8}: 0},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -234,7 +234,8 @@ func (canvas *Canvas) Init(writer io.Writer, w, h int, grid bool) *Canvas {
 	}
 	canvas.SVG = svg.New(writer)
 
-	canvas.Startview(w, h, int(canvas.Board.MinX-20), int(canvas.Board.MinY-20), int(canvas.Board.MaxX+20), int(canvas.Board.MaxY+20))
+	// the view box is the board with a margin of 20
+	canvas.Startview(w, h, int(canvas.Board.MinX-20), int(canvas.Board.MinY-20), int(canvas.Board.Width()+40), int(canvas.Board.Height()+40))
 	if grid {
 		canvas.GroupFn([]string{
 			`id="grid"`,
@@ -373,6 +373,13 @@ func TestDict(t *testing.T) {
 			key:      "float",
 			expected: 1.0,
 		},
+		"float from int": {
+			dict: env.Dict{
+				"float": int64(2),
+			},
+			key:      "float",
+			expected: 2.0,
+		},
 		"float env not set": {
 			dict: env.Dict{
 				"float": "${TEST_FLOAT}",
@@ -202,6 +202,13 @@ func ParseFloat(v interface{}) (*float64, error) {
 	case float32:
 		f := float64(val)
 		return &f, nil
+	// JSON and YAML configs don't distinguish integral floats from integers
+	case int:
+		f := float64(val)
+		return &f, nil
+	case int64:
+		f := float64(val)
+		return &f, nil
 	case string:
 		val, err := replaceEnvVar(val)
 		if err != nil {
@@ -0,0 +1,122 @@
+// Package timing records the time spent in the stages of encoding a tile, per layer. A Recorder is
+// carried by the context of the encoding, and the stages are only timed when there is one.
+package timing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Stage is a stage of encoding a layer
+type Stage string
+
+const (
+	// Fetch is the time spent in the provider, not including the time spent handling its features
+	Fetch Stage = "fetch"
+	// Convert is converting and reprojecting the provider's geometries
+	Convert Stage = "convert"
+	// Simplify is simplifying the geometries
+	Simplify Stage = "simplify"
+	// Clean is clipping and validating the geometries with validate.CleanGeometry
+	Clean Stage = "clean"
+	// Encode is scaling the geometries and encoding the layer into protobuf
+	Encode Stage = "encode"
+)
+
+// Stages are the stages in the order they happen
+var Stages = []Stage{Fetch, Convert, Simplify, Clean, Encode}
+
+// TileLayer is the layer name the stages of the whole tile, such as marshaling it, are recorded for
+const TileLayer = ""
+
+// Recorder sums the time spent in each stage by layer name. It's safe for concurrent use.
+type Recorder struct {
+	mu        sync.Mutex
+	durations map[string]map[Stage]time.Duration
+}
+
+// NewRecorder returns an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		durations: map[string]map[Stage]time.Duration{},
+	}
+}
+
+// Durations returns a copy of the time spent in each stage by layer name
+func (r *Recorder) Durations() map[string]map[Stage]time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	durations := make(map[string]map[Stage]time.Duration, len(r.durations))
+	for layer, stages := range r.durations {
+		durations[layer] = make(map[Stage]time.Duration, len(stages))
+		for stage, d := range stages {
+			durations[layer][stage] = d
+		}
+	}
+	return durations
+}
+
+func (r *Recorder) add(layer string, stage Stage, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.durations[layer] == nil {
+		r.durations[layer] = map[Stage]time.Duration{}
+	}
+	r.durations[layer][stage] += d
+}
+
+type contextKey int
+
+const (
+	recorderKey contextKey = iota
+	layerKey
+)
+
+// NewContext returns a context which records the stages of encoding into r
+func NewContext(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey, r)
+}
+
+// FromContext returns the Recorder of the context, or nil if the context doesn't record
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey).(*Recorder)
+	return r
+}
+
+// WithLayer returns a context which records the stages for the layer. The context is returned
+// unchanged if it doesn't record.
+func WithLayer(ctx context.Context, layer string) context.Context {
+	if FromContext(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, layerKey, layer)
+}
+
+// Start returns the start time of a stage, or the zero time if the context doesn't record
+func Start(ctx context.Context) time.Time {
+	if FromContext(ctx) == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// Stop records the time since start for the stage of the context's layer
+func Stop(ctx context.Context, stage Stage, start time.Time) {
+	if start.IsZero() {
+		return
+	}
+	Add(ctx, stage, time.Since(start))
+}
+
+// Add records d for the stage of the context's layer
+func Add(ctx context.Context, stage Stage, d time.Duration) {
+	r := FromContext(ctx)
+	if r == nil {
+		return
+	}
+	layer, _ := ctx.Value(layerKey).(string)
+	r.add(layer, stage, d)
+}
@@ -0,0 +1,35 @@
+package timing_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/internal/timing"
+)
+
+func TestRecorder(t *testing.T) {
+	// contexts without a recorder aren't timed
+	ctx := timing.WithLayer(context.Background(), "roads")
+	if start := timing.Start(ctx); !start.IsZero() {
+		t.Errorf("start, expected the zero time got %v", start)
+	}
+	timing.Add(ctx, timing.Fetch, time.Second)
+
+	rec := timing.NewRecorder()
+	ctx = timing.NewContext(context.Background(), rec)
+	roads := timing.WithLayer(ctx, "roads")
+
+	timing.Add(roads, timing.Fetch, 2*time.Second)
+	timing.Add(roads, timing.Fetch, time.Second)
+	timing.Add(ctx, timing.Encode, time.Second)
+
+	expected := map[string]map[timing.Stage]time.Duration{
+		"roads":          {timing.Fetch: 3 * time.Second},
+		timing.TileLayer: {timing.Encode: time.Second},
+	}
+	if got := rec.Durations(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("durations, expected %v got %v", expected, got)
+	}
+}
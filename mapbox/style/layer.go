@@ -20,12 +20,13 @@ type Layer struct {
 }
 
 type LayerPaint struct {
-	LineColor        string `json:"line-color,omitempty"`
-	FillColor        string `json:"fill-color,omitempty"`
-	FillOutlineColor string `json:"fill-outline-color,omitempty"`
-	FillOpacity      uint8  `json:"fill-opacity,omitempty"`
-	CircleRadius     uint8  `json:"circle-radius,omitempty"`
-	CircleColor      string `json:"circle-color,omitempty"`
+	LineColor        string  `json:"line-color,omitempty"`
+	LineWidth        float64 `json:"line-width,omitempty"`
+	FillColor        string  `json:"fill-color,omitempty"`
+	FillOutlineColor string  `json:"fill-outline-color,omitempty"`
+	FillOpacity      uint8   `json:"fill-opacity,omitempty"`
+	CircleRadius     uint8   `json:"circle-radius,omitempty"`
+	CircleColor      string  `json:"circle-color,omitempty"`
 }
 
 const (
@@ -0,0 +1,209 @@
+// Package polylabel finds the pole of inaccessibility of a polygon: the point inside the polygon
+// furthest from its edges. It's a good anchor for a label as, unlike the centroid, it's always
+// inside the polygon. The algorithm is a port of https://github.com/mapbox/polylabel
+package polylabel
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/maths"
+	"github.com/go-spatial/tegola/maths/hitmap"
+)
+
+// Polygon returns the pole of inaccessibility of the polygon to within precision, and its
+// distance to the polygon's closest edge. ok is false if the polygon is empty.
+func Polygon(p tegola.Polygon, precision float64) (pt maths.Pt, dist float64, ok bool) {
+	rings := polygonRings(p)
+	if len(rings) == 0 || len(rings[0]) == 0 {
+		return pt, 0, false
+	}
+
+	// bounding box of the outer ring
+	minX, minY := rings[0][0].X, rings[0][0].Y
+	maxX, maxY := minX, minY
+	for _, pt := range rings[0][1:] {
+		minX, minY = math.Min(minX, pt.X), math.Min(minY, pt.Y)
+		maxX, maxY = math.Max(maxX, pt.X), math.Max(maxY, pt.Y)
+	}
+
+	width, height := maxX-minX, maxY-minY
+	cellSize := math.Min(width, height)
+	if cellSize == 0 {
+		return maths.Pt{X: minX, Y: minY}, 0, true
+	}
+
+	hm := hitmap.NewFromPolygon(p)
+	newCell := func(x, y, h float64) *cell {
+		c := cell{x: x, y: y, h: h, d: signedDistance(&hm, rings, maths.Pt{X: x, Y: y})}
+		c.max = c.d + c.h*math.Sqrt2
+		return &c
+	}
+
+	// cover the polygon with the initial cells
+	var queue cellQueue
+	h := cellSize / 2
+	for x := minX; x < maxX; x += cellSize {
+		for y := minY; y < maxY; y += cellSize {
+			heap.Push(&queue, newCell(x+h, y+h, h))
+		}
+	}
+
+	// start with the centroid, falling back to the center of the bounding box
+	cx, cy := centroid(rings[0])
+	best := newCell(cx, cy, 0)
+	if bbox := newCell(minX+width/2, minY+height/2, 0); bbox.d > best.d {
+		best = bbox
+	}
+
+	for queue.Len() > 0 {
+		c := heap.Pop(&queue).(*cell)
+
+		if c.d > best.d {
+			best = c
+		}
+
+		// the cell can't contain a better point
+		if c.max-best.d <= precision {
+			continue
+		}
+
+		h := c.h / 2
+		heap.Push(&queue, newCell(c.x-h, c.y-h, h))
+		heap.Push(&queue, newCell(c.x+h, c.y-h, h))
+		heap.Push(&queue, newCell(c.x-h, c.y+h, h))
+		heap.Push(&queue, newCell(c.x+h, c.y+h, h))
+	}
+
+	return maths.Pt{X: best.x, Y: best.y}, best.d, true
+}
+
+// MultiPolygon returns the pole of inaccessibility of the largest polygon of the multipolygon
+func MultiPolygon(mp tegola.MultiPolygon, precision float64) (pt maths.Pt, dist float64, ok bool) {
+	var (
+		largest tegola.Polygon
+		maxArea = -1.0
+	)
+
+	for _, p := range mp.Polygons() {
+		if area := Area(p); area > maxArea {
+			largest, maxArea = p, area
+		}
+	}
+
+	if largest == nil {
+		return pt, 0, false
+	}
+
+	return Polygon(largest, precision)
+}
+
+// Area returns the area of the polygon, less the area of its holes
+func Area(p tegola.Polygon) float64 {
+	sublines := p.Sublines()
+	if len(sublines) == 0 {
+		return 0
+	}
+
+	area := maths.AreaOfPolygonLineString(sublines[0])
+	for _, hole := range sublines[1:] {
+		area -= maths.AreaOfPolygonLineString(hole)
+	}
+
+	return math.Max(area, 0)
+}
+
+// cell is a square of the search grid
+type cell struct {
+	// center of the cell
+	x, y float64
+	// half the cell size
+	h float64
+	// distance from the cell center to the polygon, negative when outside
+	d float64
+	// the max distance to the polygon within the cell
+	max float64
+}
+
+// cellQueue is a max heap of cells ordered by their max distance
+type cellQueue []*cell
+
+func (q cellQueue) Len() int            { return len(q) }
+func (q cellQueue) Less(i, j int) bool  { return q[i].max > q[j].max }
+func (q cellQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *cellQueue) Push(x interface{}) { *q = append(*q, x.(*cell)) }
+func (q *cellQueue) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+func polygonRings(p tegola.Polygon) [][]maths.Pt {
+	var rings [][]maths.Pt
+	for _, l := range p.Sublines() {
+		pts := l.Subpoints()
+		ring := make([]maths.Pt, len(pts))
+		for i := range pts {
+			ring[i] = maths.Pt{X: pts[i].X(), Y: pts[i].Y()}
+		}
+		rings = append(rings, ring)
+	}
+	return rings
+}
+
+// signedDistance returns the distance from pt to the closest ring edge. the distance is negative
+// when the point is outside the polygon
+func signedDistance(hm hitmap.Interface, rings [][]maths.Pt, pt maths.Pt) float64 {
+	min := math.Inf(1)
+	for _, ring := range rings {
+		for i := range ring {
+			j := (i + 1) % len(ring)
+			if d := segmentDistance(pt, ring[i], ring[j]); d < min {
+				min = d
+			}
+		}
+	}
+
+	if hm.LabelFor(pt) != maths.Inside {
+		return -min
+	}
+	return min
+}
+
+// segmentDistance returns the distance from pt to the segment a b
+func segmentDistance(pt, a, b maths.Pt) float64 {
+	x, y := a.X, a.Y
+	dx, dy := b.X-x, b.Y-y
+
+	if dx != 0 || dy != 0 {
+		t := ((pt.X-x)*dx + (pt.Y-y)*dy) / (dx*dx + dy*dy)
+		switch {
+		case t > 1:
+			x, y = b.X, b.Y
+		case t > 0:
+			x += dx * t
+			y += dy * t
+		}
+	}
+
+	return math.Hypot(pt.X-x, pt.Y-y)
+}
+
+// centroid returns the centroid of the ring, or its first point if the ring has no area
+func centroid(ring []maths.Pt) (float64, float64) {
+	var area, x, y float64
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%len(ring)]
+		f := a.X*b.Y - b.X*a.Y
+		x += (a.X + b.X) * f
+		y += (a.Y + b.Y) * f
+		area += f * 3
+	}
+
+	if area == 0 {
+		return ring[0].X, ring[0].Y
+	}
+	return x / area, y / area
+}
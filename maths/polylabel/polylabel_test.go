@@ -0,0 +1,72 @@
+package polylabel_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/maths"
+	"github.com/go-spatial/tegola/maths/polylabel"
+)
+
+func TestPolygon(t *testing.T) {
+	type tcase struct {
+		polygon  basic.Polygon
+		expected maths.Pt
+		dist     float64
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		pt, dist, ok := polylabel.Polygon(tc.polygon, 0.01)
+		if !ok {
+			t.Fatalf("expected a label point")
+		}
+
+		if math.Abs(pt.X-tc.expected.X) > 0.1 || math.Abs(pt.Y-tc.expected.Y) > 0.1 {
+			t.Errorf("point, expected %v got %v", tc.expected, pt)
+		}
+		if math.Abs(dist-tc.dist) > 0.1 {
+			t.Errorf("distance, expected %v got %v", tc.dist, dist)
+		}
+	}
+
+	tests := map[string]tcase{
+		"square": {
+			polygon:  basic.Polygon{basic.Line{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+			expected: maths.Pt{X: 5, Y: 5},
+			dist:     5,
+		},
+		// the centroid of a U shape is outside of the polygon
+		"u shape": {
+			polygon: basic.Polygon{basic.Line{
+				{0, 0}, {30, 0}, {30, 30}, {20, 30}, {20, 10}, {10, 10}, {10, 30}, {0, 30},
+			}},
+			expected: maths.Pt{X: 5.86, Y: 5.86},
+			dist:     5.86,
+		},
+		"square with hole": {
+			polygon: basic.Polygon{
+				basic.Line{{0, 0}, {40, 0}, {40, 20}, {0, 20}},
+				basic.Line{{20, 5}, {30, 5}, {30, 15}, {20, 15}},
+			},
+			expected: maths.Pt{X: 10, Y: 10},
+			dist:     10,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestArea(t *testing.T) {
+	p := basic.Polygon{
+		basic.Line{{0, 0}, {40, 0}, {40, 20}, {0, 20}},
+		basic.Line{{10, 5}, {30, 5}, {30, 15}, {10, 15}},
+	}
+
+	if area := polylabel.Area(p); area != 600 {
+		t.Errorf("area, expected 600 got %v", area)
+	}
+}
@@ -0,0 +1,89 @@
+package maths
+
+import (
+	"container/heap"
+	"math"
+)
+
+// https://en.wikipedia.org/wiki/Visvalingam%E2%80%93Whyatt_algorithm
+
+// Visvalingam simplifies a line by repeatedly removing the point forming the smallest
+// triangle (its effective area) with its neighbors, until every remaining point has an
+// effective area of at least minArea. The end points are always kept.
+func Visvalingam(points []Pt, minArea float64) []Pt {
+	if minArea <= 0 || len(points) <= 2 {
+		return points
+	}
+
+	// a doubly linked list of the points, so removed points can be skipped
+	vs := make([]vwPoint, len(points))
+	var queue vwQueue
+	for i := range points {
+		vs[i] = vwPoint{prev: i - 1, next: i + 1}
+		if i == 0 || i == len(points)-1 {
+			continue
+		}
+		vs[i].area = math.Abs(AreaOfTriangle(points[i-1], points[i], points[i+1]))
+		heap.Push(&queue, &vs[i])
+	}
+
+	// the effective area of the last removed point. a point's area can't be less than this,
+	// otherwise points would be removed in the wrong order
+	var maxArea float64
+	for queue.Len() > 0 {
+		v := heap.Pop(&queue).(*vwPoint)
+		if v.area >= minArea {
+			break
+		}
+		maxArea = math.Max(maxArea, v.area)
+
+		// unlink the point and recompute the area of its neighbors
+		vs[v.prev].next = v.next
+		vs[v.next].prev = v.prev
+
+		for _, n := range []int{v.prev, v.next} {
+			nv := &vs[n]
+			if n == 0 || n == len(points)-1 {
+				continue
+			}
+			nv.area = math.Max(math.Abs(AreaOfTriangle(points[nv.prev], points[n], points[nv.next])), maxArea)
+			heap.Fix(&queue, nv.heapIdx)
+		}
+	}
+
+	simplified := make([]Pt, 0, len(points))
+	for i := 0; i < len(points); i = vs[i].next {
+		simplified = append(simplified, points[i])
+	}
+	return simplified
+}
+
+type vwPoint struct {
+	// the index of the previous and next points which have not been removed
+	prev, next int
+	// effective area
+	area float64
+	// position in the queue
+	heapIdx int
+}
+
+// vwQueue is a min heap of points ordered by their effective area
+type vwQueue []*vwPoint
+
+func (q vwQueue) Len() int           { return len(q) }
+func (q vwQueue) Less(i, j int) bool { return q[i].area < q[j].area }
+func (q vwQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].heapIdx, q[j].heapIdx = i, j
+}
+func (q *vwQueue) Push(x interface{}) {
+	v := x.(*vwPoint)
+	v.heapIdx = len(*q)
+	*q = append(*q, v)
+}
+func (q *vwQueue) Pop() interface{} {
+	old := *q
+	v := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return v
+}
@@ -0,0 +1,54 @@
+package maths
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVisvalingam(t *testing.T) {
+	type tcase struct {
+		line     []Pt
+		minArea  float64
+		expected []Pt
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		got := Visvalingam(tc.line, tc.minArea)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("expected %v got %v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"no tolerance": {
+			line:     []Pt{{0, 0}, {1, 0.1}, {2, 0}},
+			expected: []Pt{{0, 0}, {1, 0.1}, {2, 0}},
+		},
+		"two points": {
+			line:     []Pt{{0, 0}, {2, 0}},
+			minArea:  1,
+			expected: []Pt{{0, 0}, {2, 0}},
+		},
+		"remove small triangle": {
+			// the triangle at {1, 0.1} has an area of 0.1
+			line:     []Pt{{0, 0}, {1, 0.1}, {2, 0}, {3, 5}, {4, 0}},
+			minArea:  1,
+			expected: []Pt{{0, 0}, {2, 0}, {3, 5}, {4, 0}},
+		},
+		"collapse to end points": {
+			line:     []Pt{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 0.1}, {4, 0}},
+			minArea:  1,
+			expected: []Pt{{0, 0}, {4, 0}},
+		},
+		"keep large triangles": {
+			line:     []Pt{{0, 0}, {1, 4}, {2, 0}, {3, 4}, {4, 0}},
+			minArea:  1,
+			expected: []Pt{{0, 0}, {1, 4}, {2, 0}, {3, 4}, {4, 0}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -0,0 +1,286 @@
+package mvt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/mvt/vector_tile"
+)
+
+// ErrInvalidGeometry is returned when a feature's geometry commands can't be decoded
+type ErrInvalidGeometry struct {
+	Type   vectorTile.Tile_GeomType
+	Reason string
+}
+
+func (e ErrInvalidGeometry) Error() string {
+	return fmt.Sprintf("mvt: invalid %v geometry: %v", e.Type, e.Reason)
+}
+
+// Decode decodes an encoded vector tile. The tile may be gzipped.
+// See DecodeTile for how the geometries are decoded.
+func Decode(b []byte, tile *tegola.Tile) (*Tile, error) {
+	// gzip magic number
+	if len(b) > 2 && b[0] == 0x1f && b[1] == 0x8b {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("mvt: decompressing tile: %v", err)
+		}
+		if b, err = ioutil.ReadAll(r); err != nil {
+			return nil, fmt.Errorf("mvt: decompressing tile: %v", err)
+		}
+	}
+
+	var vt vectorTile.Tile
+	if err := proto.Unmarshal(b, &vt); err != nil {
+		return nil, fmt.Errorf("mvt: unmarshaling tile: %v", err)
+	}
+
+	return DecodeTile(&vt, tile)
+}
+
+// DecodeTile decodes the layers of a vector tile. If tile is nil the geometries are
+// in tile pixel coordinates, otherwise they're projected into webmercator for the tile.
+func DecodeTile(vt *vectorTile.Tile, tile *tegola.Tile) (*Tile, error) {
+	var t Tile
+	for _, vtl := range vt.GetLayers() {
+		l, err := DecodeLayer(vtl, tile)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.AddLayers(l); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
+
+// DecodeLayer decodes the features of a vector tile layer, resolving their tags. If tile is nil
+// the geometries are in tile pixel coordinates, otherwise they're projected into webmercator for the tile.
+func DecodeLayer(vtl *vectorTile.Tile_Layer, tile *tegola.Tile) (*Layer, error) {
+	extent := int(vtl.GetExtent())
+	l := Layer{
+		Name:   vtl.GetName(),
+		extent: &extent,
+	}
+
+	values := make([]interface{}, len(vtl.GetValues()))
+	for i, v := range vtl.GetValues() {
+		values[i] = decodeValue(v)
+	}
+
+	pt := pixelPoint
+	if tile != nil {
+		// a copy of the tile with the layer's extent so pixels are converted at the layer's resolution
+		t := *tile
+		t.Extent = float64(extent)
+		pt = func(x, y int64) basic.Point {
+			// FromPixel only errors for unsupported srids
+			wm, _ := t.FromPixel(tegola.WebMercator, [2]float64{float64(x), float64(y)})
+			return basic.Point{wm[0], wm[1]}
+		}
+	}
+
+	keys := vtl.GetKeys()
+	for i, vtf := range vtl.GetFeatures() {
+		tags := vtf.GetTags()
+		if len(tags)%2 != 0 {
+			return nil, fmt.Errorf("mvt: layer (%v) feature %v has an odd number of tags", l.Name, i)
+		}
+
+		f := Feature{
+			Tags: make(map[string]interface{}, len(tags)/2),
+		}
+		if vtf.Id != nil {
+			id := *vtf.Id
+			f.ID = &id
+		}
+
+		for j := 0; j < len(tags); j += 2 {
+			k, v := int(tags[j]), int(tags[j+1])
+			if k >= len(keys) || v >= len(values) {
+				return nil, fmt.Errorf("mvt: layer (%v) feature %v has a tag index out of range", l.Name, i)
+			}
+			if values[v] == nil {
+				continue
+			}
+			f.Tags[keys[k]] = values[v]
+		}
+
+		geo, err := decodeGeometry(vtf.GetType(), vtf.GetGeometry(), pt)
+		if err != nil {
+			return nil, fmt.Errorf("mvt: layer (%v) feature %v: %v", l.Name, i, err)
+		}
+		f.Geometry = geo
+
+		l.features = append(l.features, f)
+	}
+
+	return &l, nil
+}
+
+// decodeValue returns the value of a tag, or nil if the value is not set
+func decodeValue(v *vectorTile.Tile_Value) interface{} {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.FloatValue != nil:
+		return *v.FloatValue
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.IntValue != nil:
+		return *v.IntValue
+	case v.UintValue != nil:
+		return *v.UintValue
+	case v.SintValue != nil:
+		return *v.SintValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	default:
+		return nil
+	}
+}
+
+// decodeZigZag reverses encodeZigZag
+func decodeZigZag(i uint32) int64 {
+	return int64(i>>1) ^ -int64(i&1)
+}
+
+func pixelPoint(x, y int64) basic.Point {
+	return basic.Point{float64(x), float64(y)}
+}
+
+// decodeGeometry decodes the geometry commands of a feature. pt converts the pixel coordinates.
+// Polygon rings are split into polygons by their winding order: rings with the same winding
+// as the first ring start a new polygon, the others are holes of the current polygon.
+func decodeGeometry(gtype vectorTile.Tile_GeomType, geometry []uint32, pt func(x, y int64) basic.Point) (tegola.Geometry, error) {
+	var (
+		x, y  int64
+		lines [][][2]int64
+	)
+
+	invalid := func(format string, args ...interface{}) error {
+		return ErrInvalidGeometry{Type: gtype, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	for i := 0; i < len(geometry); {
+		cmd := Command(geometry[i])
+		i++
+
+		switch cmd.ID() {
+		case cmdMoveTo, cmdLineTo:
+			if cmd.Count() == 0 {
+				return nil, invalid("command with a count of 0")
+			}
+			if i+2*cmd.Count() > len(geometry) {
+				return nil, invalid("%v is missing parameters", cmd)
+			}
+			if cmd.ID() == cmdLineTo && len(lines) == 0 {
+				return nil, invalid("line to before a move to")
+			}
+
+			for j := 0; j < cmd.Count(); j++ {
+				x += decodeZigZag(geometry[i])
+				y += decodeZigZag(geometry[i+1])
+				i += 2
+
+				// every move to point starts a new line, except for points which are collected together
+				if cmd.ID() == cmdMoveTo && (gtype != vectorTile.Tile_POINT || len(lines) == 0) {
+					lines = append(lines, nil)
+				}
+				lines[len(lines)-1] = append(lines[len(lines)-1], [2]int64{x, y})
+			}
+
+		case cmdClosePath:
+			if gtype != vectorTile.Tile_POLYGON {
+				return nil, invalid("close path in a non polygon geometry")
+			}
+
+		default:
+			return nil, invalid("%v", cmd)
+		}
+	}
+
+	points := func(line [][2]int64) basic.Line {
+		l := make(basic.Line, len(line))
+		for i := range line {
+			l[i] = pt(line[i][0], line[i][1])
+		}
+		return l
+	}
+
+	switch gtype {
+	case vectorTile.Tile_POINT:
+		if len(lines) == 0 {
+			return nil, invalid("no points")
+		}
+		if len(lines[0]) == 1 {
+			return pt(lines[0][0][0], lines[0][0][1]), nil
+		}
+		return basic.MultiPoint(points(lines[0])), nil
+
+	case vectorTile.Tile_LINESTRING:
+		if len(lines) == 0 {
+			return nil, invalid("no lines")
+		}
+		var ml basic.MultiLine
+		for _, line := range lines {
+			if len(line) < 2 {
+				return nil, invalid("line with %v points", len(line))
+			}
+			ml = append(ml, points(line))
+		}
+		if len(ml) == 1 {
+			return ml[0], nil
+		}
+		return ml, nil
+
+	case vectorTile.Tile_POLYGON:
+		var (
+			mp       basic.MultiPolygon
+			exterior float64
+		)
+		for _, ring := range lines {
+			area := ringArea(ring)
+			if area == 0 {
+				// degenerate rings have no winding order
+				continue
+			}
+			if exterior == 0 {
+				exterior = area
+			}
+			if (area > 0) == (exterior > 0) {
+				mp = append(mp, basic.Polygon{})
+			}
+			mp[len(mp)-1] = append(mp[len(mp)-1], points(ring))
+		}
+		switch len(mp) {
+		case 0:
+			return nil, invalid("no rings")
+		case 1:
+			return mp[0], nil
+		default:
+			return mp, nil
+		}
+
+	default:
+		return nil, ErrUnknownGeometryType
+	}
+}
+
+// ringArea returns twice the signed area of the ring. In tile coordinates (y pointing down)
+// exterior rings are clockwise, which is a positive area.
+func ringArea(ring [][2]int64) float64 {
+	var area int64
+	for i := range ring {
+		j := (i + 1) % len(ring)
+		area += ring[i][0]*ring[j][1] - ring[j][0]*ring[i][1]
+	}
+	return float64(area)
+}
@@ -0,0 +1,252 @@
+package mvt_test
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/internal/p"
+	"github.com/go-spatial/tegola/mvt"
+	"github.com/go-spatial/tegola/mvt/vector_tile"
+)
+
+// zigzag encodes the deltas of a geometry command's parameters
+func zigzag(deltas ...int32) (params []uint32) {
+	for _, d := range deltas {
+		params = append(params, uint32((d<<1)^(d>>31)))
+	}
+	return params
+}
+
+func geometryCmds(cmds ...[]uint32) (g []uint32) {
+	for _, c := range cmds {
+		g = append(g, c...)
+	}
+	return g
+}
+
+func cmd(id uint32, count int, deltas ...int32) []uint32 {
+	return append([]uint32{uint32(mvt.NewCommand(id, count))}, zigzag(deltas...)...)
+}
+
+const (
+	moveTo    = 1
+	lineTo    = 2
+	closePath = 7
+)
+
+func TestDecodeLayer(t *testing.T) {
+	type tcase struct {
+		gtype    vectorTile.Tile_GeomType
+		geometry []uint32
+		expected tegola.Geometry
+		err      bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		layer := newTileLayer("test",
+			[]string{"name", "rank"},
+			[]*vectorTile.Tile_Value{
+				{StringValue: p.String("park")},
+				{IntValue: p.Int64(3)},
+			},
+			[]*vectorTile.Tile_Feature{
+				{
+					Id:       p.Uint64(7),
+					Tags:     []uint32{0, 0, 1, 1},
+					Type:     &tc.gtype,
+					Geometry: tc.geometry,
+				},
+			},
+		)
+
+		l, err := mvt.DecodeLayer(layer, nil)
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected an error, got nil")
+			}
+			return
+		}
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			return
+		}
+
+		if l.Name != "test" {
+			t.Errorf("name, expected test got %v", l.Name)
+		}
+
+		features := l.Features()
+		if len(features) != 1 {
+			t.Errorf("features, expected 1 got %v", len(features))
+			return
+		}
+
+		f := features[0]
+		if f.ID == nil || *f.ID != 7 {
+			t.Errorf("id, expected 7 got %v", f.ID)
+		}
+		expectedTags := map[string]interface{}{"name": "park", "rank": int64(3)}
+		if !reflect.DeepEqual(f.Tags, expectedTags) {
+			t.Errorf("tags, expected %v got %v", expectedTags, f.Tags)
+		}
+		if !reflect.DeepEqual(f.Geometry, tc.expected) {
+			t.Errorf("geometry, expected %#v got %#v", tc.expected, f.Geometry)
+		}
+	}
+
+	tests := map[string]tcase{
+		"point": {
+			gtype:    vectorTile.Tile_POINT,
+			geometry: cmd(moveTo, 1, 25, 17),
+			expected: basic.Point{25, 17},
+		},
+		"multi point": {
+			gtype:    vectorTile.Tile_POINT,
+			geometry: cmd(moveTo, 2, 5, 7, -2, -5),
+			expected: basic.MultiPoint{{5, 7}, {3, 2}},
+		},
+		"line": {
+			gtype:    vectorTile.Tile_LINESTRING,
+			geometry: geometryCmds(cmd(moveTo, 1, 2, 2), cmd(lineTo, 2, 0, 8, 8, 0)),
+			expected: basic.Line{{2, 2}, {2, 10}, {10, 10}},
+		},
+		"multi line": {
+			gtype: vectorTile.Tile_LINESTRING,
+			geometry: geometryCmds(
+				cmd(moveTo, 1, 2, 2), cmd(lineTo, 1, 0, 8),
+				cmd(moveTo, 1, 1, 1), cmd(lineTo, 1, 4, 0),
+			),
+			expected: basic.MultiLine{
+				{{2, 2}, {2, 10}},
+				{{3, 11}, {7, 11}},
+			},
+		},
+		"polygon": {
+			gtype:    vectorTile.Tile_POLYGON,
+			geometry: geometryCmds(cmd(moveTo, 1, 0, 0), cmd(lineTo, 3, 10, 0, 0, 10, -10, 0), cmd(closePath, 1)),
+			expected: basic.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+		},
+		"multi polygon with hole": {
+			gtype: vectorTile.Tile_POLYGON,
+			geometry: geometryCmds(
+				// exterior, clockwise
+				cmd(moveTo, 1, 0, 0), cmd(lineTo, 3, 10, 0, 0, 10, -10, 0), cmd(closePath, 1),
+				// hole, counter clockwise
+				cmd(moveTo, 1, 2, -8), cmd(lineTo, 3, 0, 6, 6, 0, 0, -6), cmd(closePath, 1),
+				// second exterior
+				cmd(moveTo, 1, 12, 18), cmd(lineTo, 3, 10, 0, 0, 10, -10, 0), cmd(closePath, 1),
+			),
+			expected: basic.MultiPolygon{
+				{
+					{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+					{{2, 2}, {2, 8}, {8, 8}, {8, 2}},
+				},
+				{
+					{{20, 20}, {30, 20}, {30, 30}, {20, 30}},
+				},
+			},
+		},
+		"line to before move to": {
+			gtype:    vectorTile.Tile_LINESTRING,
+			geometry: cmd(lineTo, 1, 2, 2),
+			err:      true,
+		},
+		"missing parameters": {
+			gtype:    vectorTile.Tile_LINESTRING,
+			geometry: geometryCmds(cmd(moveTo, 1, 2, 2), cmd(lineTo, 2, 0, 8)),
+			err:      true,
+		},
+		"unknown command": {
+			gtype:    vectorTile.Tile_POINT,
+			geometry: cmd(5, 1, 2, 2),
+			err:      true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	tile := tegola.NewTile(2, 1, 1)
+
+	// webmercator coordinates of tile pixels
+	pt := func(x, y float64) basic.Point {
+		wm, err := tile.FromPixel(tegola.WebMercator, [2]float64{x, y})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return basic.Point{wm[0], wm[1]}
+	}
+
+	features := []mvt.Feature{
+		{
+			ID:       p.Uint64(1),
+			Tags:     map[string]interface{}{"name": "a", "height": 1.5},
+			Geometry: pt(100, 200),
+		},
+		{
+			ID:       p.Uint64(2),
+			Tags:     map[string]interface{}{"name": "b", "open": true},
+			Geometry: basic.Polygon{{pt(100, 100), pt(1000, 100), pt(1000, 1000), pt(100, 1000)}},
+		},
+	}
+
+	layer := mvt.Layer{Name: "test", DontSimplify: true}
+	layer.AddFeatures(features...)
+
+	vtl, err := layer.VTileLayer(context.Background(), tile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	decoded, err := mvt.DecodeLayer(vtl, tile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := decoded.Features()
+	if len(got) != len(features) {
+		t.Fatalf("features, expected %v got %v", len(features), len(got))
+	}
+
+	// coordinates are expected within a pixel
+	tolerance := tile.ZRes()
+	near := func(a, b tegola.Point) bool {
+		return math.Abs(a.X()-b.X()) <= tolerance && math.Abs(a.Y()-b.Y()) <= tolerance
+	}
+
+	for i := range features {
+		if *got[i].ID != *features[i].ID {
+			t.Errorf("feature %v id, expected %v got %v", i, *features[i].ID, *got[i].ID)
+		}
+		if !reflect.DeepEqual(got[i].Tags, features[i].Tags) {
+			t.Errorf("feature %v tags, expected %v got %v", i, features[i].Tags, got[i].Tags)
+		}
+	}
+
+	if gpt, ok := got[0].Geometry.(basic.Point); !ok || !near(gpt, features[0].Geometry.(basic.Point)) {
+		t.Errorf("point, expected %v got %v", features[0].Geometry, got[0].Geometry)
+	}
+
+	poly, ok := got[1].Geometry.(basic.Polygon)
+	if !ok || len(poly) != 1 || len(poly[0]) != 4 {
+		t.Fatalf("polygon, expected a single ring of 4 points got %#v", got[1].Geometry)
+	}
+	// the encoder may start the ring at a different point
+	for _, ept := range features[1].Geometry.(basic.Polygon)[0] {
+		var found bool
+		for _, gpt := range poly[0] {
+			found = found || near(ept, gpt)
+		}
+		if !found {
+			t.Errorf("polygon, expected point %v in %v", ept, poly[0])
+		}
+	}
+}
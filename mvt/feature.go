@@ -12,6 +12,7 @@ import (
 	"github.com/go-spatial/tegola/basic"
 	"github.com/go-spatial/tegola/internal/convert"
 	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/internal/timing"
 	"github.com/go-spatial/tegola/maths"
 	"github.com/go-spatial/tegola/maths/points"
 	"github.com/go-spatial/tegola/maths/validate"
@@ -88,6 +89,12 @@ func NewFeatures(geo tegola.Geometry, tags map[string]interface{}) (f []Feature)
 
 // VTileFeature will return a vectorTile.Feature that would represent the Feature
 func (f *Feature) VTileFeature(ctx context.Context, keys []string, vals []interface{}, tile *tegola.Tile, simplify bool) (tf *vectorTile.Tile_Feature, err error) {
+	return f.vtileFeature(ctx, keys, vals, tile, simplify, nil, tile.ZEpislon())
+}
+
+// vtileFeature encodes the feature, simplifying its geometry with fn to within tolerance pixels.
+// A nil fn uses the default Douglas-Peucker simplification.
+func (f *Feature) vtileFeature(ctx context.Context, keys []string, vals []interface{}, tile *tegola.Tile, simplify bool, fn Simplifier, tolerance float64) (tf *vectorTile.Tile_Feature, err error) {
 	tf = new(vectorTile.Tile_Feature)
 	tf.Id = f.ID
 
@@ -95,7 +102,7 @@ func (f *Feature) VTileFeature(ctx context.Context, keys []string, vals []interf
 		return tf, err
 	}
 
-	geo, gtype, err := encodeGeometry(ctx, f.Geometry, tile, simplify)
+	geo, gtype, err := encodeGeometry(ctx, f.Geometry, tile, simplify, fn, tolerance)
 	if err != nil {
 		return tf, err
 	}
@@ -271,13 +278,17 @@ Restart:
 	return newline
 }
 
-func simplifyLineString(g tegola.LineString, tolerance float64) basic.Line {
+func simplifyLineString(g tegola.LineString, tolerance float64, fn Simplifier) basic.Line {
 	line := basic.CloneLine(g)
 	if len(line) <= 4 || maths.DistOfLine(g) < tolerance {
 		return line
 	}
 	pts := line.AsPts()
-	pts = maths.DouglasPeucker(pts, tolerance, true)
+	if fn == nil {
+		pts = maths.DouglasPeucker(pts, tolerance, true)
+	} else {
+		pts = fn(pts, tolerance)
+	}
 	if len(pts) == 0 {
 		return nil
 	}
@@ -307,7 +318,7 @@ func normalizePoints(pts []maths.Pt) (pnts []maths.Pt) {
 	return pnts
 }
 
-func simplifyPolygon(g tegola.Polygon, tolerance float64, simplify bool) basic.Polygon {
+func simplifyPolygon(g tegola.Polygon, tolerance float64, simplify bool, fn Simplifier) basic.Polygon {
 
 	lines := g.Sublines()
 	if len(lines) <= 0 {
@@ -347,7 +358,11 @@ func simplifyPolygon(g tegola.Polygon, tolerance float64, simplify bool) basic.P
 			continue
 		}
 
-		pts = maths.DouglasPeucker(pts, sqTolerance, simplify)
+		if fn == nil {
+			pts = maths.DouglasPeucker(pts, sqTolerance, simplify)
+		} else {
+			pts = fn(pts, tolerance)
+		}
 		if len(pts) <= 2 {
 			if i == 0 {
 				return nil
@@ -367,16 +382,22 @@ func simplifyPolygon(g tegola.Polygon, tolerance float64, simplify bool) basic.P
 }
 
 func SimplifyGeometry(g tegola.Geometry, tolerance float64, simplify bool) tegola.Geometry {
+	return simplifyGeometry(g, tolerance, simplify, nil)
+}
+
+// simplifyGeometry simplifies the lines and polygons of g with fn. A nil fn uses the
+// default Douglas-Peucker simplification.
+func simplifyGeometry(g tegola.Geometry, tolerance float64, simplify bool, fn Simplifier) tegola.Geometry {
 	if !simplify || g == nil {
 		return g
 	}
 	switch gg := g.(type) {
 	case tegola.Polygon:
-		return simplifyPolygon(gg, tolerance, simplify)
+		return simplifyPolygon(gg, tolerance, simplify, fn)
 	case tegola.MultiPolygon:
 		var newMP basic.MultiPolygon
 		for _, p := range gg.Polygons() {
-			sp := simplifyPolygon(p, tolerance, simplify)
+			sp := simplifyPolygon(p, tolerance, simplify, fn)
 			if sp == nil {
 				continue
 			}
@@ -387,11 +408,11 @@ func SimplifyGeometry(g tegola.Geometry, tolerance float64, simplify bool) tegol
 		}
 		return newMP
 	case tegola.LineString:
-		return simplifyLineString(gg, tolerance)
+		return simplifyLineString(gg, tolerance, fn)
 	case tegola.MultiLine:
 		var newML basic.MultiLine
 		for _, l := range gg.Lines() {
-			sl := simplifyLineString(l, tolerance)
+			sl := simplifyLineString(l, tolerance, fn)
 			if sl == nil {
 				continue
 			}
@@ -569,8 +590,8 @@ func (c *cursor) ClosePath() uint32 {
 }
 
 // encodeGeometry will take a tegola.Geometry type and encode it according to the
-// mapbox vector_tile spec.
-func encodeGeometry(ctx context.Context, geometry tegola.Geometry, tile *tegola.Tile, simplify bool) (g []uint32, vtyp vectorTile.Tile_GeomType, err error) {
+// mapbox vector_tile spec. Lines and polygons are simplified with fn to within tolerance pixels.
+func encodeGeometry(ctx context.Context, geometry tegola.Geometry, tile *tegola.Tile, simplify bool, fn Simplifier, tolerance float64) (g []uint32, vtyp vectorTile.Tile_GeomType, err error) {
 
 	if geometry == nil {
 		return nil, vectorTile.Tile_UNKNOWN, ErrNilGeometryType
@@ -585,8 +606,13 @@ func encodeGeometry(ctx context.Context, geometry tegola.Geometry, tile *tegola.
 
 	// TODO: gdey: We need to separate out the transform, simplification, and clipping from the encoding process. #224
 
+	start := timing.Start(ctx)
 	geo := c.ScaleGeo(geometry)
-	sg := SimplifyGeometry(geo, tile.ZEpislon(), simplify)
+	timing.Stop(ctx, timing.Encode, start)
+
+	start = timing.Start(ctx)
+	sg := simplifyGeometry(geo, tolerance, simplify, fn)
+	timing.Stop(ctx, timing.Simplify, start)
 
 	pbb, err := tile.PixelBufferedBounds()
 	if err != nil {
@@ -594,13 +620,19 @@ func encodeGeometry(ctx context.Context, geometry tegola.Geometry, tile *tegola.
 	}
 	ext := geom.NewExtent([2]float64{pbb[0], pbb[1]}, [2]float64{pbb[2], pbb[3]})
 
+	start = timing.Start(ctx)
 	geometry, err = validate.CleanGeometry(ctx, sg, ext)
+	timing.Stop(ctx, timing.Clean, start)
 	if err != nil {
 		return nil, vectorTile.Tile_UNKNOWN, err
 	}
 	if geometry == nil {
 		return []uint32{}, -1, nil
 	}
+
+	start = timing.Start(ctx)
+	defer timing.Stop(ctx, timing.Encode, start)
+
 	switch t := geometry.(type) {
 	case tegola.Point:
 		g = append(g, c.MoveTo(t)...)
@@ -3,6 +3,7 @@ package mvt
 import (
 	"context"
 	"fmt"
+	"math"
 	"reflect"
 	"testing"
 
@@ -83,7 +84,7 @@ func TestEncodeGeometry(t *testing.T) {
 
 	fn := func(tc tcase) func(t *testing.T) {
 		return func(t *testing.T) {
-			g, gtype, err := encodeGeometry(context.Background(), tc.geo, tile, true)
+			g, gtype, err := encodeGeometry(context.Background(), tc.geo, tile, true, nil, tile.ZEpislon())
 			if tc.eerr != err {
 				t.Errorf("error, expected %v got %v", tc.eerr, err)
 			}
@@ -189,3 +190,132 @@ func TestEncodeGeometry(t *testing.T) {
 		t.Run(name, fn(tc))
 	}
 }
+
+func TestSimplifierDouglasPeuckerDefault(t *testing.T) {
+	type tcase struct {
+		g         tegola.Geometry
+		tolerance float64
+	}
+
+	// a jagged ring so the simplification removes points
+	var ring basic.Line
+	for i := 0; i < 64; i++ {
+		r := 100.0
+		if i%2 == 0 {
+			r = 102.0
+		}
+		a := 2 * math.Pi * float64(i) / 64
+		ring = append(ring, basic.Point{r * math.Cos(a), r * math.Sin(a)})
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		simplifier, err := NewSimplifier(SimplifierDouglasPeucker)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := simplifyGeometry(tc.g, tc.tolerance, true, nil)
+		got := simplifyGeometry(tc.g, tc.tolerance, true, simplifier)
+
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("explicit douglas_peucker, expected %v got %v", expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"polygon": {
+			g:         basic.Polygon{ring},
+			tolerance: 2,
+		},
+		"line": {
+			g:         ring,
+			tolerance: 2,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestLayerSimplifyTolerancePixels(t *testing.T) {
+	type tcase struct {
+		simplifier Simplifier
+		tolerance  float64
+		// points which are kept and removed, for the line and the polygon alike
+		kept    [2]float64
+		removed [2]float64
+	}
+
+	// a square with a 3 pixel and a 1 pixel bump on its top edge
+	ring := basic.Line{{0, 0}, {0, 100}, {25, 101}, {40, 100}, {50, 103}, {75, 100}, {100, 100}, {100, 0}}
+
+	contains := func(pts [][2]float64, pt [2]float64) bool {
+		for _, p := range pts {
+			if p == pt {
+				return true
+			}
+		}
+		return false
+	}
+	lineString := func(l tegola.LineString) (pts [][2]float64) {
+		for _, p := range l.Subpoints() {
+			pts = append(pts, [2]float64{p.X(), p.Y()})
+		}
+		return pts
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		l := Layer{Simplifier: tc.simplifier, SimplifyTolerance: tc.tolerance}
+		simplify, simplifier, tolerance := l.simplification(tegola.NewTile(0, 0, 0))
+		if !simplify {
+			t.Fatalf("expected the layer to be simplified")
+		}
+
+		line, ok := simplifyGeometry(ring, tolerance, simplify, simplifier).(tegola.LineString)
+		if !ok {
+			t.Fatalf("expected a line")
+		}
+		poly, ok := simplifyGeometry(basic.Polygon{ring}, tolerance, simplify, simplifier).(tegola.Polygon)
+		if !ok || len(poly.Sublines()) != 1 {
+			t.Fatalf("expected a polygon")
+		}
+
+		// the same tolerance removes the same points of the line and the ring
+		if !reflect.DeepEqual(lineString(line), lineString(poly.Sublines()[0])) {
+			t.Errorf("line and polygon simplified differently: %v != %v", lineString(line), lineString(poly.Sublines()[0]))
+		}
+
+		for name, pts := range map[string][][2]float64{
+			"line":    lineString(line),
+			"polygon": lineString(poly.Sublines()[0]),
+		} {
+			if !contains(pts, tc.kept) {
+				t.Errorf("%v, expected %v to be kept: %v", name, tc.kept, pts)
+			}
+			if contains(pts, tc.removed) {
+				t.Errorf("%v, expected %v to be removed: %v", name, tc.removed, pts)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"douglas peucker": {
+			tolerance: 2,
+			kept:      [2]float64{50, 103},
+			removed:   [2]float64{25, 101},
+		},
+		"visvalingam": {
+			simplifier: Visvalingam,
+			tolerance:  5,
+			kept:       [2]float64{50, 103},
+			removed:    [2]float64{25, 101},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -6,12 +6,12 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strconv"
 	"strings"
 
 	"context"
 
 	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/internal/timing"
 	"github.com/go-spatial/tegola/mvt/vector_tile"
 )
 
@@ -24,24 +24,6 @@ func init() {
 		simplifyGeometries = false
 		log.Println("Turning Off Simplification of Geometries.")
 	}
-	if strings.Contains(options, "simplifymaxzoom=") {
-		idx := strings.Index(options, "simplifymaxzoom=")
-		idx += 16
-		eidx := strings.IndexAny(options[idx:], ",.\t \n")
-
-		if eidx == -1 {
-			eidx = len(options)
-		} else {
-			eidx += idx
-		}
-		i, err := strconv.Atoi(options[idx:eidx])
-		if err != nil {
-			log.Printf("Did not under the value(%v) for SimplifyMaxZoom sticking with default (%v).", options[idx:eidx], simplificationMaxZoom)
-			return
-		}
-		simplificationMaxZoom = int(i + 1)
-		log.Printf("Setting SimplifyMaxZoom to %v", int(i))
-	}
 }
 
 // Layer describes a layer in the tile. Each layer can have multiple features
@@ -54,8 +36,14 @@ type Layer struct {
 	extent   *int // default is 4096
 	// DontSimplify truns off simplification for this layer.
 	DontSimplify bool
-	// MaxSimplificationZoom is the zoom level at which point simplification is turned off. if value is zero Max is set to 14. If you do not want to simplify at any level set DontSimplify to true.
+	// MaxSimplificationZoom is the zoom level at which point simplification is turned off. if value is zero Max is set to 10. If you do not want to simplify at any level set DontSimplify to true.
 	MaxSimplificationZoom uint
+	// Simplifier is used to simplify lines and polygons. If nil Douglas-Peucker is used.
+	Simplifier Simplifier
+	// SimplifyTolerance is the simplification tolerance in tile pixels. It's a distance for Douglas-Peucker
+	// and the side of a square for Visvalingam's area, for lines and polygons alike. A nil Simplifier uses
+	// DouglasPeucker when it's set. If zero the tile's tolerance and the default simplification are used.
+	SimplifyTolerance float64
 }
 
 func valMapToVTileValue(valMap []interface{}) (vt []*vectorTile.Tile_Value) {
@@ -67,24 +55,24 @@ func valMapToVTileValue(valMap []interface{}) (vt []*vectorTile.Tile_Value) {
 
 // VTileLayer returns a vectorTile Tile_Layer object that represents this layer.
 func (l *Layer) VTileLayer(ctx context.Context, tile *tegola.Tile) (*vectorTile.Tile_Layer, error) {
+	ctx = timing.WithLayer(ctx, l.Name)
+
+	start := timing.Start(ctx)
 	kmap, vmap, err := keyvalMapsFromFeatures(l.features)
 	if err != nil {
 		return nil, err
 	}
 	valmap := valMapToVTileValue(vmap)
+	timing.Stop(ctx, timing.Encode, start)
+
 	var features = make([]*vectorTile.Tile_Feature, 0, len(l.features))
+	simplify, fn, tolerance := l.simplification(tile)
 	for _, f := range l.features {
 		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
-		simplify := simplifyGeometries && !l.DontSimplify
-		if l.MaxSimplificationZoom == 0 {
-			l.MaxSimplificationZoom = uint(simplificationMaxZoom)
-		}
-
-		simplify = simplify && tile.Z < l.MaxSimplificationZoom
 
-		vtf, err := f.VTileFeature(ctx, kmap, vmap, tile, simplify)
+		vtf, err := f.vtileFeature(ctx, kmap, vmap, tile, simplify, fn, tolerance)
 		if err != nil {
 			switch err {
 			case context.Canceled:
@@ -110,6 +98,26 @@ func (l *Layer) VTileLayer(ctx context.Context, tile *tegola.Tile) (*vectorTile.
 	return vtl, nil
 }
 
+// simplification returns if the layer's geometries are simplified for the tile, the simplifier and the tolerance
+func (l *Layer) simplification(tile *tegola.Tile) (bool, Simplifier, float64) {
+	fn, tolerance := l.Simplifier, tile.ZEpislon()
+	if l.SimplifyTolerance > 0 {
+		// the default simplification treats the tolerance of polygons as an area,
+		// use a simplifier which applies the pixels the same way to lines and polygons
+		tolerance = l.SimplifyTolerance
+		if fn == nil {
+			fn = DouglasPeucker
+		}
+	}
+
+	simplify := simplifyGeometries && !l.DontSimplify
+	if l.MaxSimplificationZoom == 0 {
+		l.MaxSimplificationZoom = uint(simplificationMaxZoom)
+	}
+
+	return simplify && tile.Z < l.MaxSimplificationZoom, fn, tolerance
+}
+
 //Version is the version of tile spec this layer is from.
 func (*Layer) Version() int { return 2 }
 
@@ -0,0 +1,60 @@
+package mvt
+
+import (
+	"fmt"
+
+	"github.com/go-spatial/tegola/maths"
+)
+
+const (
+	SimplifierDouglasPeucker = "douglas_peucker"
+	SimplifierVisvalingam    = "visvalingam"
+)
+
+// Simplifier simplifies a line or polygon ring in tile pixels to within tolerance pixels
+type Simplifier func(pts []maths.Pt, tolerance float64) []maths.Pt
+
+// DouglasPeucker removes the points closer than tolerance pixels to the simplified line.
+// Unlike the default simplification the tolerance is a distance for lines and polygon rings alike.
+func DouglasPeucker(pts []maths.Pt, tolerance float64) []maths.Pt {
+	if tolerance <= 0 || len(pts) <= 2 {
+		return pts
+	}
+
+	// find the point furthest from the line between the end points
+	l := maths.Line{pts[0], pts[len(pts)-1]}
+	dmax, idx := 0.0, 0
+	for i := 1; i < len(pts)-1; i++ {
+		if d := l.DistanceFromPoint(pts[i]); d > dmax {
+			dmax, idx = d, i
+		}
+	}
+	if dmax <= tolerance {
+		return []maths.Pt{pts[0], pts[len(pts)-1]}
+	}
+
+	left := DouglasPeucker(pts[:idx+1], tolerance)
+	right := DouglasPeucker(pts[idx:], tolerance)
+	// the furthest point ends left and starts right
+	return append(left[:len(left)-1:len(left)-1], right...)
+}
+
+// Visvalingam removes the points whose effective area is smaller than a square of tolerance pixels.
+// It tends to keep the overall shape of a line better than DouglasPeucker.
+func Visvalingam(pts []maths.Pt, tolerance float64) []maths.Pt {
+	return maths.Visvalingam(pts, tolerance*tolerance)
+}
+
+// NewSimplifier returns the simplifier for name. An empty name and douglas_peucker return
+// a nil Simplifier, which is the default Douglas-Peucker simplification. A layer with a
+// SimplifyTolerance uses DouglasPeucker in its place, see Layer.SimplifyTolerance.
+func NewSimplifier(name string) (Simplifier, error) {
+	switch name {
+	case "", SimplifierDouglasPeucker:
+		return nil, nil
+	case SimplifierVisvalingam:
+		return Visvalingam, nil
+	default:
+		return nil, fmt.Errorf("mvt: unsupported simplifier (%v). supported simplifiers: %v, %v", name, SimplifierDouglasPeucker, SimplifierVisvalingam)
+	}
+}
@@ -0,0 +1,53 @@
+package mvt
+
+import (
+	"context"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/maths/validate"
+)
+
+// GeometryStages are a geometry at the stages of its encoding, in tile pixels
+type GeometryStages struct {
+	// Scaled is the geometry scaled to the tile, before it's simplified and clipped
+	Scaled tegola.Geometry
+	// Simplified is the scaled geometry after simplification
+	Simplified tegola.Geometry
+	// Cleaned is the simplified geometry clipped to the buffered tile and made valid.
+	// It's nil if nothing of the geometry is left.
+	Cleaned tegola.Geometry
+}
+
+// GeometryStages runs the stages of the encoding of the geometry as the layer would for the tile.
+// The geometry is expected in webmercator. It's meant for debugging the encoding of features.
+func (l *Layer) GeometryStages(ctx context.Context, geometry tegola.Geometry, tile *tegola.Tile) (GeometryStages, error) {
+	if geometry == nil {
+		return GeometryStages{}, ErrNilGeometryType
+	}
+
+	simplify, fn, tolerance := l.simplification(tile)
+
+	c := NewCursor(tile)
+	// the scaling is done by ScaleGeo
+	c.DisableScaling = true
+
+	var (
+		stages GeometryStages
+		err    error
+	)
+	stages.Scaled = c.ScaleGeo(geometry)
+	stages.Simplified = simplifyGeometry(stages.Scaled, tolerance, simplify, fn)
+
+	pbb, err := tile.PixelBufferedBounds()
+	if err != nil {
+		return stages, err
+	}
+	ext := geom.NewExtent([2]float64{pbb[0], pbb[1]}, [2]float64{pbb[2], pbb[3]})
+
+	if stages.Cleaned, err = validate.CleanGeometry(ctx, stages.Simplified, ext); err != nil {
+		return stages, err
+	}
+
+	return stages, nil
+}
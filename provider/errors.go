@@ -3,6 +3,7 @@ package provider
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 var (
@@ -17,3 +18,20 @@ type ErrUnableToConvertFeatureID struct {
 func (e ErrUnableToConvertFeatureID) Error() string {
 	return fmt.Sprintf("unable to convert feature id %+v to uint64", e.val)
 }
+
+type ErrInvalidFeatureIDStrategy string
+
+func (e ErrInvalidFeatureIDStrategy) Error() string {
+	return fmt.Sprintf("provider: invalid id strategy (%v)", string(e))
+}
+
+// ErrQueryTimeout is returned by a Tiler when fetching a layer's features
+// exceeded the configured query timeout
+type ErrQueryTimeout struct {
+	Layer   string
+	Timeout time.Duration
+}
+
+func (e ErrQueryTimeout) Error() string {
+	return fmt.Sprintf("provider: query for layer (%v) exceeded timeout (%v)", e.Layer, e.Timeout)
+}
@@ -1,41 +1,66 @@
 package provider
 
 import (
+	"math"
 	"strconv"
 
 	"github.com/go-spatial/geom"
 )
 
 type Feature struct {
-	ID       uint64
+	ID uint64
+	// NoID is set when the feature should be encoded without an id. ID is ignored.
+	NoID     bool
 	Geometry geom.Geometry
 	SRID     uint64
 	Tags     map[string]interface{}
 }
 
-// ConvertFeatureID attempts to convert an interface value to an uint64
+// ConvertFeatureID attempts to convert an interface value to an uint64.
+// Negative and fractional values can't be converted.
 func ConvertFeatureID(v interface{}) (uint64, error) {
 	switch aval := v.(type) {
 	case float64:
+		if aval < 0 || aval != math.Trunc(aval) {
+			return 0, ErrUnableToConvertFeatureID{val: v}
+		}
 		return uint64(aval), nil
 	case int64:
+		if aval < 0 {
+			return 0, ErrUnableToConvertFeatureID{val: v}
+		}
 		return uint64(aval), nil
 	case uint64:
 		return aval, nil
 	case uint:
 		return uint64(aval), nil
 	case int8:
+		if aval < 0 {
+			return 0, ErrUnableToConvertFeatureID{val: v}
+		}
 		return uint64(aval), nil
 	case uint8:
 		return uint64(aval), nil
+	case int16:
+		if aval < 0 {
+			return 0, ErrUnableToConvertFeatureID{val: v}
+		}
+		return uint64(aval), nil
 	case uint16:
 		return uint64(aval), nil
 	case int32:
+		if aval < 0 {
+			return 0, ErrUnableToConvertFeatureID{val: v}
+		}
 		return uint64(aval), nil
 	case uint32:
 		return uint64(aval), nil
 	case string:
-		return strconv.ParseUint(aval, 10, 64)
+		id, err := strconv.ParseUint(aval, 10, 64)
+		if err != nil {
+			return 0, ErrUnableToConvertFeatureID{val: v}
+		}
+		return id, nil
 	default:
 		return 0, ErrUnableToConvertFeatureID{val: v}
 	}
@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/go-spatial/tegola/internal/log"
+)
+
+const (
+	// FeatureIDNumeric passes a numeric id through. This is the default.
+	FeatureIDNumeric = "numeric"
+	// FeatureIDHash uses a stable 64-bit hash of the id value. Useful for string and UUID keys.
+	FeatureIDHash = "hash"
+	// FeatureIDSequential numbers the features of a layer in the order they're read for each tile, starting at 1
+	FeatureIDSequential = "sequential"
+	// FeatureIDNone omits feature ids
+	FeatureIDNone = "none"
+)
+
+const (
+	// ConfigKeyIDStrategy is the layer config key used by providers for the FeatureIDStrategy
+	ConfigKeyIDStrategy = "id_strategy"
+	// ConfigKeyIDTag is the layer config key used by providers for FeatureIDStrategy.Tag
+	ConfigKeyIDTag = "id_tag"
+)
+
+// FeatureIDStrategy determines how a provider assigns feature ids
+type FeatureIDStrategy struct {
+	// one of FeatureIDNumeric, FeatureIDHash, FeatureIDSequential or FeatureIDNone.
+	// an empty string is the same as FeatureIDNumeric
+	Type string
+	// when set, the original id value is added to the feature's tags with this key
+	Tag string
+}
+
+// NewFeatureIDStrategy validates the strategy type and returns a FeatureIDStrategy
+func NewFeatureIDStrategy(typ, tag string) (FeatureIDStrategy, error) {
+	switch typ {
+	case "":
+		typ = FeatureIDNumeric
+	case FeatureIDNumeric, FeatureIDHash, FeatureIDSequential, FeatureIDNone:
+	default:
+		return FeatureIDStrategy{}, ErrInvalidFeatureIDStrategy(typ)
+	}
+
+	return FeatureIDStrategy{
+		Type: typ,
+		Tag:  tag,
+	}, nil
+}
+
+// Assign sets the id of f from the value of the feature's id field. n is the
+// 1 based position of the feature in the layer's features for the tile. v may be nil
+// if the id field was not selected or is NULL.
+func (s FeatureIDStrategy) Assign(f *Feature, v interface{}, n uint64) (err error) {
+	if s.Tag != "" && v != nil {
+		f.Tags[s.Tag] = featureIDString(v)
+	}
+
+	switch s.Type {
+	case FeatureIDNone:
+		f.ID, f.NoID = 0, true
+	case FeatureIDSequential:
+		f.ID = n
+	case FeatureIDHash:
+		if v == nil {
+			f.ID, f.NoID = 0, true
+			return nil
+		}
+		f.ID = HashFeatureID(v)
+	default:
+		if v == nil {
+			return nil
+		}
+		f.ID, err = ConvertFeatureID(v)
+		if err != nil && isNumber(v) {
+			// negative and fractional ids can't be encoded. the feature is
+			// encoded without an id rather than failing the whole tile
+			warnSkippedIDOnce.Do(func() {
+				log.Warnf("skipping feature id (%v) which is negative or not an integer. use the %v id_strategy to keep ids for these features", v, FeatureIDHash)
+			})
+			f.ID, f.NoID, err = 0, true, nil
+		}
+	}
+
+	return err
+}
+
+// warnSkippedIDOnce limits the warning about skipped ids to once as it would
+// otherwise be logged for every feature
+var warnSkippedIDOnce sync.Once
+
+// isNumber reports if v is a numeric value
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case float64, int8, int16, int32, int64:
+		return true
+	default:
+		return false
+	}
+}
+
+// HashFeatureID returns a stable 64-bit FNV-1a hash of the string form of v.
+// UUIDs hash the same whether they're read as text or as 16 bytes.
+func HashFeatureID(v interface{}) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(featureIDString(v)))
+	return h.Sum64()
+}
+
+// featureIDString returns the string form of an id value
+func featureIDString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case [16]byte:
+		// uuid
+		return fmt.Sprintf("%x-%x-%x-%x-%x", val[0:4], val[4:6], val[6:8], val[8:10], val[10:16])
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
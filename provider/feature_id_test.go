@@ -0,0 +1,124 @@
+package provider_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola/provider"
+)
+
+func TestFeatureIDStrategyAssign(t *testing.T) {
+	type tcase struct {
+		strategy     string
+		tag          string
+		val          interface{}
+		n            uint64
+		expectedID   uint64
+		expectedNoID bool
+		expectedTags map[string]interface{}
+		expectErr    bool
+	}
+
+	uuid := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+
+	fn := func(t *testing.T, tc tcase) {
+		s, err := provider.NewFeatureIDStrategy(tc.strategy, tc.tag)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		f := provider.Feature{
+			Tags: map[string]interface{}{},
+		}
+
+		err = s.Assign(&f, tc.val, tc.n)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if f.ID != tc.expectedID {
+			t.Errorf("id, expected %v got %v", tc.expectedID, f.ID)
+		}
+		if f.NoID != tc.expectedNoID {
+			t.Errorf("no id, expected %v got %v", tc.expectedNoID, f.NoID)
+		}
+
+		if tc.expectedTags == nil {
+			tc.expectedTags = map[string]interface{}{}
+		}
+		if !reflect.DeepEqual(f.Tags, tc.expectedTags) {
+			t.Errorf("tags, expected %v got %v", tc.expectedTags, f.Tags)
+		}
+	}
+
+	tests := map[string]tcase{
+		"numeric": {
+			val:        int64(42),
+			expectedID: 42,
+		},
+		"numeric string": {
+			strategy:   provider.FeatureIDNumeric,
+			val:        "42",
+			expectedID: 42,
+		},
+		"numeric negative": {
+			val:          int64(-1),
+			expectedNoID: true,
+		},
+		"numeric fractional": {
+			val:          1.5,
+			expectedNoID: true,
+		},
+		"numeric text key": {
+			val:       "abc",
+			expectErr: true,
+		},
+		"hash string": {
+			strategy:   provider.FeatureIDHash,
+			val:        "abc",
+			expectedID: provider.HashFeatureID("abc"),
+		},
+		"hash uuid matches text uuid": {
+			strategy:     provider.FeatureIDHash,
+			tag:          "uuid",
+			val:          uuid,
+			expectedID:   provider.HashFeatureID("123e4567-e89b-12d3-a456-426614174000"),
+			expectedTags: map[string]interface{}{"uuid": "123e4567-e89b-12d3-a456-426614174000"},
+		},
+		"hash null": {
+			strategy:     provider.FeatureIDHash,
+			expectedNoID: true,
+		},
+		"sequential": {
+			strategy:     provider.FeatureIDSequential,
+			tag:          "key",
+			val:          "abc",
+			n:            3,
+			expectedID:   3,
+			expectedTags: map[string]interface{}{"key": "abc"},
+		},
+		"none": {
+			strategy:     provider.FeatureIDNone,
+			val:          int64(42),
+			expectedNoID: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestNewFeatureIDStrategyInvalid(t *testing.T) {
+	_, err := provider.NewFeatureIDStrategy("random", "")
+	if _, ok := err.(provider.ErrInvalidFeatureIDStrategy); !ok {
+		t.Errorf("error, expected ErrInvalidFeatureIDStrategy got %v", err)
+	}
+}
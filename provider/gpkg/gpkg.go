@@ -7,6 +7,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"os"
 
 	"github.com/go-spatial/geom"
 	"github.com/go-spatial/geom/encoding/wkb"
@@ -139,6 +140,9 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 		return err
 	}
 
+	// number of features read. used for sequential ids
+	var count uint64
+
 	for rows.Next() {
 		// check if the context cancelled or timed out
 		if ctx.Err() != nil {
@@ -159,6 +163,8 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 		feature := provider.Feature{
 			Tags: map[string]interface{}{},
 		}
+		// value of the id column
+		var id interface{}
 
 		for i := range cols {
 			// check if the context cancelled or timed out
@@ -171,10 +177,8 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 
 			switch cols[i] {
 			case pLayer.idFieldname:
-				feature.ID, err = provider.ConvertFeatureID(vals[i])
-				if err != nil {
-					return err
-				}
+				// the id is assigned by the layer's id strategy once all the columns are read
+				id = vals[i]
 
 			case pLayer.geomFieldname:
 				log.Debug("extracting geopackage geometry header.", vals[i])
@@ -216,6 +220,11 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 			}
 		}
 
+		count++
+		if err = pLayer.idStrategy.Assign(&feature, id, count); err != nil {
+			return fmt.Errorf("for layer (%v) %v: %v", pLayer.name, pLayer.idFieldname, err)
+		}
+
 		// pass the feature to the provided call back
 		if err = fn(&feature); err != nil {
 			return err
@@ -225,6 +234,22 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 	return nil
 }
 
+// HealthCheck adheres to the provider.Healther interface. It confirms the
+// geopackage file is still readable and the database connection is usable.
+func (p *Provider) HealthCheck(ctx context.Context) error {
+	f, err := os.Open(p.Filepath)
+	if err != nil {
+		return fmt.Errorf("gpkg: health check failed: %v", err)
+	}
+	f.Close()
+
+	if err := p.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("gpkg: health check failed: %v", err)
+	}
+
+	return nil
+}
+
 // Close will close the Provider's database connection
 func (p *Provider) Close() error {
 	return p.db.Close()
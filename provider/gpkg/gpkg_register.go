@@ -302,9 +302,25 @@ func NewTileProvider(config dict.Dicter) (provider.Tiler, error) {
 			return nil, fmt.Errorf("for layer (%v) %v, %q field had the following error: %v", i, layerName, ConfigKeyFields, err)
 		}
 
+		idStrategy := provider.FeatureIDNumeric
+		if idStrategy, err = layerConf.String(provider.ConfigKeyIDStrategy, &idStrategy); err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+
+		var idTag string
+		if idTag, err = layerConf.String(provider.ConfigKeyIDTag, &idTag); err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+
+		ids, err := provider.NewFeatureIDStrategy(idStrategy, idTag)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+
 		// layer container. will be added to the provider after it's configured
 		layer := Layer{
-			name: layerName,
+			name:       layerName,
+			idStrategy: ids,
 		}
 
 		if errTable == nil { // layerConf[ConfigKeyTableName] exists
@@ -9,3 +9,7 @@ func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
 }
 
 func Cleanup() {}
+
+func AutoConfig(gpkgPath string) (map[string]interface{}, error) {
+	return nil, provider.ErrUnsupported
+}
@@ -1,6 +1,9 @@
 package gpkg
 
-import "github.com/go-spatial/geom"
+import (
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/provider"
+)
 
 type Layer struct {
 	name          string
@@ -13,6 +16,8 @@ type Layer struct {
 	srid          uint64
 	bbox          geom.Extent
 	sql           string
+	// how feature ids are assigned
+	idStrategy provider.FeatureIDStrategy
 }
 
 func (l Layer) Name() string            { return l.name }
@@ -0,0 +1,399 @@
+package postgis
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/pgtype"
+)
+
+const (
+	// NestedFormatJSON encodes arrays and json objects as a JSON string value
+	NestedFormatJSON = "json"
+	// NestedFormatFlatten encodes arrays and json objects as one tag per value
+	// using dot separated keys (i.e. name.key.0)
+	NestedFormatFlatten = "flatten"
+
+	// TimeFormatISO8601 encodes timestamps as ISO-8601 strings and intervals as ISO-8601 durations
+	TimeFormatISO8601 = "iso8601"
+	// TimeFormatEpoch encodes timestamps as seconds since the unix epoch and intervals as seconds
+	TimeFormatEpoch = "epoch"
+
+	// NumericFormatFloat encodes numeric values as floats. precision may be lost
+	NumericFormatFloat = "float"
+	// NumericFormatString encodes numeric values as strings
+	NumericFormatString = "string"
+)
+
+const (
+	DefaultNestedFormat  = NestedFormatJSON
+	DefaultTimeFormat    = TimeFormatISO8601
+	DefaultNumericFormat = NumericFormatFloat
+)
+
+// attributeFormat controls how column types without a direct vector tile
+// value equivalent are converted into tags. The zero value uses the defaults.
+type attributeFormat struct {
+	nested  string
+	time    string
+	numeric string
+}
+
+// newAttributeFormat validates the formats and returns an attributeFormat
+func newAttributeFormat(nested, tm, numeric string) (attributeFormat, error) {
+	switch nested {
+	case NestedFormatJSON, NestedFormatFlatten:
+	default:
+		return attributeFormat{}, fmt.Errorf("invalid %v (%v)", ConfigKeyNestedFormat, nested)
+	}
+
+	switch tm {
+	case TimeFormatISO8601, TimeFormatEpoch:
+	default:
+		return attributeFormat{}, fmt.Errorf("invalid %v (%v)", ConfigKeyTimeFormat, tm)
+	}
+
+	switch numeric {
+	case NumericFormatFloat, NumericFormatString:
+	default:
+		return attributeFormat{}, fmt.Errorf("invalid %v (%v)", ConfigKeyNumericFormat, numeric)
+	}
+
+	return attributeFormat{
+		nested:  nested,
+		time:    tm,
+		numeric: numeric,
+	}, nil
+}
+
+// setTags converts a column value into one or more tags
+func (f attributeFormat) setTags(tags map[string]interface{}, name string, valType pgtype.OID, val interface{}) error {
+	switch vt := val.(type) {
+	case map[string]interface{}, []interface{}:
+		// json and jsonb objects and arrays
+		return f.setNestedTags(tags, name, vt)
+	}
+
+	if elems, ok, err := f.arrayElements(val); ok {
+		if err != nil {
+			return err
+		}
+		return f.setNestedTags(tags, name, elems)
+	}
+
+	value, err := f.transformVal(valType, val)
+	if err != nil {
+		return err
+	}
+
+	tags[name] = value
+	return nil
+}
+
+func (f attributeFormat) setNestedTags(tags map[string]interface{}, name string, val interface{}) error {
+	if f.nested != NestedFormatFlatten {
+		b, err := json.Marshal(val)
+		if err != nil {
+			return err
+		}
+		tags[name] = string(b)
+		return nil
+	}
+
+	switch vt := val.(type) {
+	case nil:
+		// nothing to encode
+	case map[string]interface{}:
+		for k, v := range vt {
+			if err := f.setNestedTags(tags, name+"."+k, v); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, v := range vt {
+			if err := f.setNestedTags(tags, name+"."+strconv.Itoa(i), v); err != nil {
+				return err
+			}
+		}
+	default:
+		tags[name] = vt
+	}
+
+	return nil
+}
+
+// arrayElements returns the converted elements of a postgres array. false is
+// returned if val is not an array.
+func (f attributeFormat) arrayElements(val interface{}) ([]interface{}, bool, error) {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, false, nil
+	}
+
+	// all pgtype arrays have an Elements field holding the element values
+	elems := rv.Elem().FieldByName("Elements")
+	if !elems.IsValid() || elems.Kind() != reflect.Slice {
+		return nil, false, nil
+	}
+
+	vals := make([]interface{}, 0, elems.Len())
+	for i := 0; i < elems.Len(); i++ {
+		elem, ok := elems.Index(i).Addr().Interface().(pgtype.Value)
+		if !ok {
+			return nil, true, fmt.Errorf("unsupported array element type %T", elems.Index(i).Interface())
+		}
+
+		v := elem.Get()
+		switch v.(type) {
+		case nil, pgtype.Status:
+			vals = append(vals, nil)
+			continue
+		}
+
+		tv, err := f.transformVal(pgtype.UnknownOID, v)
+		if err != nil {
+			return nil, true, err
+		}
+		vals = append(vals, tv)
+	}
+
+	return vals, true, nil
+}
+
+// transformVal converts a scalar column value into a value which can be encoded in a vector tile
+func (f attributeFormat) transformVal(valType pgtype.OID, val interface{}) (interface{}, error) {
+	switch vt := val.(type) {
+	case bool, string, []byte:
+		return vt, nil
+	case int8:
+		return int64(vt), nil
+	case int16:
+		return int64(vt), nil
+	case int32:
+		return int64(vt), nil
+	case int64, uint64:
+		return vt, nil
+	case uint8:
+		return int64(vt), nil
+	case uint16:
+		return int64(vt), nil
+	case uint32:
+		return int64(vt), nil
+	case float32:
+		return float64(vt), nil
+	case float64:
+		return vt, nil
+	case time.Time:
+		if f.time == TimeFormatEpoch {
+			return vt.Unix(), nil
+		}
+		if valType == pgtype.DateOID {
+			return vt.Format("2006-01-02"), nil
+		}
+		return vt.Format(time.RFC3339Nano), nil
+	case numericNaN:
+		if f.numeric == NumericFormatString {
+			return "NaN", nil
+		}
+		return math.NaN(), nil
+	case *pgtype.Numeric:
+		if f.numeric == NumericFormatString {
+			return numericString(vt), nil
+		}
+
+		var num float64
+		if err := vt.AssignTo(&num); err != nil {
+			return nil, err
+		}
+		return num, nil
+	case [16]byte:
+		// uuid
+		return fmt.Sprintf("%x-%x-%x-%x-%x", vt[0:4], vt[4:6], vt[6:8], vt[8:10], vt[10:16]), nil
+	case *net.IPNet:
+		// inet and cidr. hosts are encoded without a mask
+		if ones, bits := vt.Mask.Size(); ones == bits {
+			return vt.IP.String(), nil
+		}
+		return vt.String(), nil
+	case net.HardwareAddr:
+		return vt.String(), nil
+	case *pgtype.Interval:
+		return f.interval(vt), nil
+	case fmt.Stringer:
+		return vt.String(), nil
+	default:
+		return nil, fmt.Errorf("%v type is not supported", valType)
+	}
+}
+
+// interval converts an interval into seconds or an ISO-8601 duration. When
+// converting to seconds a month is considered to be 30 days.
+func (f attributeFormat) interval(i *pgtype.Interval) interface{} {
+	const day = 24 * time.Hour
+
+	if f.time == TimeFormatEpoch {
+		d := time.Duration(i.Microseconds)*time.Microsecond + time.Duration(i.Days)*day + time.Duration(i.Months)*30*day
+		return d.Seconds()
+	}
+
+	var sb strings.Builder
+	sb.WriteString("P")
+	if i.Months != 0 {
+		fmt.Fprintf(&sb, "%vM", i.Months)
+	}
+	if i.Days != 0 {
+		fmt.Fprintf(&sb, "%vD", i.Days)
+	}
+	if i.Microseconds != 0 || (i.Months == 0 && i.Days == 0) {
+		sb.WriteString("T")
+		sb.WriteString(strconv.FormatFloat((time.Duration(i.Microseconds) * time.Microsecond).Seconds(), 'f', -1, 64))
+		sb.WriteString("S")
+	}
+
+	return sb.String()
+}
+
+// numericString formats a numeric in plain decimal notation, retaining its precision
+func numericString(n *pgtype.Numeric) string {
+	if n.Int == nil {
+		return "0"
+	}
+
+	digits := new(big.Int).Abs(n.Int).String()
+	sign := ""
+	if n.Int.Sign() < 0 {
+		sign = "-"
+	}
+
+	if n.Exp >= 0 {
+		return sign + digits + strings.Repeat("0", int(n.Exp))
+	}
+
+	scale := int(-n.Exp)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+// numericNaN is the value of a numeric column holding NaN, which
+// pgtype.Numeric can not represent
+type numericNaN struct{}
+
+// columnValue decodes a single column of a row. Unlike rows.Values() it does
+// not fail on types the connection does not know about (i.e. extension types),
+// those are returned as their text representation.
+type columnValue struct {
+	oid   pgtype.OID
+	value interface{}
+}
+
+func (c *columnValue) DecodeText(ci *pgtype.ConnInfo, src []byte) error {
+	if src == nil {
+		c.value = nil
+		return nil
+	}
+
+	if c.oid == pgtype.NumericOID && string(src) == "NaN" {
+		c.value = numericNaN{}
+		return nil
+	}
+
+	dt, ok := ci.DataTypeForOID(c.oid)
+	if !ok {
+		c.value = string(src)
+		return nil
+	}
+
+	val := reflect.New(reflect.ValueOf(dt.Value).Elem().Type()).Interface()
+	decoder, ok := val.(pgtype.TextDecoder)
+	if !ok {
+		c.value = string(src)
+		return nil
+	}
+	if err := decoder.DecodeText(ci, src); err != nil {
+		return err
+	}
+
+	c.value = val.(pgtype.Value).Get()
+	return nil
+}
+
+func (c *columnValue) DecodeBinary(ci *pgtype.ConnInfo, src []byte) error {
+	if src == nil {
+		c.value = nil
+		return nil
+	}
+
+	// the numeric binary format flags NaN with a sign of 0xC000
+	if c.oid == pgtype.NumericOID && len(src) >= 6 && binary.BigEndian.Uint16(src[4:]) == 0xC000 {
+		c.value = numericNaN{}
+		return nil
+	}
+
+	dt, ok := ci.DataTypeForOID(c.oid)
+	if !ok {
+		return fmt.Errorf("%v type is not supported", c.oid)
+	}
+
+	val := reflect.New(reflect.ValueOf(dt.Value).Elem().Type()).Interface()
+	decoder, ok := val.(pgtype.BinaryDecoder)
+	if !ok {
+		return fmt.Errorf("%v type is not supported", c.oid)
+	}
+	if err := decoder.DecodeBinary(ci, src); err != nil {
+		return err
+	}
+
+	c.value = val.(pgtype.Value).Get()
+	return nil
+}
+
+// rowValues returns the values of the current row. it's used in place of
+// rows.Values() so columns of unknown types don't fail the whole query.
+func rowValues(rows *pgx.Rows) ([]interface{}, error) {
+	fdescs := rows.FieldDescriptions()
+
+	cols := make([]columnValue, len(fdescs))
+	dest := make([]interface{}, len(fdescs))
+	for i := range fdescs {
+		cols[i].oid = fdescs[i].DataType
+		dest[i] = &cols[i]
+	}
+
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	vals := make([]interface{}, len(cols))
+	for i := range cols {
+		vals[i] = cols[i].value
+	}
+
+	return vals, nil
+}
+
+// columns which have been warned about being unsupported. the warning is
+// only logged once per column to keep the logs readable
+var unsupportedColumns sync.Map
+
+func warnUnsupportedColumn(name string, valType pgtype.OID, err error) {
+	key := fmt.Sprintf("%v:%v", name, valType)
+	if _, warned := unsupportedColumns.LoadOrStore(key, true); warned {
+		return
+	}
+
+	log.Printf("postgis: skipping field (%v) of type (%v): %v", name, valType, err)
+}
@@ -0,0 +1,216 @@
+package postgis
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/pgtype"
+)
+
+func TestAttributeFormatSetTags(t *testing.T) {
+	type tcase struct {
+		format   attributeFormat
+		valType  pgtype.OID
+		val      interface{}
+		expected map[string]interface{}
+		err      bool
+	}
+
+	ts := time.Date(2018, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	textArray := &pgtype.TextArray{}
+	textArray.Set([]string{"a", "b"})
+
+	int4Array := &pgtype.Int4Array{}
+	int4Array.Set([]int32{1, 2})
+
+	numeric := &pgtype.Numeric{}
+	numeric.Set("12.5")
+
+	fn := func(t *testing.T, tc tcase) {
+		tags := map[string]interface{}{}
+
+		err := tc.format.setTags(tags, "col", tc.valType, tc.val)
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(tags, tc.expected) {
+			t.Errorf("tags, expected %#v got %#v", tc.expected, tags)
+		}
+	}
+
+	tests := map[string]tcase{
+		"int": {
+			valType:  pgtype.Int4OID,
+			val:      int32(7),
+			expected: map[string]interface{}{"col": int64(7)},
+		},
+		"text array json": {
+			valType:  pgtype.TextArrayOID,
+			val:      textArray,
+			expected: map[string]interface{}{"col": `["a","b"]`},
+		},
+		"text array flatten": {
+			format:   attributeFormat{nested: NestedFormatFlatten},
+			valType:  pgtype.TextArrayOID,
+			val:      textArray,
+			expected: map[string]interface{}{"col.0": "a", "col.1": "b"},
+		},
+		"int array json": {
+			valType:  pgtype.Int4ArrayOID,
+			val:      int4Array,
+			expected: map[string]interface{}{"col": `[1,2]`},
+		},
+		"json object json": {
+			valType:  pgtype.JSONBOID,
+			val:      map[string]interface{}{"a": map[string]interface{}{"b": 1.0}},
+			expected: map[string]interface{}{"col": `{"a":{"b":1}}`},
+		},
+		"json object flatten": {
+			format:  attributeFormat{nested: NestedFormatFlatten},
+			valType: pgtype.JSONBOID,
+			val: map[string]interface{}{
+				"a": map[string]interface{}{"b": 1.0},
+				"c": []interface{}{"x", true},
+				"d": nil,
+			},
+			expected: map[string]interface{}{"col.a.b": 1.0, "col.c.0": "x", "col.c.1": true},
+		},
+		"uuid": {
+			valType:  pgtype.UUIDOID,
+			val:      [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00},
+			expected: map[string]interface{}{"col": "123e4567-e89b-12d3-a456-426614174000"},
+		},
+		"inet host": {
+			valType:  pgtype.InetOID,
+			val:      &net.IPNet{IP: net.ParseIP("10.0.0.1").To4(), Mask: net.CIDRMask(32, 32)},
+			expected: map[string]interface{}{"col": "10.0.0.1"},
+		},
+		"timestamp iso8601": {
+			valType:  pgtype.TimestamptzOID,
+			val:      ts,
+			expected: map[string]interface{}{"col": "2018-06-01T12:30:00Z"},
+		},
+		"date iso8601": {
+			valType:  pgtype.DateOID,
+			val:      ts,
+			expected: map[string]interface{}{"col": "2018-06-01"},
+		},
+		"timestamp epoch": {
+			format:   attributeFormat{time: TimeFormatEpoch},
+			valType:  pgtype.TimestampOID,
+			val:      ts,
+			expected: map[string]interface{}{"col": ts.Unix()},
+		},
+		"interval iso8601": {
+			valType:  pgtype.UnknownOID,
+			val:      &pgtype.Interval{Months: 1, Days: 2, Microseconds: 90 * 1000000, Status: pgtype.Present},
+			expected: map[string]interface{}{"col": "P1M2DT90S"},
+		},
+		"interval epoch": {
+			format:   attributeFormat{time: TimeFormatEpoch},
+			valType:  pgtype.UnknownOID,
+			val:      &pgtype.Interval{Days: 1, Microseconds: 1500000, Status: pgtype.Present},
+			expected: map[string]interface{}{"col": 86401.5},
+		},
+		"numeric float": {
+			valType:  pgtype.NumericOID,
+			val:      numeric,
+			expected: map[string]interface{}{"col": 12.5},
+		},
+		"numeric string": {
+			format:   attributeFormat{numeric: NumericFormatString},
+			valType:  pgtype.NumericOID,
+			val:      numeric,
+			expected: map[string]interface{}{"col": "12.5"},
+		},
+		"numeric nan string": {
+			format:   attributeFormat{numeric: NumericFormatString},
+			valType:  pgtype.NumericOID,
+			val:      numericNaN{},
+			expected: map[string]interface{}{"col": "NaN"},
+		},
+		"unsupported": {
+			valType: pgtype.UnknownOID,
+			val:     struct{}{},
+			err:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestColumnValue(t *testing.T) {
+	type tcase struct {
+		oid      pgtype.OID
+		binary   bool
+		src      []byte
+		expected interface{}
+	}
+
+	ci := pgtype.NewConnInfo()
+	ci.InitializeDataTypes(map[string]pgtype.OID{"int4": pgtype.Int4OID})
+
+	fn := func(t *testing.T, tc tcase) {
+		c := columnValue{oid: tc.oid}
+
+		var err error
+		if tc.binary {
+			err = c.DecodeBinary(ci, tc.src)
+		} else {
+			err = c.DecodeText(ci, tc.src)
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(c.value, tc.expected) {
+			t.Errorf("value, expected %#v got %#v", tc.expected, c.value)
+		}
+	}
+
+	tests := map[string]tcase{
+		"null": {
+			oid:      pgtype.Int4OID,
+			expected: nil,
+		},
+		"known text": {
+			oid:      pgtype.Int4OID,
+			src:      []byte("42"),
+			expected: int32(42),
+		},
+		"unknown oid text": {
+			oid:      pgtype.OID(99999),
+			src:      []byte("(1,2)"),
+			expected: "(1,2)",
+		},
+		"numeric nan text": {
+			oid:      pgtype.NumericOID,
+			src:      []byte("NaN"),
+			expected: numericNaN{},
+		},
+		"numeric nan binary": {
+			oid:      pgtype.NumericOID,
+			binary:   true,
+			src:      []byte{0, 0, 0, 0, 0xC0, 0, 0, 0},
+			expected: numericNaN{},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
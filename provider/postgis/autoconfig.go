@@ -0,0 +1,343 @@
+package postgis
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/provider"
+)
+
+const (
+	// the geometry columns of the database
+	geometryColumnsSQL = `SELECT f_table_schema, f_table_name, f_geometry_column, srid, type FROM geometry_columns ORDER BY f_table_schema, f_table_name, f_geometry_column`
+	// the primary key columns of a table
+	primaryKeySQL = `SELECT a.attname::text, t.typname::text FROM pg_index i JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey) JOIN pg_type t ON t.oid = a.atttypid WHERE i.indrelid = $1::regclass AND i.indisprimary`
+	// the columns of a table in order
+	columnsSQL = `SELECT column_name::text, udt_name::text FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2 ORDER BY ordinal_position`
+)
+
+// AutoConfigURIEnv is the environment variable the uri of a config created by AutoConfig is
+// read from when the connection string has a password, so the password is not written to the config
+const AutoConfigURIEnv = "POSTGIS_URI"
+
+// column types which are not useful as feature tags
+var autoConfigSkipTypes = map[string]bool{
+	"geometry":  true,
+	"geography": true,
+	"raster":    true,
+	"box2d":     true,
+	"box3d":     true,
+	"bytea":     true,
+	"tsvector":  true,
+	"tsquery":   true,
+}
+
+// primary key types which are passed through as feature ids. other primary keys are hashed
+var autoConfigIntegerTypes = map[string]bool{
+	"int2": true,
+	"int4": true,
+	"int8": true,
+}
+
+// identifiers which don't need quoting
+var simpleIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// autoConfigTable is a geometry column of a table found by AutoConfig
+type autoConfigTable struct {
+	schema     string
+	table      string
+	geomColumn string
+	geomType   string
+	srid       int
+	// the primary key column and its type. empty if the table doesn't have a single column primary key
+	pk     string
+	pkType string
+	// the columns of the table in order
+	columns []autoConfigColumn
+}
+
+type autoConfigColumn struct {
+	name    string
+	typName string
+}
+
+// AutoConfig creates a config instance of the type NewTileProvider() requires including a layer for each
+// geometry column of the database at uri, a libpq style connection URI or key/value connection string.
+// The tables are read from geometry_columns, optionally filtered to the schemas and tables. A table
+// can be schema qualified (i.e. osm.roads). Empty filters include everything. If uri has a password
+// the config's uri is a placeholder for the AutoConfigURIEnv environment variable.
+func AutoConfig(uri string, schemas, tables []string) (map[string]interface{}, error) {
+	cs, err := parseConnString(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	connConfig := pgx.ConnConfig{
+		Host:     cs.hosts[0],
+		Port:     cs.ports[0],
+		Database: cs.database,
+		User:     cs.user,
+		Password: cs.password,
+		LogLevel: pgx.LogLevelWarn,
+	}
+	sslmode := DefaultSSLMode
+	if v, ok := cs.params["sslmode"]; ok {
+		sslmode = v
+	}
+	if err = ConfigTLS(sslmode, cs.params["sslkey"], cs.params["sslcert"], cs.params["sslrootcert"], &connConfig); err != nil {
+		return nil, err
+	}
+
+	conn, err := pgx.Connect(connConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	found, err := geometryColumns(conn, schemas, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range found {
+		t := &found[i]
+		if t.pk, t.pkType, err = primaryKey(conn, t.schema, t.table); err != nil {
+			return nil, err
+		}
+		if t.columns, err = tableColumns(conn, t.schema, t.table); err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]interface{}{
+		"name":          Name,
+		"type":          Name,
+		ConfigKeyURI:    autoConfigURI(uri, cs),
+		ConfigKeyLayers: autoConfigLayers(found),
+	}, nil
+}
+
+// autoConfigURI returns the uri to write to the config. a uri with a password is
+// replaced by a placeholder for the AutoConfigURIEnv environment variable
+func autoConfigURI(uri string, cs *connString) string {
+	if cs.password == "" {
+		return uri
+	}
+	return "${" + AutoConfigURIEnv + "}"
+}
+
+func geometryColumns(conn *pgx.Conn, schemas, tables []string) ([]autoConfigTable, error) {
+	rows, err := conn.Query(geometryColumnsSQL)
+	if err != nil {
+		return nil, fmt.Errorf("reading geometry_columns: %v", err)
+	}
+	defer rows.Close()
+
+	var found []autoConfigTable
+	for rows.Next() {
+		var (
+			t    autoConfigTable
+			srid int32
+		)
+		if err := rows.Scan(&t.schema, &t.table, &t.geomColumn, &srid, &t.geomType); err != nil {
+			return nil, fmt.Errorf("reading geometry_columns: %v", err)
+		}
+		t.srid = int(srid)
+
+		if !autoConfigIncluded(t.schema, t.table, schemas, tables) {
+			continue
+		}
+		// the SRID is needed to query the table
+		if t.srid <= 0 {
+			log.Printf("postgis: skipping geometry column (%v) of table (%v.%v) with an unknown SRID", t.geomColumn, t.schema, t.table)
+			continue
+		}
+		found = append(found, t)
+	}
+
+	return found, rows.Err()
+}
+
+func primaryKey(conn *pgx.Conn, schema, table string) (string, string, error) {
+	rows, err := conn.Query(primaryKeySQL, quoteIdent(schema)+"."+quoteIdent(table))
+	if err != nil {
+		return "", "", fmt.Errorf("reading the primary key of table (%v.%v): %v", schema, table, err)
+	}
+	defer rows.Close()
+
+	var pks, types []string
+	for rows.Next() {
+		var pk, typ string
+		if err := rows.Scan(&pk, &typ); err != nil {
+			return "", "", fmt.Errorf("reading the primary key of table (%v.%v): %v", schema, table, err)
+		}
+		pks, types = append(pks, pk), append(types, typ)
+	}
+	if err := rows.Err(); err != nil {
+		return "", "", err
+	}
+
+	// composite keys can't be used as feature ids
+	if len(pks) != 1 {
+		return "", "", nil
+	}
+	return pks[0], types[0], nil
+}
+
+func tableColumns(conn *pgx.Conn, schema, table string) ([]autoConfigColumn, error) {
+	rows, err := conn.Query(columnsSQL, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("reading the columns of table (%v.%v): %v", schema, table, err)
+	}
+	defer rows.Close()
+
+	var columns []autoConfigColumn
+	for rows.Next() {
+		var c autoConfigColumn
+		if err := rows.Scan(&c.name, &c.typName); err != nil {
+			return nil, fmt.Errorf("reading the columns of table (%v.%v): %v", schema, table, err)
+		}
+		columns = append(columns, c)
+	}
+
+	return columns, rows.Err()
+}
+
+// autoConfigIncluded reports if the table passes the schema and table filters
+func autoConfigIncluded(schema, table string, schemas, tables []string) bool {
+	if len(schemas) > 0 {
+		var ok bool
+		for _, s := range schemas {
+			if s == schema {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if len(tables) == 0 {
+		return true
+	}
+	for _, t := range tables {
+		if t == table || t == schema+"."+table {
+			return true
+		}
+	}
+	return false
+}
+
+// autoConfigLayers returns the provider layer configs of the geometry columns. Layers are named after
+// their table, suffixed with the geometry column for tables with several, and prefixed with the schema
+// when the name is used in several schemas.
+func autoConfigLayers(tables []autoConfigTable) []map[string]interface{} {
+	geomColumns := map[string]int{}
+	for _, t := range tables {
+		geomColumns[t.schema+"."+t.table]++
+	}
+
+	names := make([]string, len(tables))
+	schemas := map[string]map[string]bool{}
+	for i, t := range tables {
+		names[i] = t.table
+		if geomColumns[t.schema+"."+t.table] > 1 {
+			names[i] += "_" + t.geomColumn
+		}
+		if schemas[names[i]] == nil {
+			schemas[names[i]] = map[string]bool{}
+		}
+		schemas[names[i]][t.schema] = true
+	}
+	for i, t := range tables {
+		if len(schemas[names[i]]) > 1 {
+			names[i] = t.schema + "_" + names[i]
+		}
+	}
+
+	layers := make([]map[string]interface{}, len(tables))
+	for i, t := range tables {
+		layers[i] = autoConfigLayer(names[i], t)
+	}
+
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i][ConfigKeyLayerName].(string) < layers[j][ConfigKeyLayerName].(string)
+	})
+	return layers
+}
+
+func autoConfigLayer(name string, t autoConfigTable) map[string]interface{} {
+	layer := map[string]interface{}{
+		ConfigKeyLayerName: name,
+		ConfigKeyGeomField: t.geomColumn,
+	}
+
+	switch {
+	case t.pk == "":
+		// without a primary key there's nothing to identify the features with
+		layer[provider.ConfigKeyIDStrategy] = provider.FeatureIDNone
+	case autoConfigIntegerTypes[t.pkType]:
+		layer[ConfigKeyGeomIDField] = t.pk
+	default:
+		layer[ConfigKeyGeomIDField] = t.pk
+		layer[provider.ConfigKeyIDStrategy] = provider.FeatureIDHash
+	}
+
+	switch geomType := strings.ToLower(t.geomType); geomType {
+	case "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection":
+		layer[ConfigKeyGeomType] = geomType
+	}
+
+	fields := []string{}
+	for _, c := range t.columns {
+		if c.name == t.pk || c.name == t.geomColumn || autoConfigSkipTypes[c.typName] {
+			continue
+		}
+		fields = append(fields, c.name)
+	}
+
+	tablename := tableIdent(t.table)
+	if t.schema != "public" {
+		tablename = tableIdent(t.schema) + "." + tablename
+	}
+
+	// tegola queries tables in webmercator or WGS84. other SRIDs are transformed by the database
+	if t.srid == tegola.WebMercator || t.srid == tegola.WGS84 {
+		layer[ConfigKeyTablename] = tablename
+		layer[ConfigKeySRID] = t.srid
+		layer[ConfigKeyFields] = fields
+		return layer
+	}
+
+	cols := []string{fmt.Sprintf(`ST_AsBinary(ST_Transform(%v, %v)) AS %[1]v`, quoteIdent(t.geomColumn), tegola.WebMercator)}
+	if t.pk != "" {
+		cols = append(cols, quoteIdent(t.pk))
+	}
+	for _, f := range fields {
+		cols = append(cols, quoteIdent(f))
+	}
+	layer[ConfigKeySQL] = fmt.Sprintf(`SELECT %v FROM %v WHERE %v && ST_Transform(%v, %v)`, strings.Join(cols, ", "), tablename, quoteIdent(t.geomColumn), bboxToken, t.srid)
+	layer[ConfigKeySRID] = tegola.WebMercator
+
+	return layer
+}
+
+// quoteIdent quotes an identifier
+func quoteIdent(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
+
+// tableIdent quotes a schema or table name if it isn't a lowercase identifier
+func tableIdent(s string) string {
+	if simpleIdentifier.MatchString(s) {
+		return s
+	}
+	return quoteIdent(s)
+}
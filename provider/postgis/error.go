@@ -1,6 +1,9 @@
 package postgis
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type ErrLayerNotFound struct {
 	LayerName string
@@ -21,3 +24,20 @@ type ErrUnclosedToken string
 func (e ErrUnclosedToken) Error() string {
 	return fmt.Sprintf("postgis: unclosed token in (%v)", string(e))
 }
+
+type ErrInvalidConnString struct {
+	Reason string
+}
+
+func (e ErrInvalidConnString) Error() string {
+	return fmt.Sprintf("postgis: invalid connection string: %v", e.Reason)
+}
+
+type ErrInvalidLoadBalancing string
+
+func (e ErrInvalidLoadBalancing) Error() string {
+	return fmt.Sprintf("postgis: invalid load balancing strategy (%v)", string(e))
+}
+
+// ErrNoHealthyHosts is returned when none of the configured database hosts can be reached
+var ErrNoHealthyHosts = errors.New("postgis: no healthy database hosts")
@@ -1,6 +1,11 @@
 package postgis
 
-import "github.com/go-spatial/geom"
+import (
+	"time"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/provider"
+)
 
 // layer holds information about a query.
 type Layer struct {
@@ -8,6 +13,8 @@ type Layer struct {
 	name string
 	// The SQL to use when querying PostGIS for this layer
 	sql string
+	// zoom specific SQL. when set, sql is not used
+	variants []layerVariant
 	// The ID field name, this will default to 'gid' if not set to something other then empty string.
 	idField string
 	// The Geometery field name, this will default to 'geom' if not set to something other then empty string.
@@ -16,6 +23,12 @@ type Layer struct {
 	geomType geom.Geometry
 	// The SRID that the data in the table is stored in. This will default to WebMercator
 	srid uint64
+	// statement timeout for the layer's query. 0 means no timeout
+	queryTimeout time.Duration
+	// how column values are converted into tags
+	format attributeFormat
+	// how feature ids are assigned
+	idStrategy provider.FeatureIDStrategy
 }
 
 func (l Layer) Name() string {
@@ -37,3 +50,26 @@ func (l Layer) GeomFieldName() string {
 func (l Layer) IDFieldName() string {
 	return l.idField
 }
+
+// SQL returns the SQL for querying the layer at zoom z. If the layer has zoom
+// variants and none of them cover z, false is returned.
+func (l Layer) SQL(z uint) (string, bool) {
+	if len(l.variants) == 0 {
+		return l.sql, true
+	}
+
+	for _, v := range l.variants {
+		if z >= v.minZoom && z <= v.maxZoom {
+			return v.sql, true
+		}
+	}
+
+	return "", false
+}
+
+// layerVariant is the SQL used for a layer within a zoom range
+type layerVariant struct {
+	minZoom uint
+	maxZoom uint
+	sql     string
+}
@@ -0,0 +1,470 @@
+package postgis
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LoadBalanceRoundRobin rotates queries across the healthy hosts
+	LoadBalanceRoundRobin = "round_robin"
+	// LoadBalanceLeastConnections sends queries to the healthy host with the fewest connections in use
+	LoadBalanceLeastConnections = "least_connections"
+)
+
+// lazyRetryTimeout is how long a host which is retried by pick has to respond
+const lazyRetryTimeout = 5 * time.Second
+
+// pgxPool is the part of *pgx.ConnPool used by connPool
+type pgxPool interface {
+	Query(sql string, args ...interface{}) (*pgx.Rows, error)
+	QueryEx(ctx context.Context, sql string, options *pgx.QueryExOptions, args ...interface{}) (*pgx.Rows, error)
+	ExecEx(ctx context.Context, sql string, options *pgx.QueryExOptions, args ...interface{}) (pgx.CommandTag, error)
+	BeginEx(ctx context.Context, options *pgx.TxOptions) (*pgx.Tx, error)
+	Stat() pgx.ConnPoolStat
+	Close()
+}
+
+// newPgxPool connects to a host. Replaced in tests.
+var newPgxPool = func(config pgx.ConnPoolConfig) (pgxPool, error) {
+	pool, err := pgx.NewConnPool(config)
+	if err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// hostPool is the connection pool for a single database host
+type hostPool struct {
+	config pgx.ConnPoolConfig
+
+	mu sync.RWMutex
+	// nil until a connection to the host has been established
+	pool    pgxPool
+	healthy bool
+	// when the host was last found unhealthy or retried
+	lastFailure time.Time
+	// set once the pool is closed so a check in progress doesn't reconnect
+	closed bool
+}
+
+func (h *hostPool) name() string {
+	return h.config.Host
+}
+
+// get returns the host's connection pool if the host is healthy
+func (h *hostPool) get() (pgxPool, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.pool, h.healthy && h.pool != nil
+}
+
+// inUse returns the number of connections currently checked out of the pool
+func (h *hostPool) inUse() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.pool == nil {
+		return 0
+	}
+
+	stat := h.pool.Stat()
+	return stat.CurrentConnections - stat.AvailableConnections
+}
+
+func (h *hostPool) setHealthy(healthy bool, err error, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !healthy {
+		h.lastFailure = now
+	}
+	if h.healthy == healthy {
+		return
+	}
+	h.healthy = healthy
+
+	if healthy {
+		log.Printf("postgis: host (%v) is healthy, adding it back to the pool", h.name())
+	} else {
+		log.Printf("postgis: host (%v) is unhealthy, removing it from the pool: %v", h.name(), err)
+	}
+}
+
+// check connects to the host if needed and runs a trivial query against it. The host
+// is dialed without holding the lock so a slow host doesn't block picking the others.
+func (h *hostPool) check(ctx context.Context) error {
+	h.mu.RLock()
+	pool := h.pool
+	h.mu.RUnlock()
+
+	if pool == nil {
+		config := h.config
+		// without a connect_timeout pgx dials without a timeout
+		if deadline, ok := ctx.Deadline(); ok && config.Dial == nil {
+			config.Dial = (&net.Dialer{Timeout: time.Until(deadline), KeepAlive: 5 * time.Minute}).Dial
+		}
+
+		var err error
+		if pool, err = newPgxPool(config); err != nil {
+			return err
+		}
+
+		h.mu.Lock()
+		switch {
+		case h.closed:
+			h.mu.Unlock()
+			pool.Close()
+			return ErrNoHealthyHosts
+		case h.pool != nil:
+			// connected by another check in the meantime
+			h.mu.Unlock()
+			pool.Close()
+			pool = h.pool
+		default:
+			h.pool = pool
+			h.mu.Unlock()
+		}
+	}
+
+	_, err := pool.ExecEx(ctx, "SELECT 1", nil)
+	return err
+}
+
+// dueForRetry reports if the unhealthy host was last found unhealthy over interval ago. The
+// retry time is reset so a single caller retries the host.
+func (h *hostPool) dueForRetry(now time.Time, interval time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.healthy || now.Sub(h.lastFailure) < interval {
+		return false
+	}
+	h.lastFailure = now
+	return true
+}
+
+func (h *hostPool) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pool != nil {
+		h.pool.Close()
+		h.pool = nil
+	}
+	h.healthy = false
+	h.closed = true
+}
+
+// connPool spreads queries across the connection pools of one or more hosts.
+// Hosts which fail a query with a connection error are removed from rotation
+// until a background health check finds them reachable again, or, with the
+// health checks disabled, until pick retries them. The last healthy host is
+// never removed so a pool keeps reconnecting to it like a single host pool.
+type connPool struct {
+	hosts    []*hostPool
+	strategy string
+	// the time between retries of unhealthy hosts by pick. 0 when the health checks retry them
+	retryInterval time.Duration
+
+	mu   sync.Mutex
+	next int
+
+	// the retries of unhealthy hosts started by pick
+	retries sync.WaitGroup
+
+	done chan struct{}
+	once sync.Once
+
+	// now returns the current time. used for testing
+	now func() time.Time
+}
+
+// newConnPool creates a connection pool for each of the provided configs. An
+// error is only returned if none of the hosts can be reached. Unreachable hosts
+// are retried every interval. An interval of 0 disables the health checks.
+func newConnPool(configs []pgx.ConnPoolConfig, strategy string, interval time.Duration) (*connPool, error) {
+	if len(configs) == 0 {
+		return nil, ErrNoHealthyHosts
+	}
+
+	switch strategy {
+	case "":
+		strategy = LoadBalanceRoundRobin
+	case LoadBalanceRoundRobin, LoadBalanceLeastConnections:
+	default:
+		return nil, ErrInvalidLoadBalancing(strategy)
+	}
+
+	cp := connPool{
+		strategy: strategy,
+		done:     make(chan struct{}),
+		now:      time.Now,
+	}
+	if interval <= 0 {
+		cp.retryInterval = time.Duration(DefaultHealthCheckInterval) * time.Second
+	}
+
+	var lastErr error
+	for i := range configs {
+		h := hostPool{
+			config: configs[i],
+		}
+
+		pool, err := newPgxPool(h.config)
+		if err != nil {
+			log.Printf("postgis: unable to connect to host (%v): %v", h.name(), err)
+			lastErr = err
+			h.lastFailure = cp.now()
+		} else {
+			h.pool, h.healthy = pool, true
+		}
+
+		cp.hosts = append(cp.hosts, &h)
+	}
+
+	if !cp.anyHealthy() {
+		cp.Close()
+		return nil, lastErr
+	}
+
+	if interval > 0 {
+		go cp.healthCheck(interval)
+	}
+
+	return &cp, nil
+}
+
+// anyHealthy reports if any of the hosts is healthy
+func (cp *connPool) anyHealthy() bool {
+	for _, h := range cp.hosts {
+		if _, ok := h.get(); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pick returns the pool of a healthy host according to the load balancing strategy
+func (cp *connPool) pick() (*hostPool, error) {
+	if cp.retryInterval > 0 {
+		cp.retryUnhealthy()
+	}
+
+	switch cp.strategy {
+	case LoadBalanceLeastConnections:
+		var best *hostPool
+		var bestInUse int
+		for _, h := range cp.hosts {
+			if _, ok := h.get(); !ok {
+				continue
+			}
+			if n := h.inUse(); best == nil || n < bestInUse {
+				best, bestInUse = h, n
+			}
+		}
+		if best == nil {
+			return nil, ErrNoHealthyHosts
+		}
+		return best, nil
+
+	default:
+		cp.mu.Lock()
+		start := cp.next
+		cp.next = (cp.next + 1) % len(cp.hosts)
+		cp.mu.Unlock()
+
+		for i := range cp.hosts {
+			h := cp.hosts[(start+i)%len(cp.hosts)]
+			if _, ok := h.get(); ok {
+				return h, nil
+			}
+		}
+		return nil, ErrNoHealthyHosts
+	}
+}
+
+// retryUnhealthy checks the unhealthy hosts which are due for a retry in the background,
+// adding them back to rotation if they are reachable. Used when health checks are disabled.
+func (cp *connPool) retryUnhealthy() {
+	for _, h := range cp.hosts {
+		if !h.dueForRetry(cp.now(), cp.retryInterval) {
+			continue
+		}
+
+		cp.retries.Add(1)
+		go func(h *hostPool) {
+			defer cp.retries.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), lazyRetryTimeout)
+			err := h.check(ctx)
+			cancel()
+
+			if err == nil {
+				h.setHealthy(true, nil, cp.now())
+			}
+		}(h)
+	}
+}
+
+// eject removes the host from rotation unless it's the last healthy host.
+// It reports if the host was removed.
+func (cp *connPool) eject(h *hostPool, err error) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	for _, other := range cp.hosts {
+		if other == h {
+			continue
+		}
+		if _, ok := other.get(); ok {
+			h.setHealthy(false, err, cp.now())
+			return true
+		}
+	}
+	return false
+}
+
+// do runs fn against a healthy host. If fn fails with a connection error the
+// host is removed from rotation and fn is retried on the next healthy host.
+// The last healthy host is kept and its error returned.
+func (cp *connPool) do(fn func(pool pgxPool) error) error {
+	var lastErr error = ErrNoHealthyHosts
+
+	for range cp.hosts {
+		h, err := cp.pick()
+		if err != nil {
+			return lastErr
+		}
+
+		pool, ok := h.get()
+		if !ok {
+			// the host went unhealthy since it was picked
+			continue
+		}
+
+		err = fn(pool)
+		if !isConnError(err) || !cp.eject(h, err) {
+			return err
+		}
+		lastErr = err
+	}
+
+	return lastErr
+}
+
+// Query runs sql on one of the healthy hosts
+func (cp *connPool) Query(sql string, args ...interface{}) (rows *pgx.Rows, err error) {
+	err = cp.do(func(pool pgxPool) error {
+		rows, err = pool.Query(sql, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryEx runs sql on one of the healthy hosts
+func (cp *connPool) QueryEx(ctx context.Context, sql string, options *pgx.QueryExOptions, args ...interface{}) (rows *pgx.Rows, err error) {
+	err = cp.do(func(pool pgxPool) error {
+		rows, err = pool.QueryEx(ctx, sql, options, args...)
+		return err
+	})
+	return rows, err
+}
+
+// ExecEx runs sql on one of the healthy hosts
+func (cp *connPool) ExecEx(ctx context.Context, sql string, options *pgx.QueryExOptions, args ...interface{}) (tag pgx.CommandTag, err error) {
+	err = cp.do(func(pool pgxPool) error {
+		tag, err = pool.ExecEx(ctx, sql, options, args...)
+		return err
+	})
+	return tag, err
+}
+
+// BeginEx starts a transaction on one of the healthy hosts
+func (cp *connPool) BeginEx(ctx context.Context, options *pgx.TxOptions) (tx *pgx.Tx, err error) {
+	err = cp.do(func(pool pgxPool) error {
+		tx, err = pool.BeginEx(ctx, options)
+		return err
+	})
+	return tx, err
+}
+
+// healthCheck periodically checks every host, removing failed hosts from
+// rotation and adding recovered hosts back
+func (cp *connPool) healthCheck(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cp.done:
+			return
+		case <-ticker.C:
+		}
+
+		for _, h := range cp.hosts {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := h.check(ctx)
+			cancel()
+
+			if err != nil {
+				cp.eject(h, err)
+				continue
+			}
+			h.setHealthy(true, nil, cp.now())
+		}
+	}
+}
+
+// Close stops the health checks and closes the connections to all hosts
+func (cp *connPool) Close() {
+	cp.once.Do(func() { close(cp.done) })
+
+	for _, h := range cp.hosts {
+		h.close()
+	}
+}
+
+// isConnError reports if err was caused by the connection to the host being lost or
+// refused, rather than by the query (i.e. a syntax error or an argument which can't
+// be encoded), a timeout or a canceled context.
+func isConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch err := errors.Cause(err).(type) {
+	case pgx.PgError:
+		return pgConnError(err.Code)
+	case *pgx.PgError:
+		return pgConnError(err.Code)
+	case net.Error:
+		// a slow host is left to the query timeout
+		return !err.Timeout()
+	}
+
+	switch errors.Cause(err) {
+	case pgx.ErrDeadConn, io.EOF, io.ErrUnexpectedEOF:
+		return true
+	}
+	return false
+}
+
+// pgConnError reports if the Postgres error code is a connection exception (class 08)
+// or the server shutting down or starting up
+func pgConnError(code string) bool {
+	switch code {
+	case "57P01", "57P02", "57P03":
+		return true
+	}
+	return strings.HasPrefix(code, "08")
+}
@@ -0,0 +1,300 @@
+package postgis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+// fakePool is a pgxPool which records the queries run against it
+type fakePool struct {
+	host string
+	// the connections in use reported by Stat
+	inUse int
+
+	mu sync.Mutex
+	// returned by every query
+	err     error
+	queries int
+	// the order queries were run in over all the fake pools
+	log *[]string
+}
+
+func (p *fakePool) run() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.queries++
+	if p.log != nil {
+		*p.log = append(*p.log, p.host)
+	}
+	return p.err
+}
+
+func (p *fakePool) setErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *fakePool) Query(sql string, args ...interface{}) (*pgx.Rows, error) {
+	return nil, p.run()
+}
+
+func (p *fakePool) QueryEx(ctx context.Context, sql string, options *pgx.QueryExOptions, args ...interface{}) (*pgx.Rows, error) {
+	return nil, p.run()
+}
+
+func (p *fakePool) ExecEx(ctx context.Context, sql string, options *pgx.QueryExOptions, args ...interface{}) (pgx.CommandTag, error) {
+	return "", p.run()
+}
+
+func (p *fakePool) BeginEx(ctx context.Context, options *pgx.TxOptions) (*pgx.Tx, error) {
+	return nil, p.run()
+}
+
+func (p *fakePool) Stat() pgx.ConnPoolStat {
+	return pgx.ConnPoolStat{CurrentConnections: p.inUse}
+}
+
+func (p *fakePool) Close() {}
+
+// withFakePools replaces newPgxPool with one returning the fake pool of each host.
+// Hosts without a fake pool fail to connect.
+func withFakePools(t *testing.T, pools map[string]*fakePool) (configs []pgx.ConnPoolConfig, restore func()) {
+	orig := newPgxPool
+	newPgxPool = func(config pgx.ConnPoolConfig) (pgxPool, error) {
+		p, ok := pools[config.Host]
+		if !ok {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		}
+		return p, nil
+	}
+
+	for _, host := range []string{"a", "b", "c"} {
+		configs = append(configs, pgx.ConnPoolConfig{ConnConfig: pgx.ConnConfig{Host: host}})
+	}
+	return configs, func() { newPgxPool = orig }
+}
+
+func TestConnPoolRoundRobin(t *testing.T) {
+	var log []string
+	pools := map[string]*fakePool{
+		"a": {host: "a", log: &log},
+		"b": {host: "b", log: &log},
+		"c": {host: "c", log: &log},
+	}
+	configs, restore := withFakePools(t, pools)
+	defer restore()
+
+	cp, err := newConnPool(configs, LoadBalanceRoundRobin, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cp.Close()
+
+	for i := 0; i < 6; i++ {
+		if _, err := cp.ExecEx(context.Background(), "SELECT 1", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := []string{"a", "b", "c", "a", "b", "c"}
+	for i := range expected {
+		if log[i] != expected[i] {
+			t.Fatalf("hosts queried, expected %v got %v", expected, log)
+		}
+	}
+}
+
+func TestConnPoolLeastConnections(t *testing.T) {
+	pools := map[string]*fakePool{
+		"a": {host: "a", inUse: 3},
+		"b": {host: "b", inUse: 1},
+		"c": {host: "c", inUse: 2},
+	}
+	configs, restore := withFakePools(t, pools)
+	defer restore()
+
+	cp, err := newConnPool(configs, LoadBalanceLeastConnections, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cp.Close()
+
+	for i := 0; i < 3; i++ {
+		h, err := cp.pick()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if h.name() != "b" {
+			t.Errorf("picked host, expected b got %v", h.name())
+		}
+	}
+}
+
+func TestConnPoolEjection(t *testing.T) {
+	type tcase struct {
+		hosts []string
+		// the error of the first host
+		err error
+		// if the first host is expected to be removed from rotation
+		ejected bool
+		// the error expected from the query
+		expectedErr error
+	}
+
+	connErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+	fn := func(t *testing.T, tc tcase) {
+		pools := map[string]*fakePool{}
+		for _, host := range tc.hosts {
+			pools[host] = &fakePool{host: host}
+		}
+		pools["a"].err = tc.err
+
+		configs, restore := withFakePools(t, pools)
+		defer restore()
+
+		cp, err := newConnPool(configs[:len(tc.hosts)], LoadBalanceRoundRobin, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer cp.Close()
+
+		_, err = cp.ExecEx(context.Background(), "SELECT 1", nil)
+		if err != tc.expectedErr {
+			t.Errorf("error, expected %v got %v", tc.expectedErr, err)
+		}
+
+		if _, healthy := cp.hosts[0].get(); healthy == tc.ejected {
+			t.Errorf("host a healthy, expected %v got %v", !tc.ejected, healthy)
+		}
+	}
+
+	tests := map[string]tcase{
+		"connection error retried on the next host": {
+			hosts:   []string{"a", "b"},
+			err:     connErr,
+			ejected: true,
+		},
+		"dead connection retried on the next host": {
+			hosts:   []string{"a", "b"},
+			err:     pgx.ErrDeadConn,
+			ejected: true,
+		},
+		"query error": {
+			hosts:       []string{"a", "b"},
+			err:         pgx.PgError{Code: "42601"},
+			expectedErr: pgx.PgError{Code: "42601"},
+		},
+		"encode error": {
+			hosts:       []string{"a", "b"},
+			err:         pgx.SerializationError("cannot encode"),
+			expectedErr: pgx.SerializationError("cannot encode"),
+		},
+		"last healthy host is kept": {
+			hosts:       []string{"a"},
+			err:         connErr,
+			expectedErr: connErr,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestConnPoolRecovery(t *testing.T) {
+	pools := map[string]*fakePool{
+		"a": {host: "a"},
+		"b": {host: "b"},
+	}
+	configs, restore := withFakePools(t, pools)
+	defer restore()
+
+	// health checks disabled, unhealthy hosts are retried by pick
+	cp, err := newConnPool(configs[:2], LoadBalanceRoundRobin, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cp.Close()
+
+	now := time.Unix(1500000000, 0)
+	cp.now = func() time.Time { return now }
+
+	pools["a"].setErr(io.EOF)
+	if _, err := cp.ExecEx(context.Background(), "SELECT 1", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, healthy := cp.hosts[0].get(); healthy {
+		t.Fatalf("expected host a to be removed from rotation")
+	}
+
+	// the host recovers but isn't retried until the retry interval has passed
+	pools["a"].setErr(nil)
+	now = now.Add(cp.retryInterval / 2)
+	cp.pick()
+	cp.retries.Wait()
+	if _, healthy := cp.hosts[0].get(); healthy {
+		t.Fatalf("expected host a to not be retried before the retry interval")
+	}
+
+	now = now.Add(cp.retryInterval)
+	cp.pick()
+	cp.retries.Wait()
+	if _, healthy := cp.hosts[0].get(); !healthy {
+		t.Fatalf("expected host a to be added back to rotation")
+	}
+}
+
+func TestIsConnError(t *testing.T) {
+	type tcase struct {
+		err      error
+		expected bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		if got := isConnError(tc.err); got != tc.expected {
+			t.Errorf("expected %v got %v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"nil":              {},
+		"no rows":          {err: pgx.ErrNoRows},
+		"canceled":         {err: context.Canceled},
+		"deadline":         {err: context.DeadlineExceeded},
+		"acquire timeout":  {err: pgx.ErrAcquireTimeout},
+		"syntax error":     {err: pgx.PgError{Code: "42601"}},
+		"encode error":     {err: pgx.SerializationError("cannot encode")},
+		"other error":      {err: errors.New("cannot find encode plan")},
+		"network timeout":  {err: &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}},
+		"connection reset": {err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, expected: true},
+		"dead connection":  {err: pgx.ErrDeadConn, expected: true},
+		"eof":              {err: io.ErrUnexpectedEOF, expected: true},
+		"admin shutdown":   {err: &pgx.PgError{Code: "57P01"}, expected: true},
+		"connection failure": {
+			err:      pgx.PgError{Code: "08006"},
+			expected: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -159,3 +159,114 @@ func TestLayerGeomType(t *testing.T) {
 		t.Run(name, func(t *testing.T) { fn(t, tc) })
 	}
 }
+
+func TestLayerVariants(t *testing.T) {
+	type tcase struct {
+		variants []map[string]interface{}
+		// zoom to expected sql. an empty string means no variant covers the zoom
+		expected map[uint]string
+		err      string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		var configs []dict.Dicter
+		for i := range tc.variants {
+			configs = append(configs, dict.Dict(tc.variants[i]))
+		}
+
+		l := Layer{
+			name:      "roads",
+			idField:   "gid",
+			geomField: "geom",
+		}
+
+		var err error
+		l.variants, err = Provider{}.layerVariants(&l, configs)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("error, expected %v got %v", tc.err, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for z, expected := range tc.expected {
+			sql, ok := l.SQL(z)
+			if ok != (expected != "") || sql != expected {
+				t.Errorf("zoom %v, expected %q got %q (%v)", z, expected, sql, ok)
+			}
+		}
+	}
+
+	const (
+		lowSQL  = "SELECT gid, ST_AsBinary(geom) AS geom FROM roads_gen WHERE geom && !BBOX!"
+		highSQL = "SELECT gid, ST_AsBinary(geom) AS geom FROM roads WHERE geom && !BBOX!"
+	)
+
+	tests := map[string]tcase{
+		"low and high zoom": {
+			variants: []map[string]interface{}{
+				{ConfigKeyMaxZoom: 8, ConfigKeySQL: lowSQL},
+				{ConfigKeyMinZoom: 9, ConfigKeySQL: highSQL},
+			},
+			expected: map[uint]string{
+				0:  lowSQL,
+				8:  lowSQL,
+				9:  highSQL,
+				22: highSQL,
+			},
+		},
+		"zoom gap": {
+			variants: []map[string]interface{}{
+				{ConfigKeyMinZoom: 4, ConfigKeyMaxZoom: 8, ConfigKeySQL: lowSQL},
+				{ConfigKeyMinZoom: 12, ConfigKeySQL: highSQL},
+			},
+			expected: map[uint]string{
+				3:  "",
+				4:  lowSQL,
+				10: "",
+				12: highSQL,
+			},
+		},
+		"bbox token compatibility": {
+			variants: []map[string]interface{}{
+				{ConfigKeySQL: "SELECT gid, ST_AsBinary(geom) AS geom FROM roads WHERE geom && !bbox!"},
+			},
+			expected: map[uint]string{
+				5: highSQL,
+			},
+		},
+		"overlapping zooms": {
+			variants: []map[string]interface{}{
+				{ConfigKeyMaxZoom: 8, ConfigKeySQL: lowSQL},
+				{ConfigKeyMinZoom: 8, ConfigKeySQL: highSQL},
+			},
+			err: "overlap",
+		},
+		"min zoom greater than max zoom": {
+			variants: []map[string]interface{}{
+				{ConfigKeyMinZoom: 10, ConfigKeyMaxZoom: 8, ConfigKeySQL: lowSQL},
+			},
+			err: "is greater than",
+		},
+		"missing sql and tablename": {
+			variants: []map[string]interface{}{
+				{ConfigKeyMaxZoom: 8},
+			},
+			err: "must be defined",
+		},
+		"missing bbox token": {
+			variants: []map[string]interface{}{
+				{ConfigKeySQL: "SELECT gid, ST_AsBinary(geom) AS geom FROM roads"},
+			},
+			err: "missing required token",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
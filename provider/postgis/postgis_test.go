@@ -383,6 +383,37 @@ func TestTileFeatures(t *testing.T) {
 			tile:                 slippy.NewTile(1, 1, 1, 64, tegola.WebMercator),
 			expectedFeatureCount: 98,
 		},
+		"zoom variants low zoom": {
+			layerConfig: map[string]interface{}{
+				postgis.ConfigKeyLayerName: "land",
+				postgis.ConfigKeyVariants: []map[string]interface{}{
+					{
+						postgis.ConfigKeyMaxZoom: 1,
+						postgis.ConfigKeySQL:     "(SELECT gid, geom, featurecla FROM ne_10m_land_scale_rank LIMIT 100) AS sub",
+					},
+					{
+						postgis.ConfigKeyMinZoom:   2,
+						postgis.ConfigKeyTablename: "ne_10m_land_scale_rank",
+					},
+				},
+			},
+			tile:                 slippy.NewTile(1, 1, 1, 64, tegola.WebMercator),
+			expectedFeatureCount: 100,
+			expectedTags:         []string{"featurecla"},
+		},
+		"zoom variants zoom not covered": {
+			layerConfig: map[string]interface{}{
+				postgis.ConfigKeyLayerName: "land",
+				postgis.ConfigKeyVariants: []map[string]interface{}{
+					{
+						postgis.ConfigKeyMinZoom:   2,
+						postgis.ConfigKeyTablename: "ne_10m_land_scale_rank",
+					},
+				},
+			},
+			tile:                 slippy.NewTile(1, 1, 1, 64, tegola.WebMercator),
+			expectedFeatureCount: 0,
+		},
 		"SQL sub-query with token in SELECT": {
 			layerConfig: map[string]interface{}{
 				postgis.ConfigKeyLayerName: "land",
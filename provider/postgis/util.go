@@ -3,7 +3,6 @@ package postgis
 import (
 	"context"
 	"fmt"
-	"log"
 	"regexp"
 	"strconv"
 	"strings"
@@ -17,7 +16,7 @@ import (
 )
 
 // genSQL will fill in the SQL field of a layer given a pool, and list of fields.
-func genSQL(l *Layer, pool *pgx.ConnPool, tblname string, flds []string) (sql string, err error) {
+func genSQL(l *Layer, pool *connPool, tblname string, flds []string) (sql string, err error) {
 
 	// we need to hit the database to see what the fields are.
 	if len(flds) == 0 {
@@ -147,56 +146,14 @@ func uppercaseTokens(str string) string {
 	return tokenRe.ReplaceAllStringFunc(str, strings.ToUpper)
 }
 
-func transformVal(valType pgtype.OID, val interface{}) (interface{}, error) {
-	switch valType {
-	default:
-		switch vt := val.(type) {
-		default:
-			log.Printf("%v type is not supported. (Expected it to be a stringer type)", valType)
-			return nil, fmt.Errorf("%v type is not supported. (Expected it to be a stringer type)", valType)
-		case fmt.Stringer:
-			return vt.String(), nil
-		case string:
-			return vt, nil
-		}
-	case pgtype.BoolOID, pgtype.ByteaOID, pgtype.TextOID, pgtype.OIDOID, pgtype.VarcharOID, pgtype.JSONBOID:
-		return val, nil
-	case pgtype.Int8OID, pgtype.Int2OID, pgtype.Int4OID, pgtype.Float4OID, pgtype.Float8OID:
-		switch vt := val.(type) {
-		case int8:
-			return int64(vt), nil
-		case int16:
-			return int64(vt), nil
-		case int32:
-			return int64(vt), nil
-		case int64, uint64:
-			return vt, nil
-		case uint8:
-			return int64(vt), nil
-		case uint16:
-			return int64(vt), nil
-		case uint32:
-			return int64(vt), nil
-		case float32:
-			return float64(vt), nil
-		case float64:
-			return vt, nil
-		default: // should never happen.
-			return nil, fmt.Errorf("%v type is not supported. (should never happen)", valType)
-		}
-	case pgtype.DateOID, pgtype.TimestampOID, pgtype.TimestamptzOID:
-		return fmt.Sprintf("%v", val), nil
-	}
-}
-
-func decipherFields(ctx context.Context, geoFieldname, idFieldname string, descriptions []pgx.FieldDescription, values []interface{}) (gid uint64, geom []byte, tags map[string]interface{}, err error) {
+func decipherFields(ctx context.Context, geoFieldname, idFieldname string, format attributeFormat, descriptions []pgx.FieldDescription, values []interface{}) (gid interface{}, geom []byte, tags map[string]interface{}, err error) {
 	var ok bool
 	tags = make(map[string]interface{})
 
 	for i := range values {
 		// do a quick check
 		if err := ctx.Err(); err != nil {
-			return 0, nil, nil, err
+			return nil, nil, nil, err
 		}
 
 		// skip nil values.
@@ -209,10 +166,11 @@ func decipherFields(ctx context.Context, geoFieldname, idFieldname string, descr
 		switch desc.Name {
 		case geoFieldname:
 			if geom, ok = values[i].([]byte); !ok {
-				return 0, nil, nil, fmt.Errorf("unable to convert geometry field (%v) into bytes.", geoFieldname)
+				return nil, nil, nil, fmt.Errorf("unable to convert geometry field (%v) into bytes.", geoFieldname)
 			}
 		case idFieldname:
-			gid, err = gId(values[i])
+			// the id is assigned by the layer's id strategy
+			gid = values[i]
 		default:
 			switch vex := values[i].(type) {
 			case map[string]pgtype.Text:
@@ -222,18 +180,11 @@ func decipherFields(ctx context.Context, geoFieldname, idFieldname string, descr
 						tags[k] = v.String
 					}
 				}
-			case *pgtype.Numeric:
-				var num float64
-				vex.AssignTo(&num)
-
-				tags[desc.Name] = num
 			default:
-				value, err := transformVal(desc.DataType, values[i])
-				if err != nil {
-					return gid, geom, tags, fmt.Errorf("unable to convert field [%v] (%v) of type (%v - %v) to a suitable value: %+v", i, desc.Name, desc.DataType, desc.DataTypeName, values[i])
+				if err := format.setTags(tags, desc.Name, desc.DataType, values[i]); err != nil {
+					// skip the field rather than failing the whole tile
+					warnUnsupportedColumn(desc.Name, desc.DataType, err)
 				}
-
-				tags[desc.Name] = value
 			}
 		}
 	}
@@ -241,29 +192,3 @@ func decipherFields(ctx context.Context, geoFieldname, idFieldname string, descr
 	return gid, geom, tags, err
 }
 
-func gId(v interface{}) (gid uint64, err error) {
-	switch aval := v.(type) {
-	case float64:
-		return uint64(aval), nil
-	case int64:
-		return uint64(aval), nil
-	case uint64:
-		return aval, nil
-	case uint:
-		return uint64(aval), nil
-	case int8:
-		return uint64(aval), nil
-	case uint8:
-		return uint64(aval), nil
-	case uint16:
-		return uint64(aval), nil
-	case int32:
-		return uint64(aval), nil
-	case uint32:
-		return uint64(aval), nil
-	case string:
-		return strconv.ParseUint(aval, 10, 64)
-	default:
-		return gid, fmt.Errorf("unable to convert field into a uint64.")
-	}
-}
@@ -150,13 +150,13 @@ func TestDecipherFields(t *testing.T) {
 			idFieldname := "id"
 			descriptions := rows.FieldDescriptions()
 
-			vals, err := rows.Values()
+			vals, err := rowValues(rows)
 			if err != nil {
 				t.Errorf("unexepcted error reading row Values: %v", err)
 				return
 			}
 
-			_, _, tags, err := decipherFields(context.TODO(), geoFieldname, idFieldname, descriptions, vals)
+			_, _, tags, err := decipherFields(context.TODO(), geoFieldname, idFieldname, attributeFormat{}, descriptions, vals)
 			if err != nil {
 				t.Errorf("unexepcted error running decipherFileds: %v", err)
 				return
@@ -27,6 +27,13 @@ type Tiler interface {
 	Layers() ([]LayerInfo, error)
 }
 
+// Healther is an optional interface a Tiler can implement to report if it's
+// able to serve features. It's used by the server's readiness endpoint.
+type Healther interface {
+	// HealthCheck returns an error if the provider is unable to serve features
+	HealthCheck(ctx context.Context) error
+}
+
 type LayerInfo interface {
 	Name() string
 	GeomType() geom.Geometry
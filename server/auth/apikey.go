@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+)
+
+const (
+	DefaultAPIKeyHeader = "X-Api-Key"
+	DefaultAPIKeyParam  = "api_key"
+)
+
+// APIKey authenticates requests using static keys read from a request header
+// or a query parameter.
+type APIKey struct {
+	// Header is the request header to read the key from. Defaults to "X-Api-Key"
+	Header string
+	// Param is the query parameter to read the key from. Defaults to "api_key"
+	Param string
+	// Keys maps key ids to the secret key value
+	Keys map[string]string
+}
+
+func (a APIKey) header() string {
+	if a.Header == "" {
+		return DefaultAPIKeyHeader
+	}
+	return a.Header
+}
+
+func (a APIKey) param() string {
+	if a.Param == "" {
+		return DefaultAPIKeyParam
+	}
+	return a.Param
+}
+
+// Authenticate adheres to the Authenticator interface
+func (a APIKey) Authenticate(r *http.Request) (*Principal, error) {
+	var query url.Values
+
+	key := r.Header.Get(a.header())
+	if key == "" {
+		key = r.URL.Query().Get(a.param())
+		if key == "" {
+			return nil, ErrNoCredentials
+		}
+		query = url.Values{a.param(): []string{key}}
+	}
+
+	// compare against every key in constant time so the response time does
+	// not leak how much of a key matched
+	var id string
+	for k, v := range a.Keys {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(key)) == 1 {
+			id = k
+		}
+	}
+	if id == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Principal{
+		ID:    id,
+		Query: query,
+	}, nil
+}
+
+// Strip adheres to the Authenticator interface
+func (a APIKey) Strip(r *http.Request) {
+	r.Header.Del(a.header())
+	stripQuery(r, a.param())
+}
@@ -0,0 +1,182 @@
+/*
+Package auth provides pluggable request authentication for the tegola server.
+
+Three authenticators are supported and can be combined using a Chain:
+
+	APIKey - static keys read from a request header or query parameter
+	HMAC   - signed URLs which carry a key id, expiry and signature
+	JWT    - bearer tokens verified with a shared secret or a local JWKS file
+
+An authenticated request yields a Principal which is matched against the
+keys and claims a map allows.
+*/
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request does
+	// not carry the credentials the Authenticator handles
+	ErrNoCredentials = errors.New("auth: no credentials")
+	// ErrInvalidCredentials is returned when the credentials could not be verified
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+	// ErrExpired is returned when the credentials have expired
+	ErrExpired = errors.New("auth: credentials expired")
+)
+
+// Principal is an authenticated client
+type Principal struct {
+	// ID identifies the client. For API keys and HMAC signed URLs this is the
+	// configured key id. For JWTs this is the "sub" claim.
+	ID string
+	// Claims holds the verified JWT claims. nil for other authenticators.
+	Claims map[string]interface{}
+	// Query holds credentials which were passed as query parameters and can be
+	// carried over to URLs generated for the client (i.e. capabilities tile URLs)
+	Query url.Values
+}
+
+// QueryFor returns the credentials of Query which are valid for a request to path. HMAC
+// signatures are only valid for the paths their scope covers, so they are left out of URLs
+// outside of it and clients must sign those URLs themselves.
+func (p *Principal) QueryFor(path string) url.Values {
+	if len(p.Query) == 0 {
+		return nil
+	}
+	if p.Query.Get(HMACParamSignature) != "" && !ScopeCovers(p.Query.Get(HMACParamScope), path) {
+		return nil
+	}
+	return p.Query
+}
+
+// Allowed reports if the principal may access a resource restricted to the
+// provided key ids or claims. If both keys and claims are empty any principal
+// is allowed. Otherwise the principal is allowed if its ID is in keys or if it
+// holds every claim in claims.
+func (p *Principal) Allowed(keys []string, claims map[string]interface{}) bool {
+	if len(keys) == 0 && len(claims) == 0 {
+		return true
+	}
+
+	for i := range keys {
+		if keys[i] == p.ID {
+			return true
+		}
+	}
+
+	if len(claims) == 0 || p.Claims == nil {
+		return false
+	}
+
+	for k, want := range claims {
+		have, ok := p.Claims[k]
+		if !ok || !claimMatches(have, want) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// claimMatches compares a token claim against a configured value. If either
+// side is a list, a match on any element is sufficient.
+func claimMatches(have, want interface{}) bool {
+	switch w := want.(type) {
+	case []interface{}:
+		for i := range w {
+			if claimMatches(have, w[i]) {
+				return true
+			}
+		}
+		return false
+	case []string:
+		for i := range w {
+			if claimMatches(have, w[i]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if h, ok := have.([]interface{}); ok {
+		for i := range h {
+			if claimMatches(h[i], want) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return fmt.Sprint(have) == fmt.Sprint(want)
+}
+
+// Authenticator authenticates incoming requests
+type Authenticator interface {
+	// Authenticate returns the principal for the request. If the request does
+	// not carry credentials handled by the Authenticator ErrNoCredentials is returned.
+	Authenticate(r *http.Request) (*Principal, error)
+	// Strip removes the credentials handled by the Authenticator from the request
+	// so they don't leak into cache keys or logs.
+	Strip(r *http.Request)
+}
+
+// Chain tries each Authenticator in order and returns the first principal found
+type Chain []Authenticator
+
+// Authenticate adheres to the Authenticator interface. Credentials which are
+// present but invalid are reported immediately rather than falling through
+// to the next Authenticator.
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for i := range c {
+		p, err := c[i].Authenticate(r)
+		if err == ErrNoCredentials {
+			continue
+		}
+		return p, err
+	}
+
+	return nil, ErrNoCredentials
+}
+
+// Strip adheres to the Authenticator interface
+func (c Chain) Strip(r *http.Request) {
+	for i := range c {
+		c[i].Strip(r)
+	}
+}
+
+// stripQuery removes the given params from the request URL
+func stripQuery(r *http.Request, params ...string) {
+	if r.URL.RawQuery == "" {
+		return
+	}
+
+	query := r.URL.Query()
+	for i := range params {
+		query.Del(params[i])
+	}
+
+	r.URL.RawQuery = query.Encode()
+	r.RequestURI = r.URL.RequestURI()
+}
+
+type contextKey int
+
+const principalKey contextKey = 0
+
+// NewContext returns a copy of ctx carrying the principal
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// FromContext returns the principal stored in ctx, if any
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,319 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/server/auth"
+)
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func hs256Token(t *testing.T, secret string, claims map[string]interface{}) string {
+	signed := encodeSegment(t, map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func rs256Token(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	signed := encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid}) + "." + encodeSegment(t, claims)
+
+	h := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestAuthenticate(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	nowFn := func() time.Time { return now }
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks, err := auth.ParseJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"k1","use":"sig","n":"` +
+		base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()) + `","e":"AQAB"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator := auth.Chain{
+		auth.HMAC{
+			Keys: map[string]string{"partner": "signing-secret"},
+			Now:  nowFn,
+		},
+		auth.JWT{
+			Secret: []byte("jwt-secret"),
+			Keys:   jwks,
+			Now:    nowFn,
+		},
+		auth.APIKey{
+			Keys: map[string]string{"alice": "abc123"},
+		},
+	}
+
+	expires := now.Add(time.Hour)
+	signature := auth.Sign("signing-secret", "/maps/osm/", expires)
+	signedQuery := "key=partner&scope=/maps/osm/&expires=" + strconv.FormatInt(expires.Unix(), 10) + "&signature=" + signature
+
+	type tcase struct {
+		uri        string
+		header     map[string]string
+		expectedID string
+		expected   error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		r := httptest.NewRequest("GET", tc.uri, nil)
+		for k, v := range tc.header {
+			r.Header.Set(k, v)
+		}
+
+		p, err := authenticator.Authenticate(r)
+		if err != tc.expected {
+			t.Fatalf("error, expected %v got %v", tc.expected, err)
+		}
+		if err != nil {
+			return
+		}
+
+		if p.ID != tc.expectedID {
+			t.Errorf("principal id, expected %v got %v", tc.expectedID, p.ID)
+		}
+
+		// credentials must be removed after stripping
+		authenticator.Strip(r)
+		if r.URL.RawQuery != "" || r.Header.Get(auth.DefaultAPIKeyHeader) != "" || r.Header.Get("Authorization") != "" {
+			t.Errorf("credentials not stripped: %v %v", r.URL.RawQuery, r.Header)
+		}
+	}
+
+	tests := map[string]tcase{
+		"no credentials": {
+			uri:      "/maps/osm/1/1/1.pbf",
+			expected: auth.ErrNoCredentials,
+		},
+		"api key header": {
+			uri:        "/maps/osm/1/1/1.pbf",
+			header:     map[string]string{auth.DefaultAPIKeyHeader: "abc123"},
+			expectedID: "alice",
+		},
+		"api key param": {
+			uri:        "/maps/osm/1/1/1.pbf?api_key=abc123",
+			expectedID: "alice",
+		},
+		"api key invalid": {
+			uri:      "/maps/osm/1/1/1.pbf?api_key=nope",
+			expected: auth.ErrInvalidCredentials,
+		},
+		"hmac": {
+			uri:        "/maps/osm/1/1/1.pbf?" + signedQuery,
+			expectedID: "partner",
+		},
+		"hmac out of scope": {
+			uri:      "/maps/osm2/1/1/1.pbf?" + signedQuery,
+			expected: auth.ErrInvalidCredentials,
+		},
+		"hmac expired": {
+			uri: "/maps/osm/1/1/1.pbf?key=partner&expires=1400000000&signature=" +
+				auth.Sign("signing-secret", "/maps/osm/1/1/1.pbf", time.Unix(1400000000, 0)),
+			expected: auth.ErrExpired,
+		},
+		"jwt hs256": {
+			uri: "/maps/osm/1/1/1.pbf",
+			header: map[string]string{
+				"Authorization": "Bearer " + hs256Token(t, "jwt-secret", map[string]interface{}{"sub": "bob", "exp": now.Unix() + 60}),
+			},
+			expectedID: "bob",
+		},
+		"jwt hs256 param": {
+			uri:        "/maps/osm/1/1/1.pbf?access_token=" + hs256Token(t, "jwt-secret", map[string]interface{}{"sub": "bob"}),
+			expectedID: "bob",
+		},
+		"jwt wrong secret": {
+			uri: "/maps/osm/1/1/1.pbf",
+			header: map[string]string{
+				"Authorization": "Bearer " + hs256Token(t, "other", map[string]interface{}{"sub": "bob"}),
+			},
+			expected: auth.ErrInvalidCredentials,
+		},
+		"jwt expired": {
+			uri: "/maps/osm/1/1/1.pbf",
+			header: map[string]string{
+				"Authorization": "Bearer " + hs256Token(t, "jwt-secret", map[string]interface{}{"sub": "bob", "exp": now.Unix() - 60}),
+			},
+			expected: auth.ErrExpired,
+		},
+		"jwt rs256 jwks": {
+			uri: "/maps/osm/1/1/1.pbf",
+			header: map[string]string{
+				"Authorization": "Bearer " + rs256Token(t, rsaKey, "k1", map[string]interface{}{"sub": "carol"}),
+			},
+			expectedID: "carol",
+		},
+		"jwt rs256 unknown kid": {
+			uri: "/maps/osm/1/1/1.pbf",
+			header: map[string]string{
+				"Authorization": "Bearer " + rs256Token(t, rsaKey, "k2", map[string]interface{}{"sub": "carol"}),
+			},
+			expected: auth.ErrInvalidCredentials,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestPrincipalAllowed(t *testing.T) {
+	type tcase struct {
+		principal auth.Principal
+		keys      []string
+		claims    map[string]interface{}
+		expected  bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		if got := tc.principal.Allowed(tc.keys, tc.claims); got != tc.expected {
+			t.Errorf("expected %v got %v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"unrestricted": {
+			principal: auth.Principal{ID: "alice"},
+			expected:  true,
+		},
+		"key allowed": {
+			principal: auth.Principal{ID: "alice"},
+			keys:      []string{"bob", "alice"},
+			expected:  true,
+		},
+		"key denied": {
+			principal: auth.Principal{ID: "carol"},
+			keys:      []string{"bob", "alice"},
+			expected:  false,
+		},
+		"claim allowed": {
+			principal: auth.Principal{ID: "carol", Claims: map[string]interface{}{"role": "analyst"}},
+			claims:    map[string]interface{}{"role": "analyst"},
+			expected:  true,
+		},
+		"claim list allowed": {
+			principal: auth.Principal{ID: "carol", Claims: map[string]interface{}{"groups": []interface{}{"staff", "gis"}}},
+			claims:    map[string]interface{}{"groups": "gis"},
+			expected:  true,
+		},
+		"claim denied": {
+			principal: auth.Principal{ID: "carol", Claims: map[string]interface{}{"role": "guest"}},
+			claims:    map[string]interface{}{"role": []interface{}{"analyst", "admin"}},
+			expected:  false,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+// ensure a request handled by the chain with a stripped query keeps the rest of the query
+func TestStripKeepsOtherParams(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/maps/osm/1/1/1.pbf?debug=true&api_key=abc123", nil)
+
+	auth.APIKey{}.Strip(r)
+
+	if r.URL.RawQuery != "debug=true" {
+		t.Errorf("query, expected debug=true got %v", r.URL.RawQuery)
+	}
+}
+
+func TestPrincipalQueryFor(t *testing.T) {
+	type tcase struct {
+		query    url.Values
+		path     string
+		expected url.Values
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		p := auth.Principal{Query: tc.query}
+		if got := p.QueryFor(tc.path); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("query, expected %v got %v", tc.expected, got)
+		}
+	}
+
+	apiKey := url.Values{"api_key": {"abc"}}
+	signed := url.Values{
+		auth.HMACParamKey:       {"partner"},
+		auth.HMACParamExpires:   {"1600000000"},
+		auth.HMACParamScope:     {"/maps/osm/"},
+		auth.HMACParamSignature: {"ab12"},
+	}
+	signedPath := url.Values{
+		auth.HMACParamKey:       {"partner"},
+		auth.HMACParamExpires:   {"1600000000"},
+		auth.HMACParamScope:     {"/capabilities/osm.json"},
+		auth.HMACParamSignature: {"ab12"},
+	}
+
+	tests := map[string]tcase{
+		"no credentials": {
+			path: "/maps/osm/{z}/{x}/{y}.pbf",
+		},
+		"api key": {
+			query:    apiKey,
+			path:     "/maps/osm/{z}/{x}/{y}.pbf",
+			expected: apiKey,
+		},
+		"signature scope covers path": {
+			query:    signed,
+			path:     "/maps/osm/{z}/{x}/{y}.pbf",
+			expected: signed,
+		},
+		"signature scope of another map": {
+			query: signed,
+			path:  "/maps/osm2/{z}/{x}/{y}.pbf",
+		},
+		"signature of the capabilities path": {
+			query: signedPath,
+			path:  "/maps/osm/{z}/{x}/{y}.pbf",
+		},
+		"signature of the same path": {
+			query:    signedPath,
+			path:     "/capabilities/osm.json",
+			expected: signedPath,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
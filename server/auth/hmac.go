@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// query parameters used by HMAC signed URLs
+const (
+	HMACParamKey       = "key"
+	HMACParamExpires   = "expires"
+	HMACParamScope     = "scope"
+	HMACParamSignature = "signature"
+)
+
+// HMAC authenticates signed URLs. A signed URL carries the following query parameters:
+//
+//	key - the id of the signing key
+//	expires - the expiry as a unix timestamp
+//	scope - [Optional] the path prefix the signature grants access to. Defaults to the request path
+//	signature - the hex encoded HMAC-SHA256 of scope and expires, as produced by Sign
+//
+// A scope allows a single signature to cover a templated tile URL (i.e. "/maps/osm/").
+type HMAC struct {
+	// Keys maps key ids to signing secrets
+	Keys map[string]string
+	// Now returns the current time. Defaults to time.Now
+	Now func() time.Time
+}
+
+// Sign returns the signature for the scope and expiry using secret
+func Sign(secret, scope string, expires time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(scope + "\n" + strconv.FormatInt(expires.Unix(), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Authenticate adheres to the Authenticator interface
+func (a HMAC) Authenticate(r *http.Request) (*Principal, error) {
+	query := r.URL.Query()
+
+	sig := query.Get(HMACParamSignature)
+	if sig == "" {
+		return nil, ErrNoCredentials
+	}
+
+	secret, ok := a.Keys[query.Get(HMACParamKey)]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	unix, err := strconv.ParseInt(query.Get(HMACParamExpires), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	expires := time.Unix(unix, 0)
+
+	scope := r.URL.Path
+	if query.Get(HMACParamScope) != "" {
+		scope = query.Get(HMACParamScope)
+
+		if !ScopeCovers(scope, r.URL.Path) {
+			return nil, ErrInvalidCredentials
+		}
+	}
+
+	expected, err := hex.DecodeString(Sign(secret, scope, expires))
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(expected, got) {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	if now().After(expires) {
+		return nil, ErrExpired
+	}
+
+	credentials := url.Values{}
+	for _, k := range []string{HMACParamKey, HMACParamExpires, HMACParamSignature} {
+		credentials.Set(k, query.Get(k))
+	}
+	// the scope the signature was computed over, so QueryFor can tell which paths it's valid for
+	credentials.Set(HMACParamScope, scope)
+
+	return &Principal{
+		ID:    query.Get(HMACParamKey),
+		Query: credentials,
+	}, nil
+}
+
+// ScopeCovers reports if a signature scope grants access to the path. A scope must be the full
+// path or a directory prefix of the path so a scope of "/maps/osm" does not grant access to "/maps/osm2"
+func ScopeCovers(scope, path string) bool {
+	return scope == path || (strings.HasSuffix(scope, "/") && strings.HasPrefix(path, scope))
+}
+
+// Strip adheres to the Authenticator interface
+func (a HMAC) Strip(r *http.Request) {
+	stripQuery(r, HMACParamKey, HMACParamExpires, HMACParamScope, HMACParamSignature)
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultJWTParam = "access_token"
+)
+
+// JWT authenticates requests carrying a JSON Web Token in the Authorization
+// header (as a bearer token) or in a query parameter. HMAC signed tokens
+// (HS256, HS384, HS512) are verified using Secret. RSA (RS256, RS384, RS512)
+// and ECDSA (ES256, ES384, ES512) signed tokens are verified using Keys.
+type JWT struct {
+	// Secret is the shared secret for HMAC signed tokens
+	Secret []byte
+	// Keys maps a key id (the "kid" token header) to a public key. Typically loaded with LoadJWKS.
+	Keys map[string]crypto.PublicKey
+	// Param is the query parameter to read the token from. Defaults to "access_token"
+	Param string
+	// Now returns the current time. Defaults to time.Now
+	Now func() time.Time
+}
+
+func (a JWT) param() string {
+	if a.Param == "" {
+		return DefaultJWTParam
+	}
+	return a.Param
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Authenticate adheres to the Authenticator interface
+func (a JWT) Authenticate(r *http.Request) (*Principal, error) {
+	var query url.Values
+
+	var token string
+	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		token = strings.TrimSpace(h[7:])
+	}
+	if token == "" {
+		token = r.URL.Query().Get(a.param())
+		if token == "" {
+			return nil, ErrNoCredentials
+		}
+		query = url.Values{a.param(): []string{token}}
+	}
+
+	claims, err := a.verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	id, _ := claims["sub"].(string)
+
+	return &Principal{
+		ID:     id,
+		Claims: claims,
+		Query:  query,
+	}, nil
+}
+
+// Strip adheres to the Authenticator interface
+func (a JWT) Strip(r *http.Request) {
+	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		r.Header.Del("Authorization")
+	}
+	stripQuery(r, a.param())
+}
+
+// verify checks the token signature and the "exp" and "nbf" claims and returns the token claims
+func (a JWT) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidCredentials
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := a.verifySignature(header, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	t := now().Unix()
+
+	if exp, ok := claims["exp"].(float64); ok && t >= int64(exp) {
+		return nil, ErrExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && t < int64(nbf) {
+		return nil, ErrInvalidCredentials
+	}
+
+	return claims, nil
+}
+
+func (a JWT) verifySignature(header jwtHeader, signed string, sig []byte) error {
+	var hashFn func() hash.Hash
+	var cryptoHash crypto.Hash
+
+	if len(header.Alg) != 5 {
+		return ErrInvalidCredentials
+	}
+
+	switch header.Alg[len(header.Alg)-3:] {
+	case "256":
+		hashFn, cryptoHash = sha256.New, crypto.SHA256
+	case "384":
+		hashFn, cryptoHash = sha512.New384, crypto.SHA384
+	case "512":
+		hashFn, cryptoHash = sha512.New, crypto.SHA512
+	default:
+		return ErrInvalidCredentials
+	}
+
+	switch header.Alg {
+	case "HS256", "HS384", "HS512":
+		if len(a.Secret) == 0 {
+			return ErrInvalidCredentials
+		}
+
+		mac := hmac.New(hashFn, a.Secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return ErrInvalidCredentials
+		}
+
+		return nil
+
+	case "RS256", "RS384", "RS512":
+		key, ok := a.Keys[header.Kid].(*rsa.PublicKey)
+		if !ok {
+			return ErrInvalidCredentials
+		}
+
+		h := hashFn()
+		h.Write([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, cryptoHash, h.Sum(nil), sig); err != nil {
+			return ErrInvalidCredentials
+		}
+
+		return nil
+
+	case "ES256", "ES384", "ES512":
+		key, ok := a.Keys[header.Kid].(*ecdsa.PublicKey)
+		if !ok || len(sig)%2 != 0 {
+			return ErrInvalidCredentials
+		}
+
+		h := hashFn()
+		h.Write([]byte(signed))
+
+		// the signature is the concatenation of the r and s values
+		r := new(big.Int).SetBytes(sig[:len(sig)/2])
+		s := new(big.Int).SetBytes(sig[len(sig)/2:])
+		if !ecdsa.Verify(key, h.Sum(nil), r, s) {
+			return ErrInvalidCredentials
+		}
+
+		return nil
+
+	default:
+		// this includes the "none" algorithm
+		return ErrInvalidCredentials
+	}
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(seg, "="))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("auth: error decoding token segment: %v", err)
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-spatial/geom"
 	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/internal/log"
 )
 
 type Capabilities struct {
@@ -42,14 +43,31 @@ func (req HandleCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	// parse our query string
 	var query = r.URL.Query()
 
+	maps := atlas.AllMaps()
+	// composite map aliases are described like maps
+	for _, c := range atlas.AllComposites() {
+		if !compositeAllowed(r, c) {
+			continue
+		}
+		cm, err := lookupMap(nil, c.Name)
+		if err != nil {
+			log.Errorf("composite map (%v) is invalid: %v", c.Name, err)
+			continue
+		}
+		maps = append(maps, cm)
+	}
+
 	// iterate our registered maps
-	for _, m := range atlas.AllMaps() {
-		var debugQuery string
+	for _, m := range maps {
+		// skip maps the client is not allowed to access
+		if !mapAllowed(r, m) {
+			continue
+		}
 
-		// if we have a debug param add it to our URLs
-		if query.Get("debug") == "true" {
-			debugQuery = "?debug=true"
+		debug := query.Get("debug") == "true"
 
+		// if we have a debug param add it to our URLs
+		if debug {
 			// update our map to include the debug layers
 			m = m.AddDebugLayers()
 		}
@@ -61,9 +79,9 @@ func (req HandleCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Request)
 			Bounds:      m.Bounds,
 			Center:      m.Center,
 			Tiles: []string{
-				fmt.Sprintf("%v/maps/%v/{z}/{x}/{y}.pbf%v", URLRoot(r), m.Name, debugQuery),
+				generatedURL(r, debug, fmt.Sprintf("/maps/%v/{z}/{x}/{y}.pbf", m.Name)),
 			},
-			Capabilities: fmt.Sprintf("%v/capabilities/%v.json%v", URLRoot(r), m.Name, debugQuery),
+			Capabilities: generatedURL(r, debug, fmt.Sprintf("/capabilities/%v.json", m.Name)),
 		}
 
 		for i := range m.Layers {
@@ -94,7 +112,7 @@ func (req HandleCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Request)
 			cLayer := CapabilitiesLayer{
 				Name: m.Layers[i].MVTName(),
 				Tiles: []string{
-					fmt.Sprintf("%v/maps/%v/%v/{z}/{x}/{y}.pbf%v", URLRoot(r), m.Name, m.Layers[i].MVTName(), debugQuery),
+					generatedURL(r, debug, fmt.Sprintf("/maps/%v/%v/{z}/{x}/{y}.pbf", m.Name, m.Layers[i].MVTName())),
 				},
 				MinZoom: m.Layers[i].MinZoom,
 				MaxZoom: m.Layers[i].MaxZoom,
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/provider"
+)
+
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+var (
+	// Providers is the map of registered providers keyed by provider name. Providers
+	// which implement provider.Healther are checked by the readiness endpoint.
+	// set in main.go
+	Providers map[string]provider.Tiler
+
+	// ReadinessTimeout is the max amount of time the readiness checks are allowed to run
+	ReadinessTimeout = 5 * time.Second
+)
+
+// healthCheckKey is the cache key used for the readiness cache canary
+var healthCheckKey = cache.Key{
+	MapName:   "tegola-health-check",
+	LayerName: "canary",
+	Z:         0,
+	X:         0,
+	Y:         0,
+}
+
+// healthCheckData is a gzip encoded payload written to the cache during readiness checks
+var healthCheckData = []byte{0x1f, 0x8b, 0x8, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xff, 0x2a, 0xce, 0xcc, 0x49, 0x2c, 0x6, 0x4, 0x0, 0x0, 0xff, 0xff, 0xaf, 0x9d, 0x59, 0xca, 0x5, 0x0, 0x0, 0x0}
+
+type Health struct {
+	Status     string            `json:"status"`
+	Components []HealthComponent `json:"components,omitempty"`
+}
+
+type HealthComponent struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HandleHealth reports that the process is up and able to respond to requests.
+type HandleHealth struct{}
+
+func (req HandleHealth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, Health{Status: HealthStatusOK})
+}
+
+// HandleReadiness checks each registered provider implementing provider.Healther
+// and the cache backend (if configured). If any component fails a 503 is returned.
+type HandleReadiness struct {
+	// the Atlas to use, nil (default) is the default atlas
+	Atlas *atlas.Atlas
+}
+
+// readinessCheck is a named check run by the readiness endpoint
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (req HandleReadiness) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), ReadinessTimeout)
+	defer cancel()
+
+	var checks []readinessCheck
+
+	for name, p := range Providers {
+		healther, ok := p.(provider.Healther)
+		if !ok {
+			continue
+		}
+
+		checks = append(checks, readinessCheck{
+			name:  "provider:" + name,
+			check: healther.HealthCheck,
+		})
+	}
+
+	if cacher := req.Atlas.GetCache(); cacher != nil {
+		checks = append(checks, readinessCheck{
+			name: "cache",
+			check: func(ctx context.Context) error {
+				return checkCache(ctx, cacher)
+			},
+		})
+	}
+
+	health := Health{
+		Status:     HealthStatusOK,
+		Components: make([]HealthComponent, len(checks)),
+	}
+
+	// run the checks concurrently so a slow component does not delay the others
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+	for i := range checks {
+		go func(i int) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := runCheck(ctx, checks[i].check)
+
+			component := HealthComponent{
+				Name:      checks[i].name,
+				Status:    HealthStatusOK,
+				LatencyMS: float64(time.Since(start)) / float64(time.Millisecond),
+			}
+			if err != nil {
+				component.Status = HealthStatusFail
+				component.Error = err.Error()
+			}
+
+			health.Components[i] = component
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Slice(health.Components, func(i, j int) bool {
+		return health.Components[i].Name < health.Components[j].Name
+	})
+
+	status := http.StatusOK
+	for i := range health.Components {
+		if health.Components[i].Status != HealthStatusOK {
+			log.Warnf("readiness check for (%v) failed: %v", health.Components[i].Name, health.Components[i].Error)
+			health.Status = HealthStatusFail
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	writeHealth(w, status, health)
+}
+
+// runCheck runs the check and returns early if the context is done before the check completes
+func runCheck(ctx context.Context, check func(ctx context.Context) error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check(ctx)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// checkCache confirms the cache backend can be written to and read from
+// by setting and then getting a small canary tile
+func checkCache(ctx context.Context, cacher cache.Interface) error {
+	key := healthCheckKey
+
+	if err := cacher.Set(&key, healthCheckData); err != nil {
+		return fmt.Errorf("error setting canary: %v", err)
+	}
+
+	val, hit, err := cacher.Get(&key)
+	if err != nil {
+		return fmt.Errorf("error getting canary: %v", err)
+	}
+	if !hit {
+		return fmt.Errorf("canary missing from cache")
+	}
+	if !bytes.Equal(val, healthCheckData) {
+		return fmt.Errorf("canary read from cache does not match canary written")
+	}
+
+	return nil
+}
+
+func writeHealth(w http.ResponseWriter, status int, health Health) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// health responses should never be cached
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(health)
+}
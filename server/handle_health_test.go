@@ -0,0 +1,136 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/test"
+	"github.com/go-spatial/tegola/server"
+)
+
+// healthProvider is a test provider which implements provider.Healther
+type healthProvider struct {
+	test.TileProvider
+	err error
+}
+
+func (p *healthProvider) HealthCheck(ctx context.Context) error { return p.err }
+
+// failingCache is a cache which errors on every Set
+type failingCache struct{}
+
+func (failingCache) Get(key *cache.Key) ([]byte, bool, error) { return nil, false, nil }
+func (failingCache) Set(key *cache.Key, val []byte) error     { return errors.New("read only") }
+func (failingCache) Purge(key *cache.Key) error               { return nil }
+
+func newMemoryCache() cache.Interface {
+	c, _ := memory.New(nil)
+	return c
+}
+
+func TestHandleReadiness(t *testing.T) {
+	type tcase struct {
+		providers      map[string]provider.Tiler
+		cache          cache.Interface
+		expectedCode   int
+		expectedStatus map[string]string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		server.Providers = tc.providers
+		defer func() { server.Providers = nil }()
+
+		a := &atlas.Atlas{}
+		a.SetCache(tc.cache)
+
+		w, _, err := doRequest(a, "GET", "http://localhost:8080/readyz", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("status code, expected %v got %v", tc.expectedCode, w.Code)
+		}
+
+		var health server.Health
+		if err := json.NewDecoder(w.Body).Decode(&health); err != nil {
+			t.Fatalf("error unmarshal JSON, expected nil got %v", err)
+		}
+
+		if len(health.Components) != len(tc.expectedStatus) {
+			t.Fatalf("components, expected %v got %v", len(tc.expectedStatus), len(health.Components))
+		}
+
+		for _, c := range health.Components {
+			if c.Status != tc.expectedStatus[c.Name] {
+				t.Errorf("component (%v) status, expected %v got %v", c.Name, tc.expectedStatus[c.Name], c.Status)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"no components": {
+			expectedCode:   http.StatusOK,
+			expectedStatus: map[string]string{},
+		},
+		"provider without health check": {
+			providers: map[string]provider.Tiler{
+				"test": &test.TileProvider{},
+			},
+			expectedCode:   http.StatusOK,
+			expectedStatus: map[string]string{},
+		},
+		"healthy": {
+			providers: map[string]provider.Tiler{
+				"db": &healthProvider{},
+			},
+			cache:        newMemoryCache(),
+			expectedCode: http.StatusOK,
+			expectedStatus: map[string]string{
+				"provider:db": server.HealthStatusOK,
+				"cache":       server.HealthStatusOK,
+			},
+		},
+		"provider failure": {
+			providers: map[string]provider.Tiler{
+				"db":  &healthProvider{err: errors.New("connection refused")},
+				"db2": &healthProvider{},
+			},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedStatus: map[string]string{
+				"provider:db":  server.HealthStatusFail,
+				"provider:db2": server.HealthStatusOK,
+			},
+		},
+		"cache failure": {
+			cache:        failingCache{},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedStatus: map[string]string{
+				"cache": server.HealthStatusFail,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestHandleHealth(t *testing.T) {
+	w, _, err := doRequest(nil, "GET", "http://localhost:8080/healthz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status code, expected %v got %v", http.StatusOK, w.Code)
+	}
+}
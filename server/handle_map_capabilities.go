@@ -10,7 +10,7 @@ import (
 	"github.com/dimfeld/httptreemux"
 
 	"github.com/go-spatial/geom"
-	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
 	"github.com/go-spatial/tegola/mapbox/tilejson"
 )
 
@@ -26,6 +26,7 @@ type HandleMapCapabilities struct {
 //
 // URI scheme: /capabilities/:map_name.json
 // map_name - map name in the config file
+// the layers query string (i.e. ?layers=roads,water) limits the tiles to a list of layers
 func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	params := httptreemux.ContextParams(r.Context())
@@ -43,7 +44,7 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	}
 
 	// lookup our Map
-	m, err := atlas.GetMap(req.mapName)
+	m, err := lookupMap(nil, req.mapName)
 	if err != nil {
 		log.Printf("map (%v) not configured. check your config file", req.mapName)
 		http.Error(w, "map ("+req.mapName+") not configured. check your config file", http.StatusBadRequest)
@@ -66,11 +67,22 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	// parse our query string
 	var query = r.URL.Query()
 
-	var debugQuery string
-	// if we have a debug param add it to our URLs
-	if query.Get("debug") == "true" {
-		debugQuery = "?debug=true"
+	// a comma separated list of layers (i.e. roads,water) describes the tiles of those layers
+	var layerNames []string
+	if layers := query.Get("layers"); layers != "" {
+		layerNames = cache.SplitLayerNames(layers)
+		for _, name := range layerNames {
+			if !m.HasLayer(name) {
+				http.Error(w, "map ("+req.mapName+") has no layer named ("+name+")", http.StatusBadRequest)
+				return
+			}
+		}
+		m = m.FilterLayersByName(layerNames...)
+	}
 
+	debug := query.Get("debug") == "true"
+	// if we have a debug param add it to our URLs
+	if debug {
 		// update our map to include the debug layers
 		m = m.AddDebugLayers()
 	}
@@ -125,7 +137,7 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 			MinZoom: m.Layers[i].MinZoom,
 			MaxZoom: m.Layers[i].MaxZoom,
 			Tiles: []string{
-				fmt.Sprintf("%v/maps/%v/%v/{z}/{x}/{y}.pbf%v", URLRoot(r), req.mapName, m.Layers[i].MVTName(), debugQuery),
+				generatedURL(r, debug, fmt.Sprintf("/maps/%v/%v/{z}/{x}/{y}.pbf", req.mapName, m.Layers[i].MVTName())),
 			},
 		}
 
@@ -145,7 +157,10 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		tileJSON.VectorLayers = append(tileJSON.VectorLayers, layer)
 	}
 
-	tileURL := fmt.Sprintf("%v/maps/%v/{z}/{x}/{y}.pbf%v", URLRoot(r), req.mapName, debugQuery)
+	tileURL := generatedURL(r, debug, fmt.Sprintf("/maps/%v/{z}/{x}/{y}.pbf", req.mapName))
+	if len(layerNames) > 0 {
+		tileURL = generatedURL(r, debug, fmt.Sprintf("/maps/%v/%v/{z}/{x}/{y}.pbf", req.mapName, strings.Join(layerNames, ",")))
+	}
 
 	// build our URL scheme for the tile grid
 	tileJSON.Tiles = append(tileJSON.Tiles, tileURL)
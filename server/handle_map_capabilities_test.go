@@ -153,6 +153,46 @@ func TestHandleMapCapabilities(t *testing.T) {
 				},
 			},
 		},
+		{
+			handler:   server.HandleCapabilities{},
+			hostName:  "",
+			uri:       "http://localhost:8080/capabilities/test-map.json?layers=test-layer-2-name",
+			reqMethod: "GET",
+			expected: tilejson.TileJSON{
+				Attribution: &testMapAttribution,
+				Bounds:      [4]float64{-180.0, -85.0511, 180.0, 85.0511},
+				Center:      testMapCenter,
+				Format:      "pbf",
+				MinZoom:     testLayer2.MinZoom,
+				MaxZoom:     testLayer2.MaxZoom,
+				Name:        &testMapName,
+				Description: nil,
+				Scheme:      tilejson.SchemeXYZ,
+				TileJSON:    tilejson.Version,
+				Tiles: []string{
+					fmt.Sprintf("http://localhost:8080/maps/test-map/%v/{z}/{x}/{y}.pbf", testLayer2.MVTName()),
+				},
+				Grids:    []string{},
+				Data:     []string{},
+				Version:  "1.0.0",
+				Template: nil,
+				Legend:   nil,
+				VectorLayers: []tilejson.VectorLayer{
+					{
+						Version:      2,
+						Extent:       4096,
+						ID:           testLayer2.MVTName(),
+						Name:         testLayer2.MVTName(),
+						GeometryType: tilejson.GeomTypeLine,
+						MinZoom:      testLayer2.MinZoom,
+						MaxZoom:      testLayer2.MaxZoom,
+						Tiles: []string{
+							fmt.Sprintf("http://localhost:8080/maps/test-map/%v/{z}/{x}/{y}.pbf", testLayer2.MVTName()),
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for i, test := range testcases {
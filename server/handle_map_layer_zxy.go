@@ -13,9 +13,11 @@ import (
 	"github.com/go-spatial/geom/slippy"
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
 	"github.com/go-spatial/tegola/internal/log"
 	"github.com/go-spatial/tegola/maths"
 	"github.com/go-spatial/tegola/mvt"
+	"github.com/go-spatial/tegola/provider"
 )
 
 type HandleMapLayerZXY struct {
@@ -23,15 +25,19 @@ type HandleMapLayerZXY struct {
 	mapName string
 	// optional
 	layerName string
+	// the layer names of a comma separated layerName (i.e. roads,water)
+	layerNames []string
 	// zoom
 	z uint
 	// row
 	x uint
 	// column
 	y uint
-	// the requests extension (i.e. pbf or json)
+	// the requests extension (i.e. pbf or png)
 	// defaults to "pbf"
 	extension string
+	// the size in pixels of png tiles
+	tileSize int
 	// debug
 	debug bool
 	// the Atlas to use, nil (default) is the default atlas
@@ -47,6 +53,12 @@ func (req *HandleMapLayerZXY) parseURI(r *http.Request) error {
 	// set map name
 	req.mapName = params["map_name"]
 	req.layerName = params["layer_name"]
+	if req.layerName != "" {
+		req.layerNames = cache.SplitLayerNames(req.layerName)
+		if len(req.layerNames) == 0 {
+			return fmt.Errorf("invalid layer name (%v)", req.layerName)
+		}
+	}
 
 	var placeholder uint64
 
@@ -71,6 +83,21 @@ func (req *HandleMapLayerZXY) parseURI(r *http.Request) error {
 	// trim the "y" param in the url in case it has an extension
 	y := params["y"]
 	yParts := strings.Split(y, ".")
+
+	// check if we have a file extension
+	if len(yParts) > 1 {
+		req.extension = yParts[len(yParts)-1]
+	} else {
+		req.extension = "pbf"
+	}
+
+	// png tiles twice the size are requested with a @2x suffix (i.e. 3@2x.png)
+	req.tileSize = RasterTileSize
+	if req.extension == "png" && strings.HasSuffix(yParts[0], "@2x") {
+		yParts[0] = strings.TrimSuffix(yParts[0], "@2x")
+		req.tileSize = 2 * RasterTileSize
+	}
+
 	placeholder, err = strconv.ParseUint(yParts[0], 10, 32)
 	if err != nil || placeholder > maxXYatZ {
 		log.Warnf("invalid Y value (%v)", yParts[0])
@@ -79,13 +106,6 @@ func (req *HandleMapLayerZXY) parseURI(r *http.Request) error {
 
 	req.y = uint(placeholder)
 
-	// check if we have a file extension
-	if len(yParts) > 2 {
-		req.extension = yParts[len(yParts)-1]
-	} else {
-		req.extension = "pbf"
-	}
-
 	// check for debug request
 	if r.URL.Query().Get("debug") == "true" {
 		req.debug = true
@@ -102,11 +122,13 @@ func logAndError(w http.ResponseWriter, code int, format string, vals ...interfa
 
 // URI scheme: /maps/:map_name/:layer_name/:z/:x/:y
 // map_name - map name in the config file
-// layer_name - name of the single map layer to render
+// layer_name - name of the map layer to render, or a comma separated list of layer names (i.e. roads,water)
 // z, x, y - tile coordinates as described in the Slippy Map Tilenames specification
 // 	z - zoom level
 // 	x - row
 // 	y - column
+// the y may have a .png extension for a png tile, or @2x.png for a png tile twice the size.
+// a .svg extension draws the tile for debugging, with the encoding stages of the features if stages=true
 func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// parse our URI
 	if err := req.parseURI(r); err != nil {
@@ -116,11 +138,31 @@ func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// lookup our Map
 	m, err := req.Atlas.Map(req.mapName)
+	// the map may be a composite of several maps
+	var composite *atlas.Composite
 	if err != nil {
-		errMsg := fmt.Sprintf("map (%v) not configured. check your config file", req.mapName)
-		log.Errorf(errMsg)
-		http.Error(w, errMsg, http.StatusNotFound)
-		return
+		c, cerr := req.Atlas.Composite(req.mapName)
+		if cerr != nil {
+			errMsg := fmt.Sprintf("map (%v) not configured. check your config file", req.mapName)
+			log.Errorf(errMsg)
+			http.Error(w, errMsg, http.StatusNotFound)
+			return
+		}
+
+		// the composite is described by a map of its layers for the checks below
+		if m, err = req.Atlas.CompositeMap(c); err != nil {
+			logAndError(w, http.StatusBadRequest, "composite map (%v) is invalid: %v", req.mapName, err)
+			return
+		}
+		composite = &c
+	}
+
+	// layers which are not in the map at any zoom are a bad request
+	for _, name := range req.layerNames {
+		if !m.HasLayer(name) {
+			logAndError(w, http.StatusBadRequest, "map (%v) has no layer named (%v)", req.mapName, name)
+			return
+		}
 	}
 
 	// filter down the layers we need for this zoom
@@ -131,7 +173,7 @@ func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.layerName != "" {
-		m = m.FilterLayersByName(req.layerName)
+		m = m.FilterLayersByName(req.layerNames...)
 		if len(m.Layers) == 0 {
 			logAndError(w, http.StatusNotFound, "map (%v) has no layers, for LayerName %v at zoom %v", req.mapName, req.layerName, req.z)
 			return
@@ -154,13 +196,56 @@ func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		m = m.AddDebugLayers()
 	}
 
-	pbyte, err := m.Encode(r.Context(), tile)
+	// wait for a render slot. cache hits are served before this handler so they bypass the limit
+	if RenderLimit != nil {
+		release, err := RenderLimit.Acquire(r.Context())
+		if err != nil {
+			switch err {
+			case context.Canceled:
+				return
+			default:
+				w.Header().Set("Retry-After", retryAfter(RenderLimit.RetryAfter()))
+				logAndError(w, http.StatusServiceUnavailable, "tile z:%v, x:%v, y:%v not rendered: %v", req.z, req.x, req.y, err)
+				return
+			}
+		}
+		defer release()
+	}
+
+	var (
+		pbyte       []byte
+		budgetSteps []string
+	)
+	if composite != nil {
+		// the composite's tile is merged from the tiles of its maps
+		var layerNames []string
+		if req.layerName != "" {
+			for i := range m.Layers {
+				layerNames = append(layerNames, m.Layers[i].MVTName())
+				if m.Layers[i].LabelPoints != nil {
+					layerNames = append(layerNames, m.Layers[i].LabelPoints.LayerName(&m.Layers[i]))
+				}
+			}
+		}
+		pbyte, err = req.Atlas.EncodeComposite(r.Context(), *composite, tile, req.debug, layerNames...)
+	} else {
+		pbyte, budgetSteps, err = m.EncodeBudgeted(r.Context(), tile)
+	}
 	if err != nil {
 		switch err {
 		case context.Canceled:
 			// TODO: add debug logs
 			return
 		default:
+			if _, ok := err.(provider.ErrQueryTimeout); ok {
+				logAndError(w, http.StatusGatewayTimeout, "tile z:%v, x:%v, y:%v not rendered: %v", req.z, req.x, req.y, err)
+				return
+			}
+			if _, ok := err.(atlas.ErrCompositeLayerCollision); ok {
+				logAndError(w, http.StatusBadRequest, "tile z:%v, x:%v, y:%v not rendered: %v", req.z, req.x, req.y, err)
+				return
+			}
+
 			errMsg := fmt.Sprintf("error marshalling tile: %v", err)
 			log.Error(errMsg)
 			http.Error(w, errMsg, http.StatusInternalServerError)
@@ -168,9 +253,43 @@ func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// mimetype for mapbox vector tiles
-	// https://www.iana.org/assignments/media-types/application/vnd.mapbox-vector-tile
-	w.Header().Add("Content-Type", mvt.MimeType)
+	// draw the features of the tile
+	switch req.extension {
+	case "png":
+		if pbyte, err = renderPNG(m, pbyte, req.tileSize); err != nil {
+			errMsg := fmt.Sprintf("error rendering png tile: %v", err)
+			log.Error(errMsg)
+			http.Error(w, errMsg, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Add("Content-Type", PNGMimeType)
+	case "svg":
+		// the encoding stages of the features are drawn under the tile
+		var stages []atlas.LayerStages
+		if r.URL.Query().Get("stages") == "true" {
+			if stages, err = m.EncodeStages(r.Context(), tile); err != nil {
+				errMsg := fmt.Sprintf("error encoding the stages of the tile: %v", err)
+				log.Error(errMsg)
+				http.Error(w, errMsg, http.StatusInternalServerError)
+				return
+			}
+		}
+		if pbyte, err = renderSVG(m, pbyte, stages); err != nil {
+			errMsg := fmt.Sprintf("error rendering svg tile: %v", err)
+			log.Error(errMsg)
+			http.Error(w, errMsg, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Add("Content-Type", SVGMimeType)
+	default:
+		// mimetype for mapbox vector tiles
+		// https://www.iana.org/assignments/media-types/application/vnd.mapbox-vector-tile
+		w.Header().Add("Content-Type", mvt.MimeType)
+	}
+	// report how the tile was degraded to fit the map's max_tile_bytes
+	if len(budgetSteps) > 0 {
+		w.Header().Add(TileBudgetHeader, strings.Join(budgetSteps, ", "))
+	}
 	w.Header().Add("Content-Length", fmt.Sprintf("%d", len(pbyte)))
 	w.WriteHeader(http.StatusOK)
 	w.Write(pbyte)
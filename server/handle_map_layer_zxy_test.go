@@ -1,6 +1,7 @@
 package server_test
 
 import (
+	"image/png"
 	"io/ioutil"
 	"net/http"
 	"reflect"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/mvt/vector_tile"
+	"github.com/go-spatial/tegola/server"
 )
 
 type MapHandlerTCase struct {
@@ -94,6 +96,21 @@ func TestHandleMapLayerZXY(t *testing.T) {
 			expectedCode:   http.StatusOK,
 			expectedLayers: []string{"test-layer", "debug-tile-outline", "debug-tile-center"},
 		},
+		"layer list": {
+			uri:            "/maps/test-map/test-layer-2-name,test-layer/10/2/3.pbf",
+			expectedCode:   http.StatusOK,
+			expectedLayers: []string{"test-layer-2-name", "test-layer"},
+		},
+		"layer name prefix not matched": {
+			uri:          "/maps/test-map/test-layer-2/10/2/3.pbf",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "map (test-map) has no layer named (test-layer-2)",
+		},
+		"unknown layer in list": {
+			uri:          "/maps/test-map/test-layer,water/10/2/3.pbf",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "map (test-map) has no layer named (water)",
+		},
 		"neg row(y) not allowed issue-229": {
 			uri:          "/maps/test-map/test-layer/1/1/-1.pbf",
 			expectedCode: http.StatusBadRequest,
@@ -206,3 +223,106 @@ func TestHandleMapLayerCORS(t *testing.T) {
 		t.Run(name, func(t *testing.T) { CORSTest(t, tc) })
 	}
 }
+
+func TestHandleMapPNG(t *testing.T) {
+	type tcase struct {
+		uri          string
+		expectedSize int
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		w, _, err := doRequest(nil, "GET", tc.uri, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status code, expected %v got %v: %v", http.StatusOK, w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != server.PNGMimeType {
+			t.Errorf("content type, expected %v got %v", server.PNGMimeType, ct)
+		}
+
+		img, err := png.Decode(w.Body)
+		if err != nil {
+			t.Fatalf("decoding png, expected nil got %v", err)
+		}
+
+		if size := img.Bounds().Dx(); size != tc.expectedSize || img.Bounds().Dy() != tc.expectedSize {
+			t.Errorf("size, expected %v got %v", tc.expectedSize, img.Bounds())
+		}
+
+		// the debug tile outline is drawn along the edge of the tile
+		if _, _, _, a := img.At(0, tc.expectedSize/2).RGBA(); a == 0 {
+			t.Errorf("expected the tile outline to be drawn")
+		}
+	}
+
+	tests := map[string]tcase{
+		"png": {
+			uri:          "/maps/test-map/10/2/3.png?debug=true",
+			expectedSize: 256,
+		},
+		"png 2x": {
+			uri:          "/maps/test-map/10/2/3@2x.png?debug=true",
+			expectedSize: 512,
+		},
+		"layer png": {
+			uri:          "/maps/test-map/test-layer/10/2/3.png?debug=true",
+			expectedSize: 256,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestHandleMapSVG(t *testing.T) {
+	type tcase struct {
+		uri      string
+		expected []string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		w, _, err := doRequest(nil, "GET", tc.uri, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status code, expected %v got %v: %v", http.StatusOK, w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != server.SVGMimeType {
+			t.Errorf("content type, expected %v got %v", server.SVGMimeType, ct)
+		}
+
+		body := w.Body.String()
+		for _, s := range tc.expected {
+			if !strings.Contains(body, s) {
+				t.Errorf("expected the svg to contain %q", s)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"svg": {
+			uri:      "/maps/test-map/10/2/3.svg",
+			expected: []string{"<svg", `id="tile_boundary"`, `id="tile_buffer"`, `id="layer_test-layer"`},
+		},
+		"svg stages": {
+			uri:      "/maps/test-map/10/2/3.svg?stages=true",
+			expected: []string{`id="tile_boundary"`, `id="stages_test-layer"`},
+		},
+		"layer svg": {
+			uri:      "/maps/test-map/test-layer/10/2/3.svg",
+			expected: []string{`id="layer_test-layer"`},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 
 	"net/http"
 	"strconv"
@@ -47,21 +48,19 @@ func (req HandleMapStyle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// lookup our Map
-	m, err := atlas.GetMap(req.mapName)
+	m, err := lookupMap(nil, req.mapName)
 	if err != nil {
 		log.Errorf("map (%v) not configured. check your config file", req.mapName)
 		http.Error(w, "map ("+req.mapName+") not configured. check your config file", http.StatusNotFound)
 		return
 	}
 
-	var debugQuery string
-	if r.URL.Query().Get("debug") == "true" {
-		debugQuery = "?debug=true"
-
+	debug := r.URL.Query().Get("debug") == "true"
+	if debug {
 		m = m.AddDebugLayers()
 	}
 
-	sourceURL := fmt.Sprintf("%v/capabilities/%v.json%v", URLRoot(r), req.mapName, debugQuery)
+	sourceURL := generatedURL(r, debug, fmt.Sprintf("/capabilities/%v.json", req.mapName))
 
 	mapboxStyle := style.Root{
 		Name:    m.Name,
@@ -104,40 +103,13 @@ func (req HandleMapStyle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// chose our paint type based on the geometry type
-		switch l.GeomType.(type) {
-		case geom.Point, geom.MultiPoint:
-			layer.Type = style.LayerTypeCircle
-			layer.Paint = &style.LayerPaint{
-				CircleRadius: 3,
-				CircleColor:  stringToColorHex(l.MVTName()),
-			}
-		case geom.Line, geom.LineString, geom.MultiLineString:
-			layer.Type = style.LayerTypeLine
-			layer.Paint = &style.LayerPaint{
-				LineColor: stringToColorHex(l.MVTName()),
-			}
-		case geom.Polygon, geom.MultiPolygon:
-			layer.Type = style.LayerTypeFill
-			hexColor := stringToColorHex(l.MVTName())
-
-			hex, err := colors.ParseHEX(hexColor)
-			if err != nil {
-				log.Errorf("error parsing hex color (%v)", hexColor)
-				hex, _ = colors.ParseHEX("#fff") // default to white on error
-			}
-
-			rgba := hex.ToRGBA()
-			// set the opacity to 10%
-			rgba.A = 0.10
-
-			layer.Paint = &style.LayerPaint{
-				FillColor:        rgba.String(),
-				FillOutlineColor: hexColor,
-			}
-		default:
+		layerType, paint := layerPaint(&l, l.GeomType)
+		if paint == nil {
 			log.Infof("unable to infer geometry type for providerLayerName: %v. style definition not generated", l.ProviderLayerName)
 			continue
 		}
+		layer.Type = layerType
+		layer.Paint = paint
 
 		// add our layer to our tile layer response
 		mapboxStyle.Layers = append(mapboxStyle.Layers, layer)
@@ -156,6 +128,76 @@ func (req HandleMapStyle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// layerPaint returns the style layer type and paint of a layer with the geometry type. The paint
+// is generated from the layer name and overridden by the layer's style. The paint is nil if
+// the geometry type is unknown.
+func layerPaint(l *atlas.Layer, geomType geom.Geometry) (string, *style.LayerPaint) {
+	var (
+		layerType string
+		paint     style.LayerPaint
+	)
+
+	switch geomType.(type) {
+	case geom.Point, geom.MultiPoint:
+		layerType = style.LayerTypeCircle
+		paint = style.LayerPaint{
+			CircleRadius: 3,
+			CircleColor:  stringToColorHex(l.MVTName()),
+		}
+	case geom.Line, geom.LineString, geom.MultiLineString:
+		layerType = style.LayerTypeLine
+		paint = style.LayerPaint{
+			LineColor: stringToColorHex(l.MVTName()),
+		}
+	case geom.Polygon, geom.MultiPolygon:
+		layerType = style.LayerTypeFill
+		hexColor := stringToColorHex(l.MVTName())
+
+		hex, err := colors.ParseHEX(hexColor)
+		if err != nil {
+			log.Errorf("error parsing hex color (%v)", hexColor)
+			hex, _ = colors.ParseHEX("#fff") // default to white on error
+		}
+
+		rgba := hex.ToRGBA()
+		// set the opacity to 10%
+		rgba.A = 0.10
+
+		paint = style.LayerPaint{
+			FillColor:        rgba.String(),
+			FillOutlineColor: hexColor,
+		}
+	default:
+		return "", nil
+	}
+
+	if ls := l.Style; ls != nil {
+		switch layerType {
+		case style.LayerTypeCircle:
+			if ls.CircleColor != "" {
+				paint.CircleColor = ls.CircleColor
+			}
+			if ls.CircleRadius > 0 {
+				paint.CircleRadius = uint8(math.Min(ls.CircleRadius, math.MaxUint8))
+			}
+		case style.LayerTypeLine:
+			if ls.LineColor != "" {
+				paint.LineColor = ls.LineColor
+			}
+			paint.LineWidth = ls.LineWidth
+		case style.LayerTypeFill:
+			if ls.FillColor != "" {
+				paint.FillColor = ls.FillColor
+			}
+			if ls.FillOutlineColor != "" {
+				paint.FillOutlineColor = ls.FillOutlineColor
+			}
+		}
+	}
+
+	return layerType, &paint
+}
+
 // port of https://stackoverflow.com/questions/3426404/create-a-hexadecimal-colour-based-on-a-string-with-javascript
 func stringToColorHex(str string) string {
 	var hash uint
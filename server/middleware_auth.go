@@ -0,0 +1,126 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dimfeld/httptreemux"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/server/auth"
+)
+
+// Authenticator authenticates requests for maps, capabilities, styles and the viewer.
+// nil (default) disables authentication.
+// configurable via the tegola config.toml file (set in main.go)
+var Authenticator auth.Authenticator
+
+// AuthHandler authenticates the request using the configured Authenticator. If the
+// route has a :map_name param the client must also be allowed to access the map.
+// Credentials are stripped from the request before it's passed to the next handler
+// so they don't become part of a cache key.
+func AuthHandler(a *atlas.Atlas, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Authenticator == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// RateLimitHandler may have authenticated the request already
+		principal, ok := auth.FromContext(r.Context())
+		var err error
+		if !ok {
+			principal, err = Authenticator.Authenticate(r)
+		}
+		if err != nil {
+			switch err {
+			case auth.ErrNoCredentials:
+				http.Error(w, "authentication required", http.StatusUnauthorized)
+			default:
+				log.Infof("authentication failed for %v: %v", r.URL.Path, err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+			}
+			return
+		}
+
+		// check the client is allowed to access the requested map
+		if mapName := httptreemux.ContextParams(r.Context())["map_name"]; mapName != "" {
+			// trim the extension (i.e. /capabilities/osm.json)
+			mapName = strings.Split(mapName, ".")[0]
+
+			// an unknown map is left for the next handler to report
+			if m, err := a.Map(mapName); err == nil && !principal.Allowed(m.AllowedKeys, m.AllowedClaims) {
+				http.Error(w, "access to map ("+mapName+") forbidden", http.StatusForbidden)
+				return
+			}
+
+			// the client must be allowed to access all the maps of a composite
+			if c, err := a.Composite(mapName); err == nil {
+				for _, name := range c.Maps {
+					if m, err := a.Map(name); err == nil && !principal.Allowed(m.AllowedKeys, m.AllowedClaims) {
+						http.Error(w, "access to map ("+name+") forbidden", http.StatusForbidden)
+						return
+					}
+				}
+			}
+		}
+
+		Authenticator.Strip(r)
+
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+	})
+}
+
+// mapAllowed reports if the client making the request may access the map
+func mapAllowed(r *http.Request, m atlas.Map) bool {
+	if Authenticator == nil {
+		return true
+	}
+
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		return false
+	}
+
+	return principal.Allowed(m.AllowedKeys, m.AllowedClaims)
+}
+
+// compositeAllowed reports if the client making the request may access all the maps of the composite
+func compositeAllowed(r *http.Request, c atlas.Composite) bool {
+	for _, name := range c.Maps {
+		if m, err := atlas.GetMap(name); err == nil && !mapAllowed(r, m) {
+			return false
+		}
+	}
+	return true
+}
+
+// urlQuery builds the query string appended to a URL generated by the capabilities
+// and style endpoints for path. It carries over the debug flag and any credentials
+// the client passed as query parameters which are valid for path.
+func urlQuery(r *http.Request, debug bool, path string) string {
+	query := url.Values{}
+
+	if debug {
+		query.Set("debug", "true")
+	}
+
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		for k, v := range principal.QueryFor(path) {
+			query[k] = v
+		}
+	}
+
+	if len(query) == 0 {
+		return ""
+	}
+
+	return "?" + query.Encode()
+}
+
+// generatedURL returns the URL of path with the query carried over for it
+func generatedURL(r *http.Request, debug bool, path string) string {
+	return URLRoot(r) + path + urlQuery(r, debug, path)
+}
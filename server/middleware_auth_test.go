@@ -0,0 +1,88 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/server"
+	"github.com/go-spatial/tegola/server/auth"
+)
+
+func TestAuthHandler(t *testing.T) {
+	server.Authenticator = auth.APIKey{
+		Keys: map[string]string{
+			"alice": "abc",
+			"bob":   "def",
+		},
+	}
+	defer func() { server.Authenticator = nil }()
+
+	type tcase struct {
+		allowedKeys  []string
+		uri          string
+		expectedCode int
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		testMap := atlas.NewWebMercatorMap(testMapName)
+		testMap.Layers = append(testMap.Layers, testLayer1)
+		testMap.AllowedKeys = tc.allowedKeys
+
+		a := &atlas.Atlas{}
+		a.AddMap(testMap)
+
+		w, _, err := doRequest(a, "GET", tc.uri, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("status code, expected %v got %v: %v", tc.expectedCode, w.Code, w.Body.String())
+		}
+	}
+
+	tests := map[string]tcase{
+		"missing key": {
+			uri:          "http://localhost:8080/maps/test-map/4/1/1.pbf",
+			expectedCode: http.StatusUnauthorized,
+		},
+		"invalid key": {
+			uri:          "http://localhost:8080/maps/test-map/4/1/1.pbf?api_key=nope",
+			expectedCode: http.StatusUnauthorized,
+		},
+		"unrestricted map": {
+			uri:          "http://localhost:8080/maps/test-map/4/1/1.pbf?api_key=def",
+			expectedCode: http.StatusOK,
+		},
+		"allowed key": {
+			allowedKeys:  []string{"alice"},
+			uri:          "http://localhost:8080/maps/test-map/4/1/1.pbf?api_key=abc",
+			expectedCode: http.StatusOK,
+		},
+		"forbidden key": {
+			allowedKeys:  []string{"alice"},
+			uri:          "http://localhost:8080/maps/test-map/4/1/1.pbf?api_key=def",
+			expectedCode: http.StatusForbidden,
+		},
+		"forbidden style": {
+			allowedKeys:  []string{"alice"},
+			uri:          "http://localhost:8080/maps/test-map/style.json?api_key=def",
+			expectedCode: http.StatusForbidden,
+		},
+		"forbidden capabilities": {
+			allowedKeys:  []string{"alice"},
+			uri:          "http://localhost:8080/capabilities/test-map.json?api_key=def",
+			expectedCode: http.StatusForbidden,
+		},
+		"health is public": {
+			uri:          "http://localhost:8080/healthz",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
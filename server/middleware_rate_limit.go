@@ -0,0 +1,213 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/server/auth"
+)
+
+// RateLimit limits the request rate of each client. nil (default) disables rate limiting.
+// configurable via the tegola config.toml file (set in main.go)
+var RateLimit *RateLimiter
+
+// RateLimiter is a token bucket rate limiter keyed by client. A RateLimiter is safe to use concurrently.
+type RateLimiter struct {
+	// TrustedProxies are the proxies (i.e. load balancers) whose X-Forwarded-For header
+	// is used for the client IP address. When empty the header is ignored.
+	TrustedProxies []*net.IPNet
+
+	// tokens added per second
+	rate float64
+	// max tokens a bucket can hold
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	// now returns the current time. used for testing
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing rate requests per second per client,
+// with bursts of up to burst requests. rate must be greater than 0. If burst is less
+// than 1 it's set to 1.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the client's bucket. If the bucket is empty false
+// is returned along with how long until a token is available.
+func (l *RateLimiter) Allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{
+			tokens: l.burst,
+			last:   now,
+		}
+		l.buckets[client] = b
+	}
+
+	// refill the bucket for the time elapsed since the last request
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+
+	b.tokens--
+
+	return true, 0
+}
+
+// sweep removes buckets which have been idle long enough to be full again.
+// sweeps run at most once a minute
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for k, b := range l.buckets {
+		if now.Sub(b.last) > full {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// RateLimitHandler rejects requests with a 429 and a Retry-After header
+// when the client has exceeded the configured RateLimit. It runs before
+// authentication so requests with bad credentials are limited too, by IP
+// address. Authenticated clients are limited by their principal id, and the
+// principal is passed on so AuthHandler doesn't authenticate the request again.
+func RateLimitHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if RateLimit == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client := "ip:" + RateLimit.clientIP(r)
+		if Authenticator != nil {
+			if principal, err := Authenticator.Authenticate(r); err == nil && principal.ID != "" {
+				client = "principal:" + principal.ID
+				r = r.WithContext(auth.NewContext(r.Context(), principal))
+			}
+		}
+
+		if ok, wait := RateLimit.Allow(client); !ok {
+			log.Debugf("rate limit exceeded for client (%v)", client)
+			w.Header().Set("Retry-After", retryAfter(wait))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the IP address of the client. For requests from a trusted proxy the
+// X-Forwarded-For header is read from the right, skipping the trusted proxies, so
+// addresses added by the client itself are ignored.
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !l.trusted(ip) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header["X-Forwarded-For"], ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !l.trusted(hop) {
+			return hop
+		}
+		ip = hop
+	}
+
+	return ip
+}
+
+// trusted reports if ip is one of the TrustedProxies
+func (l *RateLimiter) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range l.TrustedProxies {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies parses IP addresses and CIDR ranges (i.e. 10.0.0.0/8) for RateLimiter.TrustedProxies
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy (%v)", p)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy (%v)", p)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// retryAfter formats a duration as a Retry-After header value in whole seconds
+func retryAfter(d time.Duration) string {
+	secs := int(math.Ceil(d.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	return strconv.Itoa(secs)
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/server/auth"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+
+	l := NewRateLimiter(2, 3)
+	l.now = func() time.Time { return now }
+
+	// the burst is available right away
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %v, expected allowed", i)
+		}
+	}
+
+	ok, wait := l.Allow("a")
+	if ok {
+		t.Fatalf("expected request to be limited")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("wait, expected %v got %v", 500*time.Millisecond, wait)
+	}
+
+	// other clients have their own bucket
+	if ok, _ := l.Allow("b"); !ok {
+		t.Errorf("expected client b to be allowed")
+	}
+
+	// half a second refills a single token at 2 req/s
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Errorf("expected request to be allowed after refill")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Errorf("expected request to be limited")
+	}
+
+	// idle buckets are swept
+	now = now.Add(2 * time.Minute)
+	l.Allow("c")
+	if _, ok := l.buckets["a"]; ok {
+		t.Errorf("expected idle bucket to be swept")
+	}
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	RateLimit = NewRateLimiter(1, 1)
+	defer func() { RateLimit = nil }()
+
+	h := RateLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	expected := []int{http.StatusOK, http.StatusTooManyRequests}
+	for i, code := range expected {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/maps/osm/1/1/1.pbf", nil)
+		h.ServeHTTP(w, r)
+
+		if w.Code != code {
+			t.Errorf("request %v status code, expected %v got %v", i, code, w.Code)
+		}
+		if code == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "1" {
+			t.Errorf("request %v Retry-After, expected 1 got %v", i, w.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestRateLimitBeforeAuth(t *testing.T) {
+	RateLimit = NewRateLimiter(1, 1)
+	Authenticator = auth.APIKey{Keys: map[string]string{"alice": "abc"}}
+	defer func() {
+		RateLimit = nil
+		Authenticator = nil
+	}()
+
+	router := NewRouter(&atlas.Atlas{})
+
+	// failed credentials count against the client's IP address limit
+	expected := []int{http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusOK, http.StatusTooManyRequests}
+	for i, code := range expected {
+		uri := "/capabilities?api_key=nope"
+		if i >= 2 {
+			// valid credentials from the same IP address are limited by their key
+			uri = "/capabilities?api_key=abc"
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", uri, nil)
+		router.ServeHTTP(w, r)
+
+		if w.Code != code {
+			t.Errorf("request %v status code, expected %v got %v", i, code, w.Code)
+		}
+	}
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	type tcase struct {
+		trusted    []string
+		remoteAddr string
+		forwarded  []string
+		expected   string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		trusted, err := ParseTrustedProxies(tc.trusted)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		l := NewRateLimiter(1, 1)
+		l.TrustedProxies = trusted
+
+		r := httptest.NewRequest("GET", "/maps/osm/1/1/1.pbf", nil)
+		r.RemoteAddr = tc.remoteAddr
+		for _, v := range tc.forwarded {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+
+		if got := l.clientIP(r); got != tc.expected {
+			t.Errorf("client ip, expected %v got %v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"remote address": {
+			remoteAddr: "192.0.2.1:1234",
+			expected:   "192.0.2.1",
+		},
+		"forwarded header ignored by default": {
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  []string{"192.0.2.1"},
+			expected:   "10.0.0.1",
+		},
+		"untrusted proxy": {
+			trusted:    []string{"10.0.0.2"},
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  []string{"192.0.2.1"},
+			expected:   "10.0.0.1",
+		},
+		"trusted proxy": {
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  []string{"192.0.2.1"},
+			expected:   "192.0.2.1",
+		},
+		"spoofed address ignored": {
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  []string{"198.51.100.1, 192.0.2.1"},
+			expected:   "192.0.2.1",
+		},
+		"chained proxies": {
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  []string{"192.0.2.1", "10.0.0.5"},
+			expected:   "192.0.2.1",
+		},
+		"only proxies": {
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  []string{"10.0.0.5"},
+			expected:   "10.0.0.5",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
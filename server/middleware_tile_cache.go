@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/cache"
@@ -35,6 +36,12 @@ func TileCacheHandler(a *atlas.Atlas, next http.Handler) http.Handler {
 			return
 		}
 
+		// svg tiles are debugging output and are not cached
+		if strings.HasSuffix(key.Variant, ".svg") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		// use the URL path as the key
 		cachedTile, hit, err := cacher.Get(key)
 		if err != nil {
@@ -72,8 +79,13 @@ func TileCacheHandler(a *atlas.Atlas, next http.Handler) http.Handler {
 		//	cors header
 		w.Header().Set("Access-Control-Allow-Origin", CORSAllowedOrigin)
 
-		// mimetype for mapbox vector tiles
-		w.Header().Add("Content-Type", mvt.MimeType)
+		// png tiles are cached as a variant of the tile
+		if key.Variant != "" {
+			w.Header().Add("Content-Type", PNGMimeType)
+		} else {
+			// mimetype for mapbox vector tiles
+			w.Header().Add("Content-Type", mvt.MimeType)
+		}
 
 		// communicate the cache is being used
 		w.Header().Add("Tegola-Cache", "HIT")
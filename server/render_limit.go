@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	DefaultRenderQueueSize    = 100
+	DefaultRenderQueueTimeout = 10 * time.Second
+)
+
+var (
+	ErrRenderQueueFull    = errors.New("server: render queue full")
+	ErrRenderQueueTimeout = errors.New("server: timed out waiting in render queue")
+)
+
+// RenderLimit caps the number of concurrent tile renders. nil (default) is unlimited.
+// configurable via the tegola config.toml file (set in main.go)
+var RenderLimit *RenderLimiter
+
+// RenderLimiter caps the number of concurrent Map.Encode calls. Requests which
+// can't start rendering right away wait in a bounded queue. A RenderLimiter is
+// safe to use concurrently.
+type RenderLimiter struct {
+	// renders in progress
+	slots chan struct{}
+	// requests waiting for a render slot
+	queue chan struct{}
+	// max time a request can wait in the queue
+	timeout time.Duration
+}
+
+// NewRenderLimiter returns a RenderLimiter allowing max concurrent renders. Up to
+// queueSize requests wait at most timeout for a render slot. A timeout of 0 waits
+// until the request is canceled.
+func NewRenderLimiter(max, queueSize int, timeout time.Duration) *RenderLimiter {
+	if max < 1 {
+		max = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	return &RenderLimiter{
+		slots:   make(chan struct{}, max),
+		queue:   make(chan struct{}, queueSize),
+		timeout: timeout,
+	}
+}
+
+// Acquire blocks until a render slot is available. The returned func must be
+// called to release the slot once rendering is complete. ErrRenderQueueFull is
+// returned if the wait queue is full and ErrRenderQueueTimeout if the timeout
+// elapsed while waiting.
+func (l *RenderLimiter) Acquire(ctx context.Context) (release func(), err error) {
+	release = func() { <-l.slots }
+
+	// fast path, a slot is free
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	// take a spot in the queue
+	select {
+	case l.queue <- struct{}{}:
+		defer func() { <-l.queue }()
+	default:
+		return nil, ErrRenderQueueFull
+	}
+
+	var timeout <-chan time.Time
+	if l.timeout > 0 {
+		timer := time.NewTimer(l.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		return nil, ErrRenderQueueTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// RetryAfter is an estimate of how long a client should wait before retrying
+// a request rejected by the limiter.
+func (l *RenderLimiter) RetryAfter() time.Duration {
+	if l.timeout > 0 {
+		return l.timeout
+	}
+	return time.Second
+}
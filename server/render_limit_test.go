@@ -0,0 +1,46 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/server"
+)
+
+func TestRenderLimiter(t *testing.T) {
+	l := server.NewRenderLimiter(1, 1, 50*time.Millisecond)
+	ctx := context.Background()
+
+	release, err := l.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	// the second request waits in the queue and times out
+	waiting := make(chan error)
+	go func() {
+		_, err := l.Acquire(ctx)
+		waiting <- err
+	}()
+
+	// give the waiting request time to enter the queue
+	time.Sleep(10 * time.Millisecond)
+
+	// the queue is full
+	if _, err := l.Acquire(ctx); err != server.ErrRenderQueueFull {
+		t.Errorf("expected %v got %v", server.ErrRenderQueueFull, err)
+	}
+
+	if err := <-waiting; err != server.ErrRenderQueueTimeout {
+		t.Errorf("expected %v got %v", server.ErrRenderQueueTimeout, err)
+	}
+
+	// once released a slot is available again
+	release()
+	release, err = l.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	release()
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"image/color"
+	"image/png"
+
+	"gopkg.in/go-playground/colors.v1"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/draw/raster"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/mapbox/style"
+	"github.com/go-spatial/tegola/mvt"
+)
+
+// RasterTileSize is the size in pixels of png tiles. @2x tiles (i.e. /maps/osm/1/2/3@2x.png) are twice the size.
+const RasterTileSize = 256
+
+// PNGMimeType is the mimetype of png tiles
+const PNGMimeType = "image/png"
+
+// paintLayers returns the map layers by tile layer name, to look up their paint when rendering
+// a tile. Label point layers are painted like the layer they label.
+func paintLayers(m atlas.Map) map[string]*atlas.Layer {
+	layers := map[string]*atlas.Layer{}
+	for i := range m.Layers {
+		l := &m.Layers[i]
+		if _, ok := layers[l.MVTName()]; !ok {
+			layers[l.MVTName()] = l
+		}
+		if l.LabelPoints != nil {
+			name := l.LabelPoints.LayerName(l)
+			layers[name] = &atlas.Layer{Name: name, Style: l.Style}
+		}
+	}
+	return layers
+}
+
+// renderPNG draws the features of the encoded vector tile as a png of size pixels. The layers are
+// painted like the generated style of the map. The png is gzipped like the vector tiles are.
+func renderPNG(m atlas.Map, tile []byte, size int) ([]byte, error) {
+	vtile, err := mvt.Decode(tile, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := paintLayers(m)
+
+	canvas := raster.NewCanvas(size, size)
+	// styles are sized for 256 pixel tiles
+	ratio := float64(size) / RasterTileSize
+
+	for _, vl := range vtile.Layers() {
+		l, ok := layers[vl.Name]
+		if !ok {
+			l = &atlas.Layer{Name: vl.Name}
+		}
+
+		// tile pixels to image pixels
+		scale := float64(size) / float64(vl.Extent())
+		points := func(line basic.Line) []raster.Point {
+			pts := make([]raster.Point, len(line))
+			for i := range line {
+				pts[i] = raster.Point{line[i][0] * scale, line[i][1] * scale}
+			}
+			return pts
+		}
+
+		for _, f := range vl.Features() {
+			switch g := f.Geometry.(type) {
+			case basic.Point:
+				_, paint := layerPaint(l, geom.Point{})
+				drawPoints(canvas, points(basic.Line{g}), paint, ratio)
+			case basic.MultiPoint:
+				_, paint := layerPaint(l, geom.MultiPoint{})
+				drawPoints(canvas, points(basic.Line(g)), paint, ratio)
+			case basic.Line:
+				_, paint := layerPaint(l, geom.LineString{})
+				drawLine(canvas, points(g), paint, ratio)
+			case basic.MultiLine:
+				_, paint := layerPaint(l, geom.MultiLineString{})
+				for _, line := range g {
+					drawLine(canvas, points(line), paint, ratio)
+				}
+			case basic.Polygon:
+				_, paint := layerPaint(l, geom.Polygon{})
+				var rings [][]raster.Point
+				for _, ring := range g {
+					rings = append(rings, points(ring))
+				}
+				drawPolygon(canvas, rings, paint, ratio)
+			case basic.MultiPolygon:
+				_, paint := layerPaint(l, geom.MultiPolygon{})
+				// the polygons are filled together so their holes are left empty
+				var rings [][]raster.Point
+				for _, poly := range g {
+					for _, ring := range poly {
+						rings = append(rings, points(ring))
+					}
+				}
+				drawPolygon(canvas, rings, paint, ratio)
+			default:
+				log.Debugf("unable to draw geometry type %T of layer (%v)", g, vl.Name)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	if err := png.Encode(gzipWriter, canvas.Image()); err != nil {
+		return nil, err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func drawPoints(canvas *raster.Canvas, pts []raster.Point, paint *style.LayerPaint, ratio float64) {
+	col := paintColor(paint.CircleColor)
+	for _, pt := range pts {
+		canvas.FillCircle(pt, float64(paint.CircleRadius)*ratio, col)
+	}
+}
+
+func drawLine(canvas *raster.Canvas, line []raster.Point, paint *style.LayerPaint, ratio float64) {
+	width := paint.LineWidth
+	// the default width of mapbox gl lines
+	if width == 0 {
+		width = 1
+	}
+	canvas.StrokeLine(line, width*ratio, paintColor(paint.LineColor))
+}
+
+func drawPolygon(canvas *raster.Canvas, rings [][]raster.Point, paint *style.LayerPaint, ratio float64) {
+	canvas.FillPath(rings, paintColor(paint.FillColor))
+
+	outline := paintColor(paint.FillOutlineColor)
+	for _, ring := range rings {
+		canvas.StrokeRing(ring, ratio, outline)
+	}
+}
+
+// paintColor converts a css color of a style paint. Invalid colors are transparent.
+func paintColor(s string) color.NRGBA {
+	c, err := colors.Parse(s)
+	if err != nil {
+		return color.NRGBA{}
+	}
+
+	rgba := c.ToRGBA()
+	return color.NRGBA{R: rgba.R, G: rgba.G, B: rgba.B, A: uint8(rgba.A*255 + 0.5)}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/go-spatial/tegola/atlas"
+)
+
+func TestPaintLayers(t *testing.T) {
+	water := &atlas.LayerStyle{FillColor: "#0000ff"}
+	parks := &atlas.LayerStyle{FillColor: "#00ff00"}
+
+	m := atlas.NewWebMercatorMap("test-map")
+	m.Layers = []atlas.Layer{
+		{Name: "water", Style: water, LabelPoints: &atlas.LabelPoints{}},
+		{Name: "parks", Style: parks, LabelPoints: &atlas.LabelPoints{Name: "park_labels"}},
+		// a later layer with the same tile layer name doesn't override the paint
+		{Name: "water", Style: parks},
+	}
+
+	layers := paintLayers(m)
+
+	expected := map[string]*atlas.LayerStyle{
+		"water":                          water,
+		"water" + atlas.LabelLayerSuffix: water,
+		"parks":                          parks,
+		"park_labels":                    parks,
+	}
+	if len(layers) != len(expected) {
+		t.Fatalf("layers, expected %v got %v", len(expected), len(layers))
+	}
+	for name, s := range expected {
+		l, ok := layers[name]
+		if !ok {
+			t.Errorf("layer %v, expected to be found", name)
+			continue
+		}
+		if l.Style != s {
+			t.Errorf("layer %v style, expected %v got %v", name, s, l.Style)
+		}
+	}
+}
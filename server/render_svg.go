@@ -0,0 +1,201 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/draw/svg"
+	"github.com/go-spatial/tegola/mvt"
+)
+
+// SVGMimeType is the mimetype of svg tiles
+const SVGMimeType = "image/svg+xml"
+
+// the styles of the encoding stages of features
+const (
+	svgStageScaled     = "fill:none;stroke:orange;stroke-dasharray:32,16"
+	svgStageSimplified = "fill:none;stroke:green"
+	svgStageCleaned    = "fill:none;stroke:blue"
+)
+
+// renderSVG draws the features of the encoded vector tile as an svg for debugging. The layers are
+// painted like the generated style of the map, and the tile boundary, its buffer and the feature ids
+// are drawn. The encoding stages of the features are drawn under the tile's layers if there are any.
+// The svg is gzipped like the vector tiles are.
+func renderSVG(m atlas.Map, tile []byte, stages []atlas.LayerStages) ([]byte, error) {
+	vtile, err := mvt.Decode(tile, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	extent := int64(tegola.DefaultExtent)
+	buffer := int64(tegola.DefaultTileBuffer)
+	// a pixel of a 256 pixel tile
+	px := float64(extent) / RasterTileSize
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+
+	canvas := &svg.Canvas{
+		Board:  svg.MinMax{MinX: -buffer, MinY: -buffer, MaxX: extent + buffer, MaxY: extent + buffer},
+		Region: svg.MinMax{MinX: 0, MinY: 0, MaxX: extent, MaxY: extent},
+	}
+	canvas.Init(gzipWriter, 1024, 1024, false)
+
+	canvas.Gid("tile_buffer")
+	canvas.Rect(int(-buffer), int(-buffer), int(extent+2*buffer), int(extent+2*buffer), fmt.Sprintf("fill:none;stroke:gray;stroke-width:%v;stroke-dasharray:%v,%v", px, 4*px, 4*px))
+	canvas.Gend()
+	canvas.Gid("tile_boundary")
+	canvas.Rect(0, 0, int(extent), int(extent), fmt.Sprintf("fill:none;stroke:black;stroke-width:%v", px))
+	canvas.Gend()
+
+	for _, ls := range stages {
+		canvas.Gid(svgID("stages_" + ls.Name))
+		for _, f := range ls.Features {
+			id := fmt.Sprintf("%v_%v", ls.Name, f.ID)
+			canvas.Gid(svgID("stages_" + id))
+			drawSVGGeometry(canvas, f.Scaled, svgID("scaled_"+id), svgStageScaled+fmt.Sprintf(";stroke-width:%v", px), 2*px)
+			drawSVGGeometry(canvas, f.Simplified, svgID("simplified_"+id), svgStageSimplified+fmt.Sprintf(";stroke-width:%v", px), 2*px)
+			if f.Cleaned != nil {
+				drawSVGGeometry(canvas, f.Cleaned, svgID("cleaned_"+id), svgStageCleaned+fmt.Sprintf(";stroke-width:%v", px), 2*px)
+			}
+			canvas.Gend()
+		}
+		canvas.Gend()
+	}
+
+	layers := paintLayers(m)
+
+	for _, vl := range vtile.Layers() {
+		l, ok := layers[vl.Name]
+		if !ok {
+			l = &atlas.Layer{Name: vl.Name}
+		}
+
+		// layers with a different extent are scaled to the tile
+		scale := float64(extent) / float64(vl.Extent())
+		if scale != 1 {
+			canvas.Group(`id="`+svgID("layer_"+vl.Name)+`"`, fmt.Sprintf(`transform="scale(%v)"`, scale))
+		} else {
+			canvas.Gid(svgID("layer_" + vl.Name))
+		}
+
+		for i, f := range vl.Features() {
+			id := fmt.Sprintf("%v_%v", vl.Name, i)
+			if f.ID != nil {
+				id = fmt.Sprintf("%v_%v", vl.Name, *f.ID)
+			}
+
+			svgStyle, radius := svgPaint(l, f.Geometry, px)
+			drawSVGGeometry(canvas, f.Geometry, svgID(id), svgStyle, radius)
+
+			if f.ID != nil {
+				if x, y, ok := firstPoint(f.Geometry); ok {
+					canvas.Text(int(x), int(y), fmt.Sprintf("%v", *f.ID), fmt.Sprintf("font-size:%v;fill:black", 10*px))
+				}
+			}
+		}
+
+		canvas.Gend()
+	}
+
+	canvas.End()
+
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// svgPaint returns the svg style of the geometry in the layer, and the radius of its points
+func svgPaint(l *atlas.Layer, g tegola.Geometry, px float64) (string, float64) {
+	var geomType geom.Geometry
+	switch g.(type) {
+	case tegola.Point, tegola.MultiPoint:
+		geomType = geom.Point{}
+	case tegola.LineString, tegola.MultiLine:
+		geomType = geom.LineString{}
+	case tegola.Polygon, tegola.MultiPolygon:
+		geomType = geom.Polygon{}
+	}
+
+	_, paint := layerPaint(l, geomType)
+	if paint == nil {
+		return "fill:none;stroke:black", px
+	}
+
+	switch geomType.(type) {
+	case geom.Point:
+		return "fill:" + paint.CircleColor, float64(paint.CircleRadius) * px
+	case geom.LineString:
+		width := paint.LineWidth
+		if width == 0 {
+			width = 1
+		}
+		return fmt.Sprintf("fill:none;stroke:%v;stroke-width:%v", paint.LineColor, width*px), px
+	default:
+		return fmt.Sprintf("fill-rule:evenodd;fill:%v;stroke:%v;stroke-width:%v", paint.FillColor, paint.FillOutlineColor, px), px
+	}
+}
+
+// drawSVGGeometry draws the geometry with the style. Points are drawn as circles of the radius.
+func drawSVGGeometry(canvas *svg.Canvas, g tegola.Geometry, id string, svgStyle string, radius float64) {
+	switch g := g.(type) {
+	case tegola.Point:
+		canvas.Circle(int(g.X()), int(g.Y()), int(radius), `id="`+id+`"`, svgStyle)
+	case tegola.MultiPoint:
+		canvas.Gid(id)
+		for _, pt := range g.Points() {
+			canvas.Circle(int(pt.X()), int(pt.Y()), int(radius), svgStyle)
+		}
+		canvas.Gend()
+	default:
+		canvas.DrawGeometry(g, id, svgStyle, svgStyle, false)
+	}
+}
+
+// firstPoint returns the first point of the geometry
+func firstPoint(g tegola.Geometry) (float64, float64, bool) {
+	switch g := g.(type) {
+	case tegola.Point:
+		return g.X(), g.Y(), true
+	case tegola.MultiPoint:
+		if pts := g.Points(); len(pts) > 0 {
+			return pts[0].X(), pts[0].Y(), true
+		}
+	case tegola.LineString:
+		if pts := g.Subpoints(); len(pts) > 0 {
+			return pts[0].X(), pts[0].Y(), true
+		}
+	case tegola.MultiLine:
+		if lines := g.Lines(); len(lines) > 0 {
+			return firstPoint(lines[0])
+		}
+	case tegola.Polygon:
+		if lines := g.Sublines(); len(lines) > 0 {
+			return firstPoint(lines[0])
+		}
+	case tegola.MultiPolygon:
+		if polygons := g.Polygons(); len(polygons) > 0 {
+			return firstPoint(polygons[0])
+		}
+	}
+	return 0, 0, false
+}
+
+// svgID replaces the characters of s which are not letters, digits, - or _ so it can be used as an id
+func svgID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
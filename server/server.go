@@ -16,6 +16,10 @@ const (
 	// MaxTileSize is 500k. Currently just throws a warning when tile
 	// is larger than MaxTileSize
 	MaxTileSize = 500000
+
+	// TileBudgetHeader lists the steps taken to degrade a tile to fit the map's max_tile_bytes.
+	// The steps are not cached, so the header is only set when the tile is rendered (a cache miss).
+	TileBudgetHeader = "Tegola-Tile-Budget"
 )
 
 var (
@@ -56,20 +60,24 @@ func NewRouter(a *atlas.Atlas) *httptreemux.TreeMux {
 	// one handler to respond to all OPTIONS requests for registered routes with our CORS headers
 	r.OptionsHandler = corsHandler
 
+	// health and readiness endpoints
+	group.UsingContext().Handler("GET", "/healthz", HandleHealth{})
+	group.UsingContext().Handler("GET", "/readyz", HandleReadiness{Atlas: a})
+
 	// capabilities endpoints
-	group.UsingContext().Handler("GET", "/capabilities", HeadersHandler(HandleCapabilities{}))
-	group.UsingContext().Handler("GET", "/capabilities/:map_name", HeadersHandler(HandleMapCapabilities{}))
+	group.UsingContext().Handler("GET", "/capabilities", HeadersHandler(RateLimitHandler(AuthHandler(a, HandleCapabilities{}))))
+	group.UsingContext().Handler("GET", "/capabilities/:map_name", HeadersHandler(RateLimitHandler(AuthHandler(a, HandleMapCapabilities{}))))
 
 	// map tiles
 	hMapLayerZXY := HandleMapLayerZXY{Atlas: a}
-	group.UsingContext().Handler("GET", "/maps/:map_name/:z/:x/:y", HeadersHandler(GZipHandler(TileCacheHandler(a, hMapLayerZXY))))
-	group.UsingContext().Handler("GET", "/maps/:map_name/:layer_name/:z/:x/:y", HeadersHandler(GZipHandler(TileCacheHandler(a, hMapLayerZXY))))
+	group.UsingContext().Handler("GET", "/maps/:map_name/:z/:x/:y", HeadersHandler(RateLimitHandler(AuthHandler(a, GZipHandler(TileCacheHandler(a, hMapLayerZXY))))))
+	group.UsingContext().Handler("GET", "/maps/:map_name/:layer_name/:z/:x/:y", HeadersHandler(RateLimitHandler(AuthHandler(a, GZipHandler(TileCacheHandler(a, hMapLayerZXY))))))
 
 	// map style
-	group.UsingContext().Handler("GET", "/maps/:map_name/style.json", HeadersHandler(HandleMapStyle{}))
+	group.UsingContext().Handler("GET", "/maps/:map_name/style.json", HeadersHandler(RateLimitHandler(AuthHandler(a, HandleMapStyle{}))))
 
 	// setup viewer routes, which can be excluded via build flags
-	setupViewer(group)
+	setupViewer(a, group)
 
 	return r
 }
@@ -158,3 +166,19 @@ func corsHandler(w http.ResponseWriter, r *http.Request, params map[string]strin
 	w.Header().Set("Access-Control-Allow-Methods", CORSAllowedMethods)
 	return
 }
+
+// lookupMap returns the map named name. Composite map aliases and comma separated lists
+// of maps are returned as a map describing the composite.
+func lookupMap(a *atlas.Atlas, name string) (atlas.Map, error) {
+	m, err := a.Map(name)
+	if err == nil {
+		return m, nil
+	}
+
+	c, cerr := a.Composite(name)
+	if cerr != nil {
+		return m, err
+	}
+
+	return a.CompositeMap(c)
+}
@@ -7,12 +7,13 @@ import (
 
 	"github.com/dimfeld/httptreemux"
 
+	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/server/bindata"
 )
 
 // setupViewer in this file is used for reigstering the viewer routes when the viewer
 // is included in the build (default)
-func setupViewer(group *httptreemux.Group) {
-	group.UsingContext().Handler("GET", "/", http.FileServer(bindata.AssetFileSystem()))
-	group.UsingContext().Handler("GET", "/*path", http.FileServer(bindata.AssetFileSystem()))
+func setupViewer(a *atlas.Atlas, group *httptreemux.Group) {
+	group.UsingContext().Handler("GET", "/", AuthHandler(a, http.FileServer(bindata.AssetFileSystem())))
+	group.UsingContext().Handler("GET", "/*path", AuthHandler(a, http.FileServer(bindata.AssetFileSystem())))
 }
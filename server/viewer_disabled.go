@@ -2,8 +2,12 @@
 
 package server
 
-import "github.com/dimfeld/httptreemux"
+import (
+	"github.com/dimfeld/httptreemux"
+
+	"github.com/go-spatial/tegola/atlas"
+)
 
 // setupViewer in this file is used for removing the viewer routes when the
 // build flag `noViewer` is set
-func setupViewer(group *httptreemux.Group) {}
+func setupViewer(a *atlas.Atlas, group *httptreemux.Group) {}